@@ -13,14 +13,21 @@ import (
 )
 
 type appFlags struct {
-	InputFile          string     `json:"InputFile"`
-	LogLevel           *LevelFlag `json:"LogLevel"`
-	CacheFile          string
-	KeepCacheFile      bool
+	Command        string
+	InputFile      string     `json:"InputFile"`
+	LogLevel       *LevelFlag `json:"LogLevel"`
+	CacheFile      string
+	KeepCacheFile  bool
+	IndexOnly      bool
+	Filter         string
+	PositionalArgs []string
+	Version        bool
+	CpuProfile     string
+	Format         string
+
+	// DumpRemotePayloads is the deprecated predecessor of the "harvests"
+	// command, which it is forwarded to.
 	DumpRemotePayloads bool
-	PositionalArgs     []string
-	Version            bool
-	CpuProfile         string
 }
 
 func (a *appFlags) String() string {
@@ -40,9 +47,40 @@ var usageText = heredoc.Doc(`
 	This tool is used to process and explore agent logs generated by the Node.js
 	New Relic instrumentation agent.
 
+	Usage:
+	  nrlv [flags] [newrelic_agent.log]
+	  nrlv sql [flags] [newrelic_agent.log]
+	  nrlv harvests [flags] [newrelic_agent.log]
+	  nrlv instrumentation [flags] [newrelic_agent.log]
+	  nrlv doctor [flags] [newrelic_agent.log]
+
+	The "sql" command opens a console for running SQL statements against the
+	parsed logs instead of showing the UI.
+
+	The "harvests" command prints the timeline of harvests, i.e. the data sent
+	to the collector, with their durations and outcomes instead of showing the
+	UI.
+
+	The "instrumentation" command prints the inventory of the modules that the
+	agent instrumented, along with any issue logged while instrumenting them,
+	instead of showing the UI.
+
+	The "doctor" command runs a set of checks over the parsed logs, e.g. whether
+	the agent started and whether the collector rejected it, and prints a report
+	of the problems found instead of showing the UI. Each finding lists the row
+	ids of the lines that show it, which can be looked up with the "sql"
+	command.
+
 	The following flags are supported:
 `)
 
+// commands lists the subcommands that may be given as the first positional
+// argument.
+var commands = []string{"sql", "harvests", "instrumentation", "doctor"}
+
+// doctorFormats lists the output formats of the "doctor" command.
+var doctorFormats = []string{"text", "json", "markdown"}
+
 func createAndParseFlags(args []string) error {
 	flagSet := flag.NewFlagSet("", flag.ContinueOnError)
 	flagSet.Usage = func() {
@@ -87,16 +125,35 @@ func createAndParseFlags(args []string) error {
 	)
 
 	flagSet.BoolVar(
-		&flags.DumpRemotePayloads,
-		"dump-remote-payloads",
+		&flags.IndexOnly,
+		"index-only",
 		false,
 		heredoc.Doc(`
-			Filters the provided log file down to only the logs around sending data
-			to the remote collector. No UI will be shown. The logs will be written
-			to stdout as NDJSON.
+			Store only the location of each line within the log file, instead of a
+			copy of each line, in the cache file. This greatly reduces the size of
+			the cache file for large logs, but the log file must be kept, unchanged,
+			for as long as the cache file is used.
+		`),
+	)
+
+	flagSet.StringVar(
+		&flags.Filter,
+		"filter",
+		"",
+		heredoc.Doc(`
+			Name of a saved filter to apply to the lines when the viewer starts.
+			Saved filters are read from the user's filters.yaml config file, the
+			cache file, and the defaults shipped with the application.
 		`),
 	)
 
+	flagSet.StringVar(
+		&flags.Format,
+		"format",
+		"text",
+		"Output format of the doctor command, one of: "+strings.Join(doctorFormats, ", ")+".",
+	)
+
 	flagSet.BoolVarP(
 		&flags.Version,
 		"version",
@@ -116,16 +173,39 @@ func createAndParseFlags(args []string) error {
 	)
 	flagSet.MarkHidden("cpuprofile")
 
-	// TODO: add a force-parse flag that will purge any cache and force parsing of the log file
+	flagSet.BoolVar(
+		&flags.DumpRemotePayloads,
+		"dump-remote-payloads",
+		false,
+		"Prints the harvests sent to the remote collector. Use the harvests command instead.",
+	)
+	flagSet.MarkDeprecated("dump-remote-payloads", "use the harvests command instead")
 
-	// TODO: add a flag that will filter messages based on log type, e.g. "error" logs
+	// TODO: add a force-parse flag that will purge any cache and force parsing of the log file
 
 	err := flagSet.Parse(args[1:])
 	if err != nil {
 		return err
 	}
 
+	if arrutil.HasValue(doctorFormats, flags.Format) == false {
+		return fmt.Errorf("format must be one of: %s", strings.Join(doctorFormats, ", "))
+	}
+
 	flags.PositionalArgs = flagSet.Args()
+	if len(flags.PositionalArgs) > 0 && arrutil.HasValue(commands, flags.PositionalArgs[0]) {
+		flags.Command = flags.PositionalArgs[0]
+		flags.PositionalArgs = flags.PositionalArgs[1:]
+	}
+	if flags.DumpRemotePayloads == true && flags.Command == "" {
+		flags.Command = "harvests"
+	}
+
+	// Index only caches read lines back from the log file, so there must be a
+	// file to read them from.
+	if flags.IndexOnly == true && flags.InputFile == "" && len(flags.PositionalArgs) == 0 {
+		return fmt.Errorf("--index-only requires the path of a log file, it cannot index standard input")
+	}
 	return nil
 }
 
@@ -0,0 +1,82 @@
+package common
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// SplitFieldPath splits a field path into its segments. Segments are
+// separated by dots, and array indexes may be written either as a segment or
+// in brackets. For example, `data[0].high_security`, `data.0.high_security`,
+// and `app_name.0` are all valid paths.
+func SplitFieldPath(path string) []string {
+	path = strings.ReplaceAll(path, "[", ".")
+	path = strings.ReplaceAll(path, "]", "")
+	segments := make([]string, 0)
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "" {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	return segments
+}
+
+// LookupField walks the decoded JSON document according to the field path,
+// see [SplitFieldPath], and returns the value found at the end of the path.
+//
+// Agent logs frequently include attributes whose value is a JSON document
+// that has been serialized to a string, e.g. the `data` attribute of
+// `remote_method` lines. When the path continues past such a string, the
+// string is decoded and the lookup continues within the decoded document.
+// Collector payloads are also commonly wrapped in a single element array, so
+// a non-index segment applied to such an array is looked up within its only
+// element. Thus, `data.utilization.hostname` is equivalent to
+// `data.0.utilization.hostname` for the `connect` payload.
+func LookupField(document any, path string) (any, bool) {
+	current := document
+	for _, segment := range SplitFieldPath(path) {
+		if encoded, isString := current.(string); isString {
+			var decoded any
+			err := json.Unmarshal([]byte(encoded), &decoded)
+			if err != nil {
+				return nil, false
+			}
+			current = decoded
+		}
+
+		switch value := current.(type) {
+		case map[string]any:
+			next, found := value[segment]
+			if found == false {
+				return nil, false
+			}
+			current = next
+
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil && len(value) == 1 {
+				element, isMap := value[0].(map[string]any)
+				if isMap == false {
+					return nil, false
+				}
+				next, found := element[segment]
+				if found == false {
+					return nil, false
+				}
+				current = next
+				continue
+			}
+			if err != nil || index < 0 || index >= len(value) {
+				return nil, false
+			}
+			current = value[index]
+
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
@@ -0,0 +1,47 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gookit/goutil/testutil/assert"
+)
+
+func Test_SplitFieldPath(t *testing.T) {
+	assert.Equal(t, []string{"data", "0", "high_security"}, SplitFieldPath("data[0].high_security"))
+	assert.Equal(t, []string{"app_name", "0"}, SplitFieldPath("app_name.0"))
+	assert.Equal(t, []string{"rss"}, SplitFieldPath("rss"))
+}
+
+func Test_LookupField(t *testing.T) {
+	var document any
+	err := json.Unmarshal([]byte(`{
+		"msg": "Invoking remote method",
+		"app_name": ["first", "second"],
+		"data": "[{\"high_security\":false,\"utilization\":{\"hostname\":\"foo\"}}]"
+	}`), &document)
+	assert.NoErr(t, err)
+
+	value, found := LookupField(document, "app_name.1")
+	assert.True(t, found)
+	assert.Equal(t, "second", value)
+
+	value, found = LookupField(document, "data[0].high_security")
+	assert.True(t, found)
+	assert.Equal(t, false, value)
+
+	value, found = LookupField(document, "data.0.utilization.hostname")
+	assert.True(t, found)
+	assert.Equal(t, "foo", value)
+
+	value, found = LookupField(document, "data.utilization.hostname")
+	assert.True(t, found)
+	assert.Equal(t, "foo", value)
+
+	_, found = LookupField(document, "app_name.first")
+	assert.False(t, found)
+	_, found = LookupField(document, "msg.0")
+	assert.False(t, found)
+	_, found = LookupField(document, "missing")
+	assert.False(t, found)
+}
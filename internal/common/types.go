@@ -33,6 +33,22 @@ const (
 	TypeExtraAttributes
 )
 
+// TypeName returns a short name for the line type, e.g. "error".
+func TypeName(t Type) string {
+	switch t {
+	case TypeDataIncluded:
+		return "data"
+	case TypeEmbeddedDataIncluded:
+		return "embedded_data"
+	case TypeError:
+		return "error"
+	case TypeExtraAttributes:
+		return "attributes"
+	default:
+		return "message"
+	}
+}
+
 type LogLevel interface {
 	IsDebug() bool
 	IsError() bool
@@ -0,0 +1,208 @@
+// Package console implements an interactive SQL console for the cache file.
+package console
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/log"
+)
+
+const (
+	prompt             = "nrlv> "
+	continuationPrompt = "  ...> "
+)
+
+var helpText = strings.TrimSpace(`
+Enter SQL statements terminated by a semicolon. The cached lines are stored in
+the "logs" table. The following commands are also available:
+
+  .functions      list the helper functions available to statements
+  .help           show this message
+  .quit, .exit    exit the console
+  .schema [name]  show the statements that created the cache's tables
+  .tables         list the cache's tables
+`)
+
+// Console reads SQL statements from an input, runs them against the cache,
+// and writes the results to an output as a table.
+type Console struct {
+	db          *database.LogsDatabase
+	input       io.Reader
+	output      io.Writer
+	interactive bool
+	logger      *log.Logger
+}
+
+type Params struct {
+	Database *database.LogsDatabase
+	Input    io.Reader
+	Output   io.Writer
+
+	// Interactive indicates that the input is a terminal. When set, prompts
+	// are written to the output before each line is read.
+	Interactive bool
+
+	Logger *log.Logger
+}
+
+func New(params Params) *Console {
+	return &Console{
+		db:          params.Database,
+		input:       params.Input,
+		output:      params.Output,
+		interactive: params.Interactive,
+		logger:      params.Logger,
+	}
+}
+
+// Run reads and executes statements until the input is exhausted or the user
+// quits. Errors from individual statements are written to the output and do
+// not stop the console.
+func (c *Console) Run() error {
+	if c.interactive == true {
+		fmt.Fprintln(c.output, `Enter ".help" for usage hints.`)
+	}
+
+	scanner := bufio.NewScanner(c.input)
+	scanner.Buffer(make([]byte, 0, 64*1_024), 1_024*1_024)
+	statement := &strings.Builder{}
+
+	for {
+		if c.interactive == true {
+			if statement.Len() == 0 {
+				fmt.Fprint(c.output, prompt)
+			} else {
+				fmt.Fprint(c.output, continuationPrompt)
+			}
+		}
+
+		if scanner.Scan() == false {
+			break
+		}
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if statement.Len() == 0 && strings.HasPrefix(trimmed, ".") {
+			if c.command(trimmed) == false {
+				return nil
+			}
+			continue
+		}
+		if statement.Len() == 0 && trimmed == "" {
+			continue
+		}
+
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			c.execute(statement.String())
+			statement.Reset()
+		}
+	}
+
+	if statement.Len() > 0 {
+		c.execute(statement.String())
+	}
+	if c.interactive == true {
+		fmt.Fprintln(c.output)
+	}
+
+	return scanner.Err()
+}
+
+// command runs a console command, e.g. `.tables`. It returns false when the
+// console should exit.
+func (c *Console) command(input string) bool {
+	fields := strings.Fields(input)
+	c.logger.Trace("running console command", "command", fields[0])
+
+	switch fields[0] {
+	case ".quit", ".exit":
+		return false
+
+	case ".help":
+		fmt.Fprintln(c.output, helpText)
+
+	case ".functions":
+		writer := tabwriter.NewWriter(c.output, 0, 4, 2, ' ', 0)
+		for _, function := range database.SQLFunctions {
+			fmt.Fprintf(writer, "%s\t%s\n", function.Signature, function.Description)
+		}
+		writer.Flush()
+
+	case ".tables":
+		c.execute(`
+			select name from sqlite_schema
+			where type in ('table', 'view') and name not like 'sqlite_%'
+			order by name
+		`)
+
+	case ".schema":
+		where := ""
+		if len(fields) > 1 {
+			where = fmt.Sprintf("and name = '%s'", strings.ReplaceAll(fields[1], "'", "''"))
+		}
+		result, err := c.db.RunSQL(
+			context.Background(),
+			fmt.Sprintf(`select sql from sqlite_schema where sql is not null %s order by rowid`, where),
+			0,
+		)
+		if err != nil {
+			c.writeError(err)
+			break
+		}
+		for _, row := range result.Rows {
+			fmt.Fprintf(c.output, "%s;\n", row[0])
+		}
+
+	default:
+		fmt.Fprintf(c.output, "unknown command: %s (enter \".help\" for usage hints)\n", fields[0])
+	}
+
+	return true
+}
+
+func (c *Console) execute(statement string) {
+	c.logger.Debug("running console statement", "statement", statement)
+	result, err := c.db.RunSQL(context.Background(), statement, 0)
+	if err != nil {
+		c.writeError(err)
+		return
+	}
+	WriteResult(c.output, result)
+}
+
+func (c *Console) writeError(err error) {
+	fmt.Fprintf(c.output, "error: %v\n", err)
+}
+
+// WriteResult writes the result set to the output as an aligned table
+// followed by a count of the rows.
+func WriteResult(output io.Writer, result *database.SQLResult) {
+	if len(result.Columns) == 0 {
+		return
+	}
+
+	writer := tabwriter.NewWriter(output, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(result.Columns, "\t"))
+	for _, row := range result.Rows {
+		cells := make([]string, 0, len(row))
+		for _, cell := range row {
+			cells = append(cells, strings.ReplaceAll(cell, "\n", `\n`))
+		}
+		fmt.Fprintln(writer, strings.Join(cells, "\t"))
+	}
+	writer.Flush()
+
+	suffix := "s"
+	if len(result.Rows) == 1 {
+		suffix = ""
+	}
+	fmt.Fprintf(output, "(%d row%s)\n", len(result.Rows), suffix)
+}
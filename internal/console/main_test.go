@@ -0,0 +1,93 @@
+package console
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/log"
+	v0 "github.com/newrelic/node-log-viewer/internal/v0"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var nullLogger = log.NewDiscardLogger()
+
+var testLines = []string{
+	`{"v":0,"level":30,"name":"newrelic","hostname":"localhost","pid":1,"time":"2025-03-06T18:08:04.184Z","msg":"Starting","component":"api"}`,
+	`{"v":0,"level":40,"name":"newrelic","hostname":"localhost","pid":1,"time":"2025-03-06T18:08:05.184Z","msg":"Calling connect","component":"remote_method","data":"[{\"utilization\":{\"hostname\":\"web-1\"}}]"}`,
+}
+
+func newTestDb(t *testing.T) *database.LogsDatabase {
+	testDb, err := database.New(database.DbParams{
+		DatabaseFilePath: "file::memory:",
+		DoMigration:      true,
+		Logger:           nullLogger,
+	})
+	require.Nil(t, err)
+	t.Cleanup(testDb.Close)
+
+	for _, line := range testLines {
+		var envelope *v0.LineEnvelope
+		require.Nil(t, json.Unmarshal([]byte(line), &envelope))
+		require.Nil(t, testDb.Insert(database.InsertTuple{ParsedLog: envelope, Source: line}))
+	}
+	return testDb
+}
+
+func TestConsole(t *testing.T) {
+	t.Run("runs statements and renders results", func(t *testing.T) {
+		output := &strings.Builder{}
+		console := New(Params{
+			Database: newTestDb(t),
+			Input: strings.NewReader(strings.Join([]string{
+				"select rowid, nr_level_name(level) as level,",
+				"  nr_field(original, 'data.utilization.hostname') as host",
+				"from logs order by rowid;",
+				"select * from nope;",
+			}, "\n")),
+			Output: output,
+			Logger: nullLogger,
+		})
+
+		require.Nil(t, console.Run())
+		expected := strings.Join([]string{
+			"rowid  level  host",
+			"1      Info   NULL",
+			"2      Warn   web-1",
+			"(2 rows)",
+			"error: SQL logic error: no such table: nope (1)",
+			"",
+		}, "\n")
+		assert.Equal(t, expected, output.String())
+	})
+
+	t.Run("runs commands and stops on quit", func(t *testing.T) {
+		output := &strings.Builder{}
+		console := New(Params{
+			Database: newTestDb(t),
+			Input:    strings.NewReader(".tables\n.bogus\n.quit\nselect 1;\n"),
+			Output:   output,
+			Logger:   nullLogger,
+		})
+
+		require.Nil(t, console.Run())
+		assert.Contains(t, output.String(), "saved_filters\n")
+		assert.Contains(t, output.String(), "unknown command: .bogus")
+		assert.NotContains(t, output.String(), "(1 row)")
+	})
+
+	t.Run("runs an unterminated final statement", func(t *testing.T) {
+		output := &strings.Builder{}
+		console := New(Params{
+			Database: newTestDb(t),
+			Input:    strings.NewReader("select count(*) as lines from logs"),
+			Output:   output,
+			Logger:   nullLogger,
+		})
+
+		require.Nil(t, console.Run())
+		assert.Equal(t, "lines\n2\n(1 row)\n", output.String())
+	})
+}
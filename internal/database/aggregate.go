@@ -0,0 +1,234 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Facet is a promoted column of the `logs` table that lines may be grouped,
+// or filtered, by.
+type Facet string
+
+const (
+	FacetComponent Facet = "component"
+	FacetLevel     Facet = "level"
+	FacetPid       Facet = "pid"
+	FacetHostname  Facet = "hostname"
+	// FacetSession is the agent session of a line, see [LogsDatabase.Sessions].
+	FacetSession Facet = "session_id"
+)
+
+// Facets lists the supported facets in the order they should be presented to
+// the user.
+var Facets = []Facet{FacetComponent, FacetLevel, FacetPid, FacetHostname, FacetSession}
+
+func (f Facet) isValid() bool {
+	switch f {
+	case FacetComponent, FacetLevel, FacetPid, FacetHostname, FacetSession:
+		return true
+	}
+	return false
+}
+
+// Aggregation describes how the lines of a query should be counted.
+type Aggregation struct {
+	// GroupBy is the facet to group lines by. When empty, lines are only
+	// grouped into time buckets.
+	GroupBy Facet
+
+	// Bucket, when greater than zero, groups lines into time buckets of the
+	// given size, e.g. one minute. Buckets are aligned to the Unix epoch.
+	Bucket time.Duration
+}
+
+// AggregateRow is the number of lines for one group of an [Aggregation].
+type AggregateRow struct {
+	// Value is the value of the grouped facet. It is empty when the
+	// aggregation is not grouped by a facet.
+	Value string
+
+	// BucketStart is the start of the time bucket. It is the zero time when
+	// the aggregation is not bucketed by time.
+	BucketStart time.Time
+
+	Count int
+}
+
+// Aggregate counts the lines matching the query's filter according to the
+// given aggregation. When grouped only by a facet, the rows are ordered by
+// descending count. Otherwise, the rows are ordered by time bucket and then by
+// descending count. Context lines are not included in the counts.
+func (q *Query) Aggregate(ctx context.Context, aggregation Aggregation) ([]AggregateRow, error) {
+	if aggregation.GroupBy == "" && aggregation.Bucket <= 0 {
+		return nil, fmt.Errorf("aggregation must group by a facet, a time bucket, or both")
+	}
+	if aggregation.GroupBy != "" && aggregation.GroupBy.isValid() == false {
+		return nil, fmt.Errorf("unsupported facet: %s", aggregation.GroupBy)
+	}
+
+	valueColumn := "''"
+	if aggregation.GroupBy != "" {
+		valueColumn = fmt.Sprintf("coalesce(cast(%s as text), '')", aggregation.GroupBy)
+	}
+
+	bucketColumn := "0"
+	orderBy := "count(*) desc, value"
+	if aggregation.Bucket > 0 {
+		bucketColumn = bucketExpression(aggregation.Bucket)
+		orderBy = "bucket, count(*) desc, value"
+	}
+
+	where := ""
+	if q.filter != "" {
+		where = "where " + q.filter
+	}
+
+	statement := fmt.Sprintf(
+		`
+			select %s as value, %s as bucket, count(*) as num_lines
+			from logs
+			%s
+			group by value, bucket
+			order by %s
+		`,
+		valueColumn,
+		bucketColumn,
+		where,
+		orderBy,
+	)
+
+	rows, err := q.db.Connection.QueryContext(ctx, statement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate lines: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]AggregateRow, 0)
+	for rows.Next() {
+		var row AggregateRow
+		var bucket sql.NullInt64
+		err = rows.Scan(&row.Value, &bucket, &row.Count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan aggregate row: %w", err)
+		}
+		if aggregation.Bucket > 0 && bucket.Valid {
+			row.BucketStart = time.Unix(bucket.Int64, 0).UTC()
+		}
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+// bucketExpression renders an expression for the start, in Unix seconds, of
+// the time bucket of the given size that a line belongs to. Buckets are at
+// least one second.
+func bucketExpression(size time.Duration) string {
+	seconds := int64(size / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return fmt.Sprintf("(unixepoch(time) / %[1]d) * %[1]d", seconds)
+}
+
+// TimeBucket summarizes the lines logged within a time bucket.
+type TimeBucket struct {
+	Start time.Time
+	Count int
+
+	// Levels is the number of lines per numeric level, e.g. 30 for info.
+	Levels map[int]int
+
+	// Components are the components that logged the most lines within the
+	// bucket, ordered by descending count.
+	Components []AggregateRow
+
+	// FirstError is the message of the first line logged at the error level,
+	// or above, within the bucket. It is empty when there is no such line.
+	FirstError string
+}
+
+// TimeBuckets summarizes the lines matching the query's filter per time bucket
+// of the given size. At most maxComponents components are listed per bucket.
+// Buckets without lines are omitted, and the buckets are ordered by time.
+// Context lines are not included.
+func (q *Query) TimeBuckets(ctx context.Context, size time.Duration, maxComponents int) ([]TimeBucket, error) {
+	levelRows, err := q.Aggregate(ctx, Aggregation{GroupBy: FacetLevel, Bucket: size})
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]TimeBucket, 0)
+	indexes := make(map[time.Time]int)
+	for _, row := range levelRows {
+		index, found := indexes[row.BucketStart]
+		if found == false {
+			index = len(buckets)
+			indexes[row.BucketStart] = index
+			buckets = append(buckets, TimeBucket{
+				Start:  row.BucketStart,
+				Levels: make(map[int]int),
+			})
+		}
+
+		bucket := &buckets[index]
+		bucket.Count += row.Count
+		level, err := strconv.Atoi(row.Value)
+		if err == nil {
+			bucket.Levels[level] += row.Count
+		}
+	}
+
+	componentRows, err := q.Aggregate(ctx, Aggregation{GroupBy: FacetComponent, Bucket: size})
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range componentRows {
+		index, found := indexes[row.BucketStart]
+		if found == false || len(buckets[index].Components) >= maxComponents {
+			continue
+		}
+		buckets[index].Components = append(buckets[index].Components, row)
+	}
+
+	where := "where level >= 50"
+	if q.filter != "" {
+		where += " and (" + q.filter + ")"
+	}
+	rows, err := q.db.Connection.QueryContext(ctx, fmt.Sprintf(
+		`
+			select bucket, message from (
+				select
+					%s as bucket,
+					coalesce(message, '') as message,
+					row_number() over (partition by %[1]s order by rowid) as n
+				from logs
+				%s
+			)
+			where n = 1
+		`,
+		bucketExpression(size),
+		where,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for first errors: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucket int64
+		var message string
+		err = rows.Scan(&bucket, &message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan first error: %w", err)
+		}
+		if index, found := indexes[time.Unix(bucket, 0).UTC()]; found {
+			buckets[index].FirstError = message
+		}
+	}
+
+	return buckets, rows.Err()
+}
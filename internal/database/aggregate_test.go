@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregate(t *testing.T) {
+	testDb := openTestDbCopy(t)
+
+	t.Run("groups all lines by a facet", func(t *testing.T) {
+		query := SelectAllQuery(testDb, nullLogger)
+		rows, err := query.Aggregate(context.Background(), Aggregation{GroupBy: FacetLevel})
+		require.Nil(t, err)
+		assert.Equal(t, []AggregateRow{
+			{Value: "20", Count: 5_458},
+			{Value: "10", Count: 2_525},
+			{Value: "30", Count: 97},
+			{Value: "40", Count: 11},
+			{Value: "50", Count: 1},
+		}, rows)
+
+		rows, err = query.Aggregate(context.Background(), Aggregation{GroupBy: FacetPid})
+		require.Nil(t, err)
+		assert.Equal(t, 9, len(rows))
+		assert.Equal(t, AggregateRow{Value: "99445", Count: 7_996}, rows[0])
+	})
+
+	t.Run("groups filtered lines into time buckets", func(t *testing.T) {
+		filter := Filter{Components: []string{"remote_method"}}
+		query := filter.Query(testDb, nullLogger)
+		rows, err := query.Aggregate(context.Background(), Aggregation{Bucket: time.Minute})
+		require.Nil(t, err)
+		assert.Equal(t, 50, len(rows))
+		assert.Equal(t, AggregateRow{
+			BucketStart: time.Date(2025, 3, 6, 18, 8, 0, 0, time.UTC),
+			Count:       20,
+		}, rows[0])
+
+		rows, err = query.Aggregate(context.Background(), Aggregation{GroupBy: FacetComponent, Bucket: time.Minute})
+		require.Nil(t, err)
+		assert.Equal(t, 50, len(rows))
+		assert.Equal(t, "remote_method", rows[0].Value)
+	})
+
+	t.Run("selected groups can be applied as filters", func(t *testing.T) {
+		filter := Filter{}.WithCondition(FacetPid, "19573")
+		assert.Equal(t, 23, filter.Query(testDb, nullLogger).NumRows())
+
+		since := time.Date(2025, 3, 6, 18, 8, 0, 0, time.UTC)
+		filter = Filter{Components: []string{"remote_method"}}.
+			WithTimeRange(since, since.Add(time.Minute))
+		assert.Equal(t, 20, filter.Query(testDb, nullLogger).NumRows())
+	})
+
+	t.Run("summarizes lines per time bucket", func(t *testing.T) {
+		query := SelectAllQuery(testDb, nullLogger)
+		buckets, err := query.TimeBuckets(context.Background(), time.Minute, 2)
+		require.Nil(t, err)
+
+		total := 0
+		errors := make([]TimeBucket, 0)
+		for i, bucket := range buckets {
+			total += bucket.Count
+			levels := 0
+			for _, count := range bucket.Levels {
+				levels += count
+			}
+			assert.Equal(t, bucket.Count, levels)
+			assert.LessOrEqual(t, len(bucket.Components), 2)
+			if i > 0 {
+				assert.True(t, buckets[i-1].Start.Before(bucket.Start))
+			}
+			if bucket.FirstError != "" {
+				errors = append(errors, bucket)
+			}
+		}
+		assert.Equal(t, 8_092, total)
+		require.Equal(t, 1, len(errors))
+		assert.Equal(t, 1, errors[0].Levels[50])
+		assert.Equal(t, "Agent endpoint metric_data returned 409 status. Restarting.", errors[0].FirstError)
+
+		rows, err := query.Aggregate(context.Background(), Aggregation{Bucket: time.Minute})
+		require.Nil(t, err)
+		require.Equal(t, len(rows), len(buckets))
+		assert.Equal(t, rows[0].BucketStart, buckets[0].Start)
+		assert.Equal(t, rows[0].Count, buckets[0].Count)
+
+		filter := Filter{Components: []string{"remote_method"}}
+		buckets, err = filter.Query(testDb, nullLogger).TimeBuckets(context.Background(), time.Minute, 3)
+		require.Nil(t, err)
+		assert.Equal(t, 50, len(buckets))
+		assert.Equal(t, time.Date(2025, 3, 6, 18, 8, 0, 0, time.UTC), buckets[0].Start)
+		assert.Equal(t, 20, buckets[0].Count)
+		assert.Equal(t, []AggregateRow{{
+			Value:       "remote_method",
+			BucketStart: buckets[0].Start,
+			Count:       20,
+		}}, buckets[0].Components)
+	})
+
+	t.Run("rejects unknown facets", func(t *testing.T) {
+		query := SelectAllQuery(testDb, nullLogger)
+		_, err := query.Aggregate(context.Background(), Aggregation{GroupBy: Facet("original")})
+		assert.NotNil(t, err)
+		_, err = query.Aggregate(context.Background(), Aggregation{})
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AnnotationField is the name of the field added to exported lines that have
+// been bookmarked.
+const AnnotationField = "nrlv_annotation"
+
+// Bookmark marks a log line of interest, optionally with a note describing
+// why the line is of interest.
+type Bookmark struct {
+	LogId   int
+	Note    string
+	Created time.Time
+
+	// Time, Component, and Message describe the bookmarked line. They are only
+	// populated when listing bookmarks.
+	Time      time.Time
+	Component string
+	Message   string
+}
+
+// Annotate adds the bookmark's note to the original source line as an extra
+// field, see [AnnotationField]. Lines that are not JSON objects are returned
+// unmodified.
+func (b Bookmark) Annotate(original string) string {
+	trimmed := strings.TrimRight(original, " \t\r\n")
+	if strings.HasSuffix(trimmed, "}") == false {
+		return original
+	}
+
+	note, _ := json.Marshal(b.Note)
+	separator := ","
+	if strings.HasSuffix(strings.TrimSpace(trimmed[:len(trimmed)-1]), "{") {
+		separator = ""
+	}
+	return fmt.Sprintf(`%s%s"%s":%s}`, trimmed[:len(trimmed)-1], separator, AnnotationField, note)
+}
+
+// Bookmarks returns all bookmarked lines ordered by their position in the log.
+func (l *LogsDatabase) Bookmarks() ([]Bookmark, error) {
+	rows, err := l.Connection.Query(`
+		select
+			bookmarks.log_id, bookmarks.note, bookmarks.created,
+			coalesce(logs.time, ''), coalesce(logs.component, ''), coalesce(logs.message, '')
+		from bookmarks
+		left join logs on logs.rowid = bookmarks.log_id
+		order by bookmarks.log_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for bookmarks: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]Bookmark, 0)
+	for rows.Next() {
+		var bookmark Bookmark
+		var created string
+		var logged string
+		err = rows.Scan(
+			&bookmark.LogId,
+			&bookmark.Note,
+			&created,
+			&logged,
+			&bookmark.Component,
+			&bookmark.Message,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bookmark: %w", err)
+		}
+		bookmark.Created, err = time.Parse(time.RFC3339Nano, created)
+		if err != nil {
+			return nil, fmt.Errorf("invalid creation time for bookmark on line %d: %w", bookmark.LogId, err)
+		}
+		if logged != "" {
+			// Malformed line times are not fatal; the line itself is still of
+			// interest.
+			bookmark.Time, _ = time.Parse(time.RFC3339Nano, logged)
+		}
+		result = append(result, bookmark)
+	}
+
+	return result, rows.Err()
+}
+
+// ToggleBookmark bookmarks the line if it is not bookmarked, or removes the
+// bookmark, and any note, if it is. It returns true if the line is bookmarked
+// after toggling.
+func (l *LogsDatabase) ToggleBookmark(logId int) (bool, error) {
+	result, err := l.Connection.Exec(`delete from bookmarks where log_id = ?`, logId)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove bookmark: %w", err)
+	}
+	if removed, _ := result.RowsAffected(); removed > 0 {
+		return false, nil
+	}
+
+	_, err = l.Connection.Exec(
+		`insert into bookmarks (log_id, created) values (?, ?)`,
+		logId,
+		time.Now().UTC().Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to add bookmark: %w", err)
+	}
+	return true, nil
+}
+
+// SetBookmarkNote attaches the note to the line, bookmarking the line if it
+// is not already bookmarked.
+func (l *LogsDatabase) SetBookmarkNote(logId int, note string) error {
+	_, err := l.Connection.Exec(
+		`
+			insert into bookmarks (log_id, note, created) values (?, ?, ?)
+			on conflict (log_id) do update set note = excluded.note
+		`,
+		logId,
+		note,
+		time.Now().UTC().Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save bookmark note: %w", err)
+	}
+	return nil
+}
+
+// NextBookmark finds the row number, within the query's result set, of the
+// nearest bookmarked line after (or, when forward is false, before) the given
+// row number. Zero is returned if there is no such line.
+func (q *Query) NextBookmark(number int, forward bool) int {
+	err := q.Materialize(context.Background())
+	if err != nil {
+		q.logger.Error("could not search for bookmarks", "error", err)
+		return 0
+	}
+
+	statement := `
+		select mv.row_num from %s mv join bookmarks on bookmarks.log_id = mv.log_id
+		where mv.row_num > ? order by mv.row_num limit 1
+	`
+	if forward == false {
+		statement = `
+			select mv.row_num from %s mv join bookmarks on bookmarks.log_id = mv.log_id
+			where mv.row_num < ? order by mv.row_num desc limit 1
+		`
+	}
+
+	var rowNum int
+	err = q.db.Connection.QueryRow(fmt.Sprintf(statement, q.table), number).Scan(&rowNum)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0
+	case err != nil:
+		q.logger.Error("failed to query for next bookmark", "error", err, "row", number)
+		return 0
+	}
+
+	return rowNum
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookmarks(t *testing.T) {
+	testDb := openTestDbCopy(t)
+
+	t.Run("toggles bookmarks and notes", func(t *testing.T) {
+		bookmarked, err := testDb.ToggleBookmark(297)
+		require.Nil(t, err)
+		assert.True(t, bookmarked)
+		require.Nil(t, testDb.SetBookmarkNote(378, "connect payload here"))
+		require.Nil(t, testDb.SetBookmarkNote(297, "first response"))
+
+		bookmarks, err := testDb.Bookmarks()
+		require.Nil(t, err)
+		require.Equal(t, 2, len(bookmarks))
+		assert.Equal(t, 297, bookmarks[0].LogId)
+		assert.Equal(t, "first response", bookmarks[0].Note)
+		assert.Equal(t, "new_relic_response", bookmarks[0].Component)
+		assert.Equal(t, 378, bookmarks[1].LogId)
+		assert.Equal(t, "connect payload here", bookmarks[1].Note)
+
+		bookmarked, err = testDb.ToggleBookmark(297)
+		require.Nil(t, err)
+		assert.False(t, bookmarked)
+		bookmarks, err = testDb.Bookmarks()
+		require.Nil(t, err)
+		assert.Equal(t, 1, len(bookmarks))
+	})
+
+	t.Run("finds bookmarks within a query", func(t *testing.T) {
+		_, err := testDb.ToggleBookmark(414)
+		require.Nil(t, err)
+
+		query := ModeSearchQuery("new_relic_response", SearchModeSubstring, testDb, nullLogger)
+		// Lines 297, 378, 414, and 416 match the search.
+		assert.Equal(t, 2, query.NextBookmark(1, true))
+		assert.Equal(t, 3, query.NextBookmark(2, true))
+		assert.Equal(t, 0, query.NextBookmark(3, true))
+		assert.Equal(t, 2, query.NextBookmark(3, false))
+		assert.Equal(t, 0, query.NextBookmark(2, false))
+	})
+
+	t.Run("annotates exported lines", func(t *testing.T) {
+		bookmark := Bookmark{Note: `first "ENOENT"`}
+		assert.Equal(
+			t,
+			`{"msg":"hello","nrlv_annotation":"first \"ENOENT\""}`,
+			bookmark.Annotate(`{"msg":"hello"}`),
+		)
+		assert.Equal(t, `{ "nrlv_annotation":""}`, Bookmark{}.Annotate(`{ }`))
+		assert.Equal(t, "not json", bookmark.Annotate("not json"))
+	})
+}
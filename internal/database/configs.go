@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/newrelic/node-log-viewer/internal/config"
+	"github.com/newrelic/node-log-viewer/internal/harvest"
+	"github.com/newrelic/node-log-viewer/internal/payload"
+	"github.com/newrelic/node-log-viewer/internal/session"
+)
+
+// SessionConfig is a session along with its effective configuration.
+type SessionConfig struct {
+	session.Session
+	Config config.Config
+
+	// LogIds identifies the lines whose payloads hold the configuration.
+	LogIds []int
+}
+
+// Configs reconstructs the effective configuration of each session, see
+// [config.Builder]. Only sessions that logged the payloads of the `connect`
+// or `agent_settings` methods, which requires the trace level, are included.
+// Payloads that cannot be decoded are skipped. When sessionId is not zero,
+// only that session is included.
+func (l *LogsDatabase) Configs(ctx context.Context, sessionId int) ([]SessionConfig, error) {
+	sessions, err := l.Sessions()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := l.Connection.QueryContext(
+		ctx,
+		fmt.Sprintf(
+			`
+				select
+					rowid,
+					coalesce(session_id, 0),
+					message,
+					coalesce(json_extract(%s, '$.data'), '')
+				from logs
+				where component = 'remote_method'
+					and (message like 'Calling connect on %%' or message like 'Calling agent_settings on %%')
+					%s
+				order by rowid
+			`,
+			originalColumn,
+			sessionCondition(sessionId),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration lines: %w", err)
+	}
+	defer rows.Close()
+
+	builders := make(map[int]*config.Builder)
+	logIds := make(map[int][]int)
+	for rows.Next() {
+		var logId, sessionId int
+		var message, data string
+		err = rows.Scan(&logId, &sessionId, &message, &data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan configuration line: %w", err)
+		}
+
+		document, err := payload.Decode(data)
+		if err != nil {
+			l.logger.Debug("skipping undecodable configuration payload", "log_id", logId, "error", err)
+			continue
+		}
+		builder := builders[sessionId]
+		if builder == nil {
+			builder = config.New()
+			builders[sessionId] = builder
+		}
+		err = builder.Add(harvest.Method(message), document)
+		if err != nil {
+			l.logger.Debug("skipping malformed configuration payload", "log_id", logId, "error", err)
+			continue
+		}
+		logIds[sessionId] = append(logIds[sessionId], logId)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read configuration lines: %w", err)
+	}
+
+	result := make([]SessionConfig, 0, len(builders))
+	for _, s := range sessions {
+		builder := builders[s.Id]
+		if builder == nil {
+			continue
+		}
+		result = append(result, SessionConfig{Session: s, Config: builder.Config(), LogIds: logIds[s.Id]})
+	}
+	return result, nil
+}
+
+// ConfigFilter creates a filter that limits the lines to those whose payloads
+// hold the configuration.
+func ConfigFilter(c SessionConfig) Filter {
+	return logIdsFilter(c.LogIds)
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/node-log-viewer/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigs(t *testing.T) {
+	testDb := openTestDbCopy(t)
+
+	configs, err := testDb.Configs(context.Background(), 0)
+	require.Nil(t, err)
+	// Only the last session was logged at the trace level.
+	require.Equal(t, 1, len(configs))
+
+	c := configs[0]
+	assert.Equal(t, 99445, c.Pid)
+	assert.Equal(t, 3, len(c.LogIds))
+	assert.Equal(t, "test-app", c.Config.Settings["app_name.0"])
+	assert.Equal(t, "collector.newrelic.com", c.Config.Settings["host"])
+	assert.Equal(t, false, c.Config.Settings["high_security"])
+	assert.Equal(t, true, c.Config.Settings["distributed_tracing.enabled"])
+	assert.Equal(t, true, c.Config.Settings["feature_flag.opentelemetry_bridge"])
+	assert.Equal(t, config.Masked, c.Config.Settings["license_key"])
+	assert.Equal(t, "agent_settings", c.Config.Sources["license_key"])
+	assert.NotContains(t, c.Config.Settings, "_eventsCount")
+
+	configs, err = testDb.Configs(context.Background(), 8)
+	require.Nil(t, err)
+	assert.Empty(t, configs)
+
+	configs, err = testDb.Configs(context.Background(), 9)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(configs))
+	assert.Equal(t, c.LogIds, configs[0].LogIds)
+}
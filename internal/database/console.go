@@ -0,0 +1,241 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SQLResult is the result set of an ad-hoc SQL statement.
+type SQLResult struct {
+	Columns []string
+	Rows    [][]string
+
+	// LogIdColumn is the index of the column that identifies rows of the
+	// `logs` table, e.g. `rowid` or `log_id`. It is -1 when the result set
+	// does not include such a column.
+	LogIdColumn int
+
+	// Truncated indicates that the statement produced more rows than were
+	// requested.
+	Truncated bool
+}
+
+// IsLogLines indicates if the rows of the result set identify log lines, and
+// can thus be viewed as a set of lines.
+func (r *SQLResult) IsLogLines() bool {
+	return r.LogIdColumn >= 0
+}
+
+// logIdColumnNames are the column names recognized as identifying a row of
+// the `logs` table.
+var logIdColumnNames = []string{"rowid", "_rowid_", "oid", "log_id"}
+
+// RunSQL executes an ad-hoc SQL statement against the cache, e.g. as entered
+// in the SQL console. At most maxRows rows are returned; a maxRows of zero or
+// less returns all rows. Values are rendered as strings, with `NULL` for null
+// values.
+//
+// The statement cannot modify the cache: it runs on a connection that is
+// switched to `query_only` mode, and only a single statement is accepted, so
+// that the mode cannot be switched off by the statement itself.
+func (l *LogsDatabase) RunSQL(ctx context.Context, statement string, maxRows int) (*SQLResult, error) {
+	statement, err := parseStatement(statement)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := l.Connection.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open connection: %w", err)
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, `pragma query_only = true`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make connection read only: %w", err)
+	}
+	defer func() {
+		_, err := conn.ExecContext(context.Background(), `pragma query_only = false`)
+		if err != nil {
+			// The connection must not be returned to the pool read only.
+			l.logger.Warn("discarding read only connection", "error", err)
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	rows, err := conn.QueryContext(ctx, statement)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read result columns: %w", err)
+	}
+
+	result := &SQLResult{
+		Columns:     columns,
+		Rows:        make([][]string, 0),
+		LogIdColumn: findLogIdColumn(columns),
+	}
+
+	for rows.Next() {
+		if maxRows > 0 && len(result.Rows) >= maxRows {
+			result.Truncated = true
+			break
+		}
+
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		err = rows.Scan(pointers...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan result row: %w", err)
+		}
+
+		row := make([]string, 0, len(values))
+		for _, value := range values {
+			row = append(row, formatSQLValue(value))
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	return result, rows.Err()
+}
+
+// LogLinesFilter returns a filter whose lines are those identified by the
+// result set of the given statement. The statement must select one of the
+// columns recognized by [SQLResult.LogIdColumn].
+func LogLinesFilter(statement string, result *SQLResult) (Filter, error) {
+	if result == nil || result.IsLogLines() == false {
+		return Filter{}, fmt.Errorf("result set does not include a log line id column (e.g. rowid)")
+	}
+
+	// The statement is embedded in the query of the filter, where comments
+	// and a trailing semicolon would break the surrounding query.
+	statement, err := parseStatement(statement)
+	if err != nil {
+		return Filter{}, err
+	}
+
+	return Filter{
+		SQL:       statement,
+		SQLColumn: result.Columns[result.LogIdColumn],
+	}, nil
+}
+
+// parseStatement removes the comments, and the trailing semicolon, from an
+// ad-hoc SQL statement. An error is returned when the input does not hold
+// exactly one statement. String literals and quoted identifiers are kept as
+// they are.
+func parseStatement(input string) (string, error) {
+	var builder strings.Builder
+	ended := false
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case c == '-' && strings.HasPrefix(input[i:], "--"):
+			end := strings.IndexByte(input[i:], '\n')
+			if end < 0 {
+				i = len(input)
+				continue
+			}
+			i += end
+			builder.WriteByte('\n')
+			continue
+
+		case c == '/' && strings.HasPrefix(input[i:], "/*"):
+			end := strings.Index(input[i+2:], "*/")
+			if end < 0 {
+				i = len(input)
+				continue
+			}
+			i += end + 3
+			builder.WriteByte(' ')
+			continue
+
+		case c == ';':
+			ended = true
+			continue
+
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			builder.WriteByte(c)
+			continue
+		}
+
+		if ended == true {
+			return "", fmt.Errorf("only a single statement can be run")
+		}
+
+		closing := closingQuote(c)
+		if closing == 0 {
+			builder.WriteByte(c)
+			continue
+		}
+		end := strings.IndexByte(input[i+1:], closing)
+		if end < 0 {
+			// The statement is left for sqlite to report as invalid.
+			builder.WriteString(input[i:])
+			break
+		}
+		builder.WriteString(input[i : i+end+2])
+		i += end + 1
+	}
+
+	statement := strings.TrimSpace(builder.String())
+	if statement == "" {
+		return "", fmt.Errorf("statement is empty")
+	}
+	return statement, nil
+}
+
+// closingQuote returns the character that ends the string literal, or quoted
+// identifier, started by c. It is zero if c does not start one.
+func closingQuote(c byte) byte {
+	switch c {
+	case '\'', '"', '`':
+		return c
+	case '[':
+		return ']'
+	}
+	return 0
+}
+
+func findLogIdColumn(columns []string) int {
+	for _, name := range logIdColumnNames {
+		for i, column := range columns {
+			if strings.EqualFold(column, name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func formatSQLValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case sql.RawBytes:
+		return string(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSQL(t *testing.T) {
+	testDb := openTestDbCopy(t)
+
+	t.Run("helper functions decode lines", func(t *testing.T) {
+		result, err := testDb.RunSQL(
+			context.Background(),
+			`
+				select nr_field(original, 'data.utilization.hostname') as hostname
+				from logs
+				where nr_field(original, 'data.utilization.hostname') is not null
+			`,
+			0,
+		)
+		require.Nil(t, err)
+		assert.Equal(t, []string{"hostname"}, result.Columns)
+		assert.Equal(t, [][]string{{"localhost"}}, result.Rows)
+		assert.False(t, result.IsLogLines())
+
+		result, err = testDb.RunSQL(
+			context.Background(),
+			"select nr_level_name(level), count(*) from logs group by 1 order by level desc",
+			2,
+		)
+		require.Nil(t, err)
+		assert.Equal(t, [][]string{{"Error", "1"}, {"Warn", "11"}}, result.Rows)
+		assert.True(t, result.Truncated)
+
+		result, err = testDb.RunSQL(
+			context.Background(),
+			"select count(*) from logs where nr_kind(original) = 'embedded_data'",
+			0,
+		)
+		require.Nil(t, err)
+		assert.Equal(t, [][]string{{"378"}}, result.Rows)
+	})
+
+	t.Run("null values and errors are reported", func(t *testing.T) {
+		result, err := testDb.RunSQL(context.Background(), "select null, nr_level_name(5)", 0)
+		require.Nil(t, err)
+		assert.Equal(t, [][]string{{"NULL", "NULL"}}, result.Rows)
+
+		_, err = testDb.RunSQL(context.Background(), "select * from not_a_table", 0)
+		assert.ErrorContains(t, err, "no such table")
+	})
+
+	t.Run("log line results can be viewed as lines", func(t *testing.T) {
+		statement := "select rowid, message from logs where level >= 40;"
+		result, err := testDb.RunSQL(context.Background(), statement, 0)
+		require.Nil(t, err)
+		assert.True(t, result.IsLogLines())
+		assert.Equal(t, 12, len(result.Rows))
+
+		filter, err := LogLinesFilter(statement, result)
+		require.Nil(t, err)
+		assert.Equal(t, 12, filter.Query(testDb, nullLogger).NumRows())
+
+		result, err = testDb.RunSQL(context.Background(), "select component from logs limit 1", 0)
+		require.Nil(t, err)
+		_, err = LogLinesFilter("select component from logs limit 1", result)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("comments do not break the filter of log line results", func(t *testing.T) {
+		statement := "-- errors\nselect rowid from logs /* only errors */ where level >= 50; -- done"
+		result, err := testDb.RunSQL(context.Background(), statement, 0)
+		require.Nil(t, err)
+
+		filter, err := LogLinesFilter(statement, result)
+		require.Nil(t, err)
+		assert.Equal(t, "select rowid from logs   where level >= 50", filter.SQL)
+		assert.Equal(t, 1, filter.Query(testDb, nullLogger).NumRows())
+
+		filter = Filter{SQL: "select rowid from logs where level >= 50 -- errors"}
+		assert.Equal(t, 1, filter.Query(testDb, nullLogger).NumRows())
+	})
+
+	t.Run("statements cannot modify the cache", func(t *testing.T) {
+		_, err := testDb.RunSQL(context.Background(), "drop table bookmarks", 0)
+		assert.ErrorContains(t, err, "readonly")
+
+		_, err = testDb.RunSQL(context.Background(), "pragma query_only = false; drop table bookmarks", 0)
+		assert.ErrorContains(t, err, "single statement")
+
+		// Literals that look like statement separators are kept.
+		result, err := testDb.RunSQL(context.Background(), "select ';', '-- x', \"a;b\" from (select 1 as \"a;b\")", 0)
+		require.Nil(t, err)
+		assert.Equal(t, [][]string{{";", "-- x", "1"}}, result.Rows)
+
+		// The connection is writable again for the cache's own writes.
+		_, err = testDb.ToggleBookmark(1)
+		require.Nil(t, err)
+		result, err = testDb.RunSQL(context.Background(), "select count(*) from logs", 0)
+		require.Nil(t, err)
+		assert.Equal(t, [][]string{{"8092"}}, result.Rows)
+	})
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/newrelic/node-log-viewer/internal/doctor"
+)
+
+// Diagnose gathers what is known about each session and runs the built-in
+// rules over it, see [doctor.Diagnose]. When sessionId is not zero, only that
+// session is diagnosed.
+func (l *LogsDatabase) Diagnose(ctx context.Context, sessionId int) ([]doctor.Finding, error) {
+	lifecycles, err := l.Lifecycles(ctx, sessionId)
+	if err != nil {
+		return nil, err
+	}
+	configs, err := l.Configs(ctx, sessionId)
+	if err != nil {
+		return nil, err
+	}
+	warnings, err := l.warnings(ctx, sessionId)
+	if err != nil {
+		return nil, err
+	}
+	harvests, err := l.harvestsBySession(ctx, sessionId)
+	if err != nil {
+		return nil, err
+	}
+	errorGroups, err := l.errorGroupsBySession(ctx, sessionId)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]doctor.Session, 0, len(lifecycles))
+	for _, lc := range lifecycles {
+		s := doctor.Session{
+			Session:   lc.Session,
+			Lifecycle: lc.Lifecycle,
+			Harvests:  harvests[lc.Id],
+			Errors:    errorGroups[lc.Id],
+			Warnings:  warnings[lc.Id],
+		}
+		for _, c := range configs {
+			if c.Id == lc.Id {
+				s.Config = &c.Config
+				s.ConfigLogIds = c.LogIds
+			}
+		}
+		sessions = append(sessions, s)
+	}
+	return doctor.Diagnose(sessions, doctor.Rules), nil
+}
+
+// warnings reads the lines logged at [doctor.WarnLevel] or above, by session.
+func (l *LogsDatabase) warnings(ctx context.Context, sessionId int) (map[int][]doctor.Line, error) {
+	rows, err := l.Connection.QueryContext(
+		ctx,
+		fmt.Sprintf(
+			`
+				select
+					rowid,
+					unixepoch(time, 'subsec'),
+					coalesce(session_id, 0),
+					coalesce(level, 0),
+					coalesce(component, ''),
+					coalesce(message, '')
+				from logs
+				where level >= %d %s
+				order by rowid
+			`,
+			doctor.WarnLevel,
+			sessionCondition(sessionId),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read warning lines: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[int][]doctor.Line)
+	for rows.Next() {
+		var line doctor.Line
+		var seconds *float64
+		var id int
+		err = rows.Scan(&line.LogId, &seconds, &id, &line.Level, &line.Component, &line.Message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan warning line: %w", err)
+		}
+		line.Time = unixTime(seconds)
+		result[id] = append(result[id], line)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read warning lines: %w", err)
+	}
+	return result, nil
+}
+
+// FindingFilter creates a filter that limits the lines to the evidence of the
+// finding.
+func FindingFilter(f doctor.Finding) Filter {
+	return logIdsFilter(f.LogIds)
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/node-log-viewer/internal/doctor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnose(t *testing.T) {
+	testDb := openTestDbCopy(t)
+
+	findings, err := testDb.Diagnose(context.Background(), 0)
+	require.Nil(t, err)
+	require.Equal(t, 11, len(findings))
+
+	neverStarted := findings[0]
+	assert.Equal(t, "agent-lifecycle", neverStarted.Rule)
+	assert.Equal(t, doctor.SeverityError, neverStarted.Severity)
+	assert.Equal(t, 2, neverStarted.Session)
+	assert.Equal(t, "Agent never started", neverStarted.Title)
+	assert.Equal(t, []int{16, 18}, neverStarted.LogIds)
+
+	assert.Equal(t, "Agent restarted by the collector", findings[2].Title)
+	assert.Equal(t, "missing-harvests", findings[3].Rule)
+	assert.Equal(t, "feature-flags", findings[4].Rule)
+	assert.Equal(t, "enoent-loop", findings[10].Rule)
+	assert.Equal(t, doctor.SeverityInfo, findings[10].Severity)
+
+	results, err := FindingFilter(findings[10]).Query(testDb, nullLogger).AllResults()
+	require.Nil(t, err)
+	assert.Equal(t, 8, len(results))
+
+	findings, err = testDb.Diagnose(context.Background(), 8)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(findings))
+	assert.Equal(t, []int{86}, findings[0].LogIds)
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/newrelic/node-log-viewer/internal/common"
+	"github.com/newrelic/node-log-viewer/internal/triage"
+	v0 "github.com/newrelic/node-log-viewer/internal/v0"
+)
+
+// ErrorGroups groups the error lines, and the lines logged at the error or
+// fatal level, see [triage.Grouper]. When sessionId is not zero, only that
+// session's lines are considered.
+func (l *LogsDatabase) ErrorGroups(ctx context.Context, sessionId int) ([]triage.Group, error) {
+	grouper := triage.New()
+	err := l.readErrorLines(ctx, sessionId, func(_ int, line triage.Line) {
+		grouper.Add(line)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return grouper.Groups(), nil
+}
+
+// errorGroupsBySession is like [LogsDatabase.ErrorGroups], except that the
+// lines of each session are grouped separately. The groups are keyed by the
+// id of their session.
+func (l *LogsDatabase) errorGroupsBySession(ctx context.Context, sessionId int) (map[int][]triage.Group, error) {
+	groupers := make(map[int]*triage.Grouper)
+	err := l.readErrorLines(ctx, sessionId, func(id int, line triage.Line) {
+		grouper := groupers[id]
+		if grouper == nil {
+			grouper = triage.New()
+			groupers[id] = grouper
+		}
+		grouper.Add(line)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int][]triage.Group, len(groupers))
+	for id, grouper := range groupers {
+		result[id] = grouper.Groups()
+	}
+	return result, nil
+}
+
+// readErrorLines reads the error lines in the order they were logged, and
+// passes each of them, along with the id of its session, to add.
+func (l *LogsDatabase) readErrorLines(
+	ctx context.Context,
+	sessionId int,
+	add func(sessionId int, line triage.Line),
+) error {
+	rows, err := l.Connection.QueryContext(
+		ctx,
+		fmt.Sprintf(
+			`
+				select
+					rowid,
+					unixepoch(time, 'subsec'),
+					coalesce(session_id, 0),
+					coalesce(level, 0),
+					coalesce(component, ''),
+					coalesce(message, ''),
+					coalesce(cast(json_extract(%[1]s, '$.code') as text), ''),
+					coalesce(cast(json_extract(%[1]s, '$.syscall') as text), ''),
+					coalesce(cast(json_extract(%[1]s, '$.path') as text), ''),
+					coalesce(cast(json_extract(%[1]s, '$.stack') as text), '')
+				from logs
+				where (kind = %[2]d or level >= %[3]d) %[4]s
+				order by rowid
+			`,
+			originalColumn,
+			common.TypeError,
+			v0.ERROR,
+			sessionCondition(sessionId),
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to read error lines: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line triage.Line
+		var seconds *float64
+		var id int
+		err = rows.Scan(
+			&line.LogId,
+			&seconds,
+			&id,
+			&line.Level,
+			&line.Component,
+			&line.Message,
+			&line.Code,
+			&line.Syscall,
+			&line.Path,
+			&line.Stack,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan error line: %w", err)
+		}
+		line.Time = unixTime(seconds)
+		add(id, line)
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to read error lines: %w", err)
+	}
+	return nil
+}
+
+// ErrorGroupFilter creates a filter that limits the lines to the occurrences
+// of the error group.
+func ErrorGroupFilter(g triage.Group) Filter {
+	return logIdsFilter(g.LogIds)
+}
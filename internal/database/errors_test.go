@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorGroups(t *testing.T) {
+	testDb := openTestDbCopy(t)
+
+	groups, err := testDb.ErrorGroups(context.Background(), 0)
+	require.Nil(t, err)
+	require.Equal(t, 5, len(groups))
+
+	packages := groups[0]
+	assert.Equal(t, "Could not list packages in <*> (probably not an error)", packages.Message)
+	assert.Equal(t, "ENOENT", packages.Code)
+	assert.Equal(t, "scandir", packages.Syscall)
+	assert.Equal(t, 8, packages.Count)
+	assert.Equal(t, []string{"environment"}, packages.Components)
+	assert.Equal(t, "/lib/node_modules", packages.Paths[0])
+
+	// Lines logged at the error level are grouped without error attributes.
+	restart := groups[2]
+	assert.Equal(t, "Agent endpoint metric_data returned <*> status. Restarting.", restart.Message)
+	assert.Equal(t, "", restart.Code)
+	assert.Equal(t, 50, restart.Level)
+
+	results, err := ErrorGroupFilter(packages).Query(testDb, nullLogger).AllResults()
+	require.Nil(t, err)
+	assert.Equal(t, 8, len(results))
+
+	groups, err = testDb.ErrorGroups(context.Background(), 8)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(groups))
+	assert.Equal(t, restart.Key, groups[0].Key)
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/node-log-viewer/internal/common"
+)
+
+// fieldPredicate is a comparison of an attribute of a line against a value,
+// e.g. `data[0].high_security = false` or `rss > 80000000`.
+type fieldPredicate struct {
+	path     []string
+	operator string
+	value    string
+	// quoted indicates that the value was given as a quoted string, and thus
+	// must always be compared as a string.
+	quoted bool
+}
+
+var matchFieldPredicate = regexp.MustCompile(
+	`(?:^|\s)([A-Za-z_]\w*(?:\.\w+|\[\d+\])*)\s*(!=|>=|<=|=|>|<)\s*("(?:[^"]|"")*"|'(?:[^']|'')*'|[^\s()]+)`,
+)
+
+var matchNumber = regexp.MustCompile(`^-?\d+(\.\d+)?([eE][-+]?\d+)?$`)
+
+// predicateMarker stands in for the field predicates removed from a search
+// term, so that the operators next to them can be inspected.
+const predicateMarker = "\x00"
+
+// parseFieldPredicates extracts the field predicates from a search term. The
+// remainder of the term, i.e. everything that is not a field predicate, is
+// returned with any dangling `AND` operators removed so that it may be
+// used as an fts5 query. Field predicates are always combined with the
+// remainder by `AND`, so an error is returned for terms that combine them
+// with `OR`, or negate them with `NOT`, as those cannot be honored.
+func parseFieldPredicates(searchTerm string) ([]fieldPredicate, string, error) {
+	predicates := make([]fieldPredicate, 0)
+
+	remainder := matchFieldPredicate.ReplaceAllStringFunc(searchTerm, func(match string) string {
+		groups := matchFieldPredicate.FindStringSubmatch(match)
+		predicate := fieldPredicate{
+			path:     common.SplitFieldPath(groups[1]),
+			operator: groups[2],
+			value:    groups[3],
+		}
+
+		if len(predicate.value) >= 2 && (predicate.value[0] == '"' || predicate.value[0] == '\'') {
+			quote := predicate.value[0:1]
+			predicate.value = strings.ReplaceAll(predicate.value[1:len(predicate.value)-1], quote+quote, quote)
+			predicate.quoted = true
+		}
+
+		predicates = append(predicates, predicate)
+		return " " + predicateMarker + " "
+	})
+	if len(predicates) == 0 {
+		return predicates, searchTerm, nil
+	}
+
+	words := strings.Fields(remainder)
+	for i, word := range words {
+		switch {
+		case word == "OR":
+			return nil, "", fmt.Errorf("field predicates cannot be combined with OR")
+		case word == "NOT" && ((i > 0 && words[i-1] == predicateMarker) || (i+1 < len(words) && words[i+1] == predicateMarker)):
+			return nil, "", fmt.Errorf("field predicates cannot be combined with NOT")
+		}
+	}
+	words = slices.DeleteFunc(words, func(word string) bool {
+		return word == predicateMarker
+	})
+
+	return predicates, trimDanglingAnd(words), nil
+}
+
+// trimDanglingAnd removes the `AND` operators that no longer join two
+// expressions once field predicates have been removed from a search term.
+func trimDanglingAnd(words []string) string {
+	result := make([]string, 0, len(words))
+	for _, word := range words {
+		if word == "AND" && (len(result) == 0 || result[len(result)-1] == "AND") {
+			continue
+		}
+		result = append(result, word)
+	}
+	if len(result) > 0 && result[len(result)-1] == "AND" {
+		result = result[:len(result)-1]
+	}
+	return strings.Join(result, " ")
+}
+
+// sql renders the predicate as an SQL expression evaluated against the
+// original text of the lines of the `logs` table, see [originalColumn].
+func (p fieldPredicate) sql() string {
+	value := p.sqlValue()
+	if value == "null" {
+		if p.operator == "!=" {
+			return p.sqlField() + " is not null"
+		}
+		return p.sqlField() + " is null"
+	}
+	return fmt.Sprintf("%s %s %s", p.sqlField(), p.operator, value)
+}
+
+// sqlField renders an expression for the value of the field. Attributes such
+// as `data` frequently hold a JSON document serialized to a string. So, in
+// addition to the full path, each prefix of the path is tried as the location
+// of such a string, with the rest of the path being looked up within the
+// decoded string.
+func (p fieldPredicate) sqlField() string {
+	candidates := []string{
+		fmt.Sprintf("json_extract(%s, '%s')", originalColumn, jsonPath(p.path)),
+	}
+
+	for i := 1; i < len(p.path); i++ {
+		inner := fmt.Sprintf("json_extract(%s, '%s')", originalColumn, jsonPath(p.path[:i]))
+		candidates = append(candidates, fmt.Sprintf(
+			"case when json_valid(%[1]s) then json_extract(%[1]s, '%[2]s') end",
+			inner,
+			jsonPath(p.path[i:]),
+		))
+	}
+
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	return "coalesce(" + strings.Join(candidates, ", ") + ")"
+}
+
+// sqlValue renders the value as an SQL literal. JSON booleans are extracted
+// by sqlite as the integers 1 and 0, so boolean values are rendered likewise.
+func (p fieldPredicate) sqlValue() string {
+	if p.quoted == true {
+		return "'" + escapeSqlString(p.value) + "'"
+	}
+
+	switch {
+	case p.value == "true":
+		return "1"
+	case p.value == "false":
+		return "0"
+	case p.value == "null":
+		return "null"
+	case matchNumber.MatchString(p.value):
+		return p.value
+	}
+	return "'" + escapeSqlString(p.value) + "'"
+}
+
+// jsonPath renders the path segments as an sqlite JSON path, e.g.
+// `$.data[0].high_security`.
+func jsonPath(segments []string) string {
+	builder := strings.Builder{}
+	builder.WriteString("$")
+	for _, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil {
+			builder.WriteString("[" + segment + "]")
+			continue
+		}
+		builder.WriteString("." + segment)
+	}
+	return builder.String()
+}
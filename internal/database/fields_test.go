@@ -0,0 +1,71 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseFieldPredicates(t *testing.T) {
+	predicates, remainder, err := parseFieldPredicates(`remote_method AND data[0].high_security = false AND name != "it's ""quoted"""`)
+	require.Nil(t, err)
+	assert.Equal(t, "remote_method", remainder)
+	assert.Equal(t, []fieldPredicate{
+		{path: []string{"data", "0", "high_security"}, operator: "=", value: "false"},
+		{path: []string{"name"}, operator: "!=", value: `it's "quoted"`, quoted: true},
+	}, predicates)
+
+	predicates, remainder, err = parseFieldPredicates("rss>80000000 AND heap NOT sampler")
+	require.Nil(t, err)
+	assert.Equal(t, "heap NOT sampler", remainder)
+	assert.Equal(t, 1, len(predicates))
+
+	// Field predicates are always combined with the remainder by AND.
+	_, _, err = parseFieldPredicates("rss>80000000 OR heap")
+	assert.ErrorContains(t, err, "OR")
+	_, _, err = parseFieldPredicates("NOT rss>80000000")
+	assert.ErrorContains(t, err, "NOT")
+	_, _, err = parseFieldPredicates("heap NOT rss>80000000")
+	assert.ErrorContains(t, err, "NOT")
+
+	// Without field predicates, the term is left to fts5.
+	predicates, remainder, err = parseFieldPredicates("heap OR rss")
+	require.Nil(t, err)
+	assert.Equal(t, "heap OR rss", remainder)
+	assert.Equal(t, 0, len(predicates))
+
+	predicates, remainder, err = parseFieldPredicates("component:remote_method")
+	require.Nil(t, err)
+	assert.Equal(t, "component:remote_method", remainder)
+	assert.Equal(t, 0, len(predicates))
+}
+
+func Test_fieldPredicateSql(t *testing.T) {
+	// The predicates are evaluated against the original text of each line,
+	// which may be read from the source log file of an index only cache.
+	original := func(sql string) string {
+		return strings.ReplaceAll(sql, "original", originalColumn)
+	}
+
+	predicate := fieldPredicate{path: []string{"rss"}, operator: ">", value: "80000000"}
+	assert.Equal(t, original("json_extract(original, '$.rss') > 80000000"), predicate.sql())
+
+	predicate = fieldPredicate{path: []string{"data", "0", "ok"}, operator: "=", value: "true"}
+	assert.Equal(
+		t,
+		original("coalesce("+
+			"json_extract(original, '$.data[0].ok'), "+
+			"case when json_valid(json_extract(original, '$.data')) then json_extract(json_extract(original, '$.data'), '$[0].ok') end, "+
+			"case when json_valid(json_extract(original, '$.data[0]')) then json_extract(json_extract(original, '$.data[0]'), '$.ok') end"+
+			") = 1"),
+		predicate.sql(),
+	)
+
+	predicate = fieldPredicate{path: []string{"run_id"}, operator: "!=", value: "null"}
+	assert.Equal(t, original("json_extract(original, '$.run_id') is not null"), predicate.sql())
+
+	predicate = fieldPredicate{path: []string{"version"}, operator: "=", value: "12", quoted: true}
+	assert.Equal(t, original("json_extract(original, '$.version') = '12'"), predicate.sql())
+}
@@ -0,0 +1,426 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newrelic/node-log-viewer/internal/log"
+)
+
+// Filter describes a set of criteria for narrowing the cached logs down to
+// the lines of interest. Filters may be named and saved for reuse across
+// sessions.
+type Filter struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+
+	// Search is the search term(s) a line must match. It is interpreted
+	// according to Mode. In the words mode, the term may also include field
+	// predicates, e.g. `data[0].high_security = false`, see
+	// [parseFieldPredicates].
+	Search string     `yaml:"search,omitempty"`
+	Mode   SearchMode `yaml:"mode,omitempty"`
+
+	// Components limits the lines to those logged by any of the listed
+	// components.
+	Components []string `yaml:"components,omitempty"`
+
+	// MinLevel limits the lines to those logged at, or above, the given
+	// numeric level, e.g. 50 for errors.
+	MinLevel int `yaml:"min_level,omitempty"`
+
+	// Conditions limits the lines to those whose promoted column has the given
+	// value, e.g. `pid: "11385"`.
+	Conditions map[Facet]string `yaml:"conditions,omitempty"`
+
+	// Since and Until limit the lines to those logged within the time range.
+	// Since is inclusive while Until is exclusive. A zero time leaves that end
+	// of the range open.
+	Since time.Time `yaml:"since,omitempty"`
+	Until time.Time `yaml:"until,omitempty"`
+
+	// Templates limits the lines to those whose message has any of the listed
+	// templates, while HiddenTemplates excludes the lines whose message has
+	// any of the listed templates. See [LogsDatabase.Templates].
+	Templates       []string `yaml:"templates,omitempty"`
+	HiddenTemplates []string `yaml:"hidden_templates,omitempty"`
+
+	// SQL limits the lines to those identified by the result set of an ad-hoc
+	// SQL statement. SQLColumn is the name of the result column that holds the
+	// `logs` row id, e.g. `rowid`.
+	SQL       string `yaml:"sql,omitempty"`
+	SQLColumn string `yaml:"sql_column,omitempty"`
+
+	// ContextLines is the number of surrounding lines to show with each
+	// matching line.
+	ContextLines int `yaml:"context_lines,omitempty"`
+}
+
+// IsEmpty indicates if the filter does not exclude any lines.
+func (f Filter) IsEmpty() bool {
+	return f.Search == "" &&
+		len(f.Components) == 0 &&
+		f.MinLevel <= 0 &&
+		len(f.Conditions) == 0 &&
+		f.Since.IsZero() &&
+		f.Until.IsZero() &&
+		len(f.Templates) == 0 &&
+		len(f.HiddenTemplates) == 0 &&
+		f.SQL == ""
+}
+
+// WithCondition returns a copy of the filter that is further limited to the
+// lines whose promoted column has the given value.
+func (f Filter) WithCondition(facet Facet, value string) Filter {
+	conditions := make(map[Facet]string, len(f.Conditions)+1)
+	for k, v := range f.Conditions {
+		conditions[k] = v
+	}
+	conditions[facet] = value
+	f.Conditions = conditions
+	return f
+}
+
+// WithTimeRange returns a copy of the filter that is further limited to the
+// lines logged within the given time range.
+func (f Filter) WithTimeRange(since time.Time, until time.Time) Filter {
+	f.Since = since
+	f.Until = until
+	return f
+}
+
+// Query creates a query whose result set is the lines matching the filter.
+// Filters that use templates only match the lines whose templates have been
+// mined, so [LogsDatabase.MineTemplates] must be run before the query is
+// materialized, see [Filter.UsesTemplates].
+func (f Filter) Query(db *LogsDatabase, logger *log.Logger) *Query {
+	predicate, err := f.predicate(db.hasTrigramIndex())
+	query := newQuery(db, logger, predicate, f.ContextLines)
+	query.err = err
+	return query
+}
+
+// UsesTemplates indicates if the filter selects, or excludes, lines by their
+// template.
+func (f Filter) UsesTemplates() bool {
+	return len(f.Templates) > 0 || len(f.HiddenTemplates) > 0
+}
+
+// WithTemplate returns a copy of the filter that is further limited to the
+// lines with the given template.
+func (f Filter) WithTemplate(pattern string) Filter {
+	f.Templates = []string{pattern}
+	f.HiddenTemplates = slices.DeleteFunc(slices.Clone(f.HiddenTemplates), func(hidden string) bool {
+		return hidden == pattern
+	})
+	return f
+}
+
+// ToggleHiddenTemplate returns a copy of the filter that excludes the lines
+// with the given template, or no longer excludes them if they were excluded.
+func (f Filter) ToggleHiddenTemplate(pattern string) Filter {
+	if slices.Contains(f.HiddenTemplates, pattern) {
+		f.HiddenTemplates = slices.DeleteFunc(slices.Clone(f.HiddenTemplates), func(hidden string) bool {
+			return hidden == pattern
+		})
+		return f
+	}
+	f.HiddenTemplates = append(slices.Clone(f.HiddenTemplates), pattern)
+	return f
+}
+
+// predicate builds the SQL expression, evaluated against the `logs` table,
+// that selects the lines matching the filter. An error is returned for search
+// terms that cannot be honored, see [parseFieldPredicates].
+func (f Filter) predicate(hasTrigramIndex bool) (string, error) {
+	predicates := make([]string, 0)
+
+	search := f.Search
+	if f.Mode == SearchModeWords {
+		var fields []fieldPredicate
+		var err error
+		fields, search, err = parseFieldPredicates(search)
+		if err != nil {
+			return "", err
+		}
+		for _, field := range fields {
+			predicates = append(predicates, field.sql())
+		}
+	}
+	if search != "" {
+		predicates = append(predicates, planSearch(search, f.Mode, hasTrigramIndex))
+	}
+
+	if len(f.Components) > 0 {
+		predicates = append(predicates, fmt.Sprintf("component in (%s)", quoteSqlStrings(f.Components)))
+	}
+
+	if f.MinLevel > 0 {
+		predicates = append(predicates, fmt.Sprintf("level >= %d", f.MinLevel))
+	}
+
+	facets := make([]Facet, 0, len(f.Conditions))
+	for facet := range f.Conditions {
+		facets = append(facets, facet)
+	}
+	slices.Sort(facets)
+	for _, facet := range facets {
+		if facet.isValid() == false {
+			continue
+		}
+		predicates = append(
+			predicates,
+			fmt.Sprintf("%s = '%s'", facet, escapeSqlString(f.Conditions[facet])),
+		)
+	}
+
+	// Timestamps are stored as RFC 3339 strings in UTC. Comparing them as
+	// Unix time avoids problems with differing fractional second precision.
+	if f.Since.IsZero() == false {
+		predicates = append(predicates, fmt.Sprintf("unixepoch(time, 'subsec') >= %f", unixSeconds(f.Since)))
+	}
+	if f.Until.IsZero() == false {
+		predicates = append(predicates, fmt.Sprintf("unixepoch(time, 'subsec') < %f", unixSeconds(f.Until)))
+	}
+
+	if len(f.Templates) > 0 {
+		predicates = append(predicates, "rowid in "+templateLines(f.Templates))
+	}
+	if len(f.HiddenTemplates) > 0 {
+		predicates = append(predicates, "rowid not in "+templateLines(f.HiddenTemplates))
+	}
+
+	if f.SQL != "" {
+		column := f.SQLColumn
+		if column == "" {
+			column = "rowid"
+		}
+		// The statement is placed on lines of its own, so that a trailing
+		// comment does not comment out the rest of the query.
+		predicates = append(
+			predicates,
+			fmt.Sprintf("rowid in (select \"%s\" from (\n%s\n))", strings.ReplaceAll(column, `"`, `""`), f.SQL),
+		)
+	}
+
+	return joinPredicates(predicates...), nil
+}
+
+// templateLines renders a subquery that selects the ids of the lines with any
+// of the given templates.
+func templateLines(patterns []string) string {
+	return fmt.Sprintf(
+		`(
+			select line_templates.log_id from line_templates
+			join templates on templates.id = line_templates.template_id
+			where templates.pattern in (%s)
+		)`,
+		quoteSqlStrings(patterns),
+	)
+}
+
+// quoteSqlStrings renders the values as a comma separated list of SQL string
+// literals.
+func quoteSqlStrings(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, "'"+escapeSqlString(value)+"'")
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixMilli()) / 1_000
+}
+
+// joinPredicates combines the given SQL expressions such that all of them
+// must be satisfied. Empty expressions are ignored.
+func joinPredicates(predicates ...string) string {
+	parts := make([]string, 0, len(predicates))
+	for _, predicate := range predicates {
+		if predicate == "" {
+			continue
+		}
+		parts = append(parts, "("+predicate+")")
+	}
+	return strings.Join(parts, " and ")
+}
+
+// SavedFilters returns the filters that have been saved in the cache file.
+func (l *LogsDatabase) SavedFilters() ([]Filter, error) {
+	rows, err := l.Connection.Query(`
+		select
+			name, description, search, mode, components, min_level, context_lines,
+			conditions, since, until, sql, sql_column, templates, hidden_templates
+		from saved_filters
+		order by name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for saved filters: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]Filter, 0)
+	for rows.Next() {
+		var filter Filter
+		var mode string
+		var components string
+		var conditions string
+		var since string
+		var until string
+		var templates string
+		var hiddenTemplates string
+		err = rows.Scan(
+			&filter.Name,
+			&filter.Description,
+			&filter.Search,
+			&mode,
+			&components,
+			&filter.MinLevel,
+			&filter.ContextLines,
+			&conditions,
+			&since,
+			&until,
+			&filter.SQL,
+			&filter.SQLColumn,
+			&templates,
+			&hiddenTemplates,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saved filter: %w", err)
+		}
+
+		err = filter.Mode.UnmarshalText([]byte(mode))
+		if err != nil {
+			return nil, fmt.Errorf("invalid mode for saved filter `%s`: %w", filter.Name, err)
+		}
+		err = json.Unmarshal([]byte(components), &filter.Components)
+		if err != nil {
+			return nil, fmt.Errorf("invalid components for saved filter `%s`: %w", filter.Name, err)
+		}
+		err = json.Unmarshal([]byte(conditions), &filter.Conditions)
+		if err != nil {
+			return nil, fmt.Errorf("invalid conditions for saved filter `%s`: %w", filter.Name, err)
+		}
+		err = json.Unmarshal([]byte(templates), &filter.Templates)
+		if err != nil {
+			return nil, fmt.Errorf("invalid templates for saved filter `%s`: %w", filter.Name, err)
+		}
+		err = json.Unmarshal([]byte(hiddenTemplates), &filter.HiddenTemplates)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hidden templates for saved filter `%s`: %w", filter.Name, err)
+		}
+		filter.Since, err = parseFilterTime(since)
+		if err != nil {
+			return nil, fmt.Errorf("invalid since time for saved filter `%s`: %w", filter.Name, err)
+		}
+		filter.Until, err = parseFilterTime(until)
+		if err != nil {
+			return nil, fmt.Errorf("invalid until time for saved filter `%s`: %w", filter.Name, err)
+		}
+
+		result = append(result, filter)
+	}
+
+	return result, rows.Err()
+}
+
+// SaveFilter stores the filter in the cache file. Any previously saved filter
+// with the same name is replaced.
+func (l *LogsDatabase) SaveFilter(filter Filter) error {
+	if filter.Name == "" {
+		return fmt.Errorf("filter must have a name")
+	}
+
+	components, err := json.Marshal(filter.Components)
+	if err != nil {
+		return fmt.Errorf("failed to serialize filter components: %w", err)
+	}
+	if filter.Components == nil {
+		components = []byte("[]")
+	}
+	conditions, err := json.Marshal(filter.Conditions)
+	if err != nil {
+		return fmt.Errorf("failed to serialize filter conditions: %w", err)
+	}
+	if filter.Conditions == nil {
+		conditions = []byte("{}")
+	}
+	templates, err := marshalStrings(filter.Templates)
+	if err != nil {
+		return fmt.Errorf("failed to serialize filter templates: %w", err)
+	}
+	hiddenTemplates, err := marshalStrings(filter.HiddenTemplates)
+	if err != nil {
+		return fmt.Errorf("failed to serialize filter hidden templates: %w", err)
+	}
+
+	_, err = l.Connection.Exec(
+		`
+			insert or replace into saved_filters (
+				name, description, search, mode, components, min_level, context_lines,
+				conditions, since, until, sql, sql_column, templates, hidden_templates
+			)
+			values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+		filter.Name,
+		filter.Description,
+		filter.Search,
+		filter.Mode.key(),
+		string(components),
+		filter.MinLevel,
+		filter.ContextLines,
+		string(conditions),
+		formatFilterTime(filter.Since),
+		formatFilterTime(filter.Until),
+		filter.SQL,
+		filter.SQLColumn,
+		templates,
+		hiddenTemplates,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save filter: %w", err)
+	}
+
+	return nil
+}
+
+// marshalStrings serializes the values as a JSON array, using an empty array
+// for nil values.
+func marshalStrings(values []string) (string, error) {
+	if values == nil {
+		return "[]", nil
+	}
+	encoded, err := json.Marshal(values)
+	return string(encoded), err
+}
+
+func formatFilterTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseFilterTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// logIdsFilter creates a filter that limits the lines to the given ones.
+func logIdsFilter(logIds []int) Filter {
+	if len(logIds) == 0 {
+		return Filter{SQL: "select rowid from logs where false"}
+	}
+	ids := make([]string, 0, len(logIds))
+	for _, logId := range logIds {
+		ids = append(ids, strconv.Itoa(logId))
+	}
+	return Filter{SQL: "select rowid from logs where rowid in (" + strings.Join(ids, ", ") + ")"}
+}
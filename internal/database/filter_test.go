@@ -0,0 +1,13 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_logIdsFilter(t *testing.T) {
+	assert.Equal(t, "select rowid from logs where rowid in (3, 15, 21)", logIdsFilter([]int{3, 15, 21}).SQL)
+	assert.Equal(t, "select rowid from logs where false", logIdsFilter(nil).SQL)
+	assert.Equal(t, "select rowid from logs where false", logIdsFilter([]int{}).SQL)
+}
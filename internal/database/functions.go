@@ -0,0 +1,158 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/newrelic/node-log-viewer/internal/common"
+	v0 "github.com/newrelic/node-log-viewer/internal/v0"
+	"modernc.org/sqlite"
+)
+
+// SQLFunction describes a helper function that is available to every
+// statement issued against the cache.
+type SQLFunction struct {
+	Signature   string
+	Description string
+}
+
+// SQLFunctions lists the helper functions registered with the sqlite driver.
+var SQLFunctions = []SQLFunction{
+	{
+		Signature: "nr_field(original, path)",
+		Description: "Value of the attribute at `path`, e.g. 'data.utilization.hostname'. " +
+			"Attributes holding serialized JSON are decoded as the path is followed.",
+	},
+	{
+		Signature:   "nr_level_name(level)",
+		Description: "Name of the numeric log level, e.g. 'Info' for 30.",
+	},
+	{
+		Signature:   "nr_kind(original)",
+		Description: "Kind of the log line: message, data, embedded_data, error, or attributes.",
+	},
+	{
+		Signature: "nr_source(path, offset, length)",
+		Description: "Bytes at `offset` of the file at `path`, which must be the source log file " +
+			"of the cache. Index only caches use it to read lines from the source log file, see " +
+			"the `log_sources` view.",
+	},
+}
+
+// The functions are registered with the driver, rather than with a specific
+// connection, so they must be registered before any connection is opened.
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction("nr_field", 2, nrField)
+	sqlite.MustRegisterDeterministicScalarFunction("nr_level_name", 1, nrLevelName)
+	sqlite.MustRegisterDeterministicScalarFunction("nr_kind", 1, nrKind)
+	sqlite.MustRegisterScalarFunction("nr_source", 3, nrSource)
+}
+
+func nrField(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	original, ok := textArg(args[0])
+	if ok == false {
+		return nil, nil
+	}
+	path, ok := textArg(args[1])
+	if ok == false {
+		return nil, fmt.Errorf("nr_field: path must be a string")
+	}
+
+	var document any
+	err := json.Unmarshal([]byte(original), &document)
+	if err != nil {
+		return nil, nil
+	}
+
+	value, found := common.LookupField(document, path)
+	if found == false {
+		return nil, nil
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return v, nil
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v), nil
+		}
+		return v, nil
+	case bool:
+		if v == true {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, nil
+		}
+		return string(encoded), nil
+	}
+}
+
+func nrLevelName(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	var number int
+	switch v := args[0].(type) {
+	case int64:
+		number = int(v)
+	case float64:
+		number = int(v)
+	default:
+		return nil, nil
+	}
+
+	name := v0.LevelFromNumber(number).String()
+	if name == "" {
+		return nil, nil
+	}
+	return name, nil
+}
+
+func nrKind(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	original, ok := textArg(args[0])
+	if ok == false {
+		return nil, nil
+	}
+
+	var envelope *v0.LineEnvelope
+	err := json.Unmarshal([]byte(original), &envelope)
+	if err != nil || envelope == nil {
+		return nil, nil
+	}
+	return common.TypeName(envelope.Kind()), nil
+}
+
+func nrSource(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	path, ok := textArg(args[0])
+	if ok == false {
+		return nil, nil
+	}
+	offset, ok := args[1].(int64)
+	if ok == false {
+		return nil, nil
+	}
+	length, ok := args[2].(int64)
+	if ok == false {
+		return nil, nil
+	}
+
+	source, err := readSource(path, offset, length)
+	if err != nil {
+		return nil, fmt.Errorf("nr_source: %w", err)
+	}
+	return source, nil
+}
+
+func textArg(value driver.Value) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	}
+	return "", false
+}
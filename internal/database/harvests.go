@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/newrelic/node-log-viewer/internal/harvest"
+)
+
+// Harvests stitches the cached lines of the collector components into
+// harvests, see [harvest.Tracker]. The harvests are ordered by the time they
+// were started. When sessionId is not zero, only the lines of that session are
+// considered, see [LogsDatabase.Sessions].
+func (l *LogsDatabase) Harvests(ctx context.Context, sessionId int) ([]harvest.Harvest, error) {
+	tracker := harvest.New()
+	err := l.readCollectorLines(ctx, sessionId, func(_ int, line harvest.Line) {
+		tracker.Add(line)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tracker.Harvests(), nil
+}
+
+// harvestsBySession is like [LogsDatabase.Harvests], except that the lines of
+// each session are stitched into harvests separately. The harvests are keyed
+// by the id of their session.
+func (l *LogsDatabase) harvestsBySession(ctx context.Context, sessionId int) (map[int][]harvest.Harvest, error) {
+	trackers := make(map[int]*harvest.Tracker)
+	err := l.readCollectorLines(ctx, sessionId, func(id int, line harvest.Line) {
+		tracker := trackers[id]
+		if tracker == nil {
+			tracker = harvest.New()
+			trackers[id] = tracker
+		}
+		tracker.Add(line)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int][]harvest.Harvest, len(trackers))
+	for id, tracker := range trackers {
+		result[id] = tracker.Harvests()
+	}
+	return result, nil
+}
+
+// readCollectorLines reads the lines of the collector components in the order
+// they were logged, and passes each of them, along with the id of its
+// session, to add.
+func (l *LogsDatabase) readCollectorLines(
+	ctx context.Context,
+	sessionId int,
+	add func(sessionId int, line harvest.Line),
+) error {
+	rows, err := l.Connection.QueryContext(
+		ctx,
+		fmt.Sprintf(
+			`
+				select
+					rowid,
+					unixepoch(time, 'subsec'),
+					coalesce(session_id, 0),
+					coalesce(pid, 0),
+					component,
+					coalesce(message, ''),
+					coalesce(json_extract(%[1]s, '$.compressed'), 0),
+					coalesce(length(cast(json_extract(%[1]s, '$.data') as blob)), 0),
+					coalesce(length(cast(json_extract(%[1]s, '$.body') as blob)), 0)
+				from logs
+				where component in (%[2]s) %[3]s
+				order by rowid
+			`,
+			originalColumn,
+			quoteSqlStrings(harvest.Components),
+			sessionCondition(sessionId),
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to read collector lines: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line harvest.Line
+		var seconds *float64
+		var id int
+		err = rows.Scan(
+			&line.LogId,
+			&seconds,
+			&id,
+			&line.Pid,
+			&line.Component,
+			&line.Message,
+			&line.Compressed,
+			&line.DataBytes,
+			&line.BodyBytes,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan collector line: %w", err)
+		}
+		line.Time = unixTime(seconds)
+		add(id, line)
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to read collector lines: %w", err)
+	}
+	return nil
+}
+
+// HarvestFilter creates a filter that limits the lines to those logged by the
+// harvest's process from the harvest's first line through its last line.
+func HarvestFilter(h harvest.Harvest) Filter {
+	if len(h.LogIds) == 0 {
+		return Filter{SQL: "select rowid from logs where false"}
+	}
+	return Filter{
+		SQL: fmt.Sprintf(
+			"select rowid from logs where coalesce(pid, 0) = %d and rowid between %d and %d",
+			h.Pid,
+			h.LogIds[0],
+			h.LogIds[len(h.LogIds)-1],
+		),
+	}
+}
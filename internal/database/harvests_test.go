@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/newrelic/node-log-viewer/internal/harvest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHarvests(t *testing.T) {
+	testDb := openTestDbCopy(t)
+
+	harvests, err := testDb.Harvests(context.Background(), 0)
+	require.Nil(t, err)
+	require.Equal(t, 97, len(harvests))
+
+	outcomes := make(map[harvest.Outcome][]harvest.Harvest)
+	for _, h := range harvests {
+		outcomes[h.Outcome] = append(outcomes[h.Outcome], h)
+	}
+	assert.Equal(t, 95, len(outcomes[harvest.OutcomeFinished]))
+
+	require.Equal(t, 1, len(outcomes[harvest.OutcomeMissing]))
+	missing := outcomes[harvest.OutcomeMissing][0]
+	assert.Equal(t, "span_event_data", missing.Method)
+	assert.Equal(t, time.Date(2025, 3, 6, 18, 16, 7, 229_000_000, time.UTC), missing.Start)
+	assert.Equal(t, 3, len(missing.LogIds))
+
+	require.Equal(t, 1, len(outcomes[harvest.OutcomeFailed]))
+	failed := outcomes[harvest.OutcomeFailed][0]
+	assert.Equal(t, "metric_data", failed.Method)
+	assert.Equal(t, 19573, failed.Pid)
+	assert.Equal(t, 409, failed.Status)
+
+	preconnect := harvests[1]
+	assert.Equal(t, "preconnect", preconnect.Method)
+	assert.Equal(t, 99445, preconnect.Pid)
+	assert.Equal(t, time.Date(2025, 3, 6, 18, 8, 4, 184_000_000, time.UTC), preconnect.Start)
+	assert.Equal(t, 554*time.Millisecond, preconnect.Duration())
+	assert.Equal(t, len(`[{"high_security":false}]`), preconnect.PayloadBytes)
+	assert.Equal(t, false, preconnect.Compressed)
+	assert.Equal(t, 4, len(preconnect.LogIds))
+}
+
+func TestHarvestFilter(t *testing.T) {
+	testDb := openTestDbCopy(t)
+
+	harvests, err := testDb.Harvests(context.Background(), 0)
+	require.Nil(t, err)
+
+	query := HarvestFilter(harvests[1]).Query(testDb, nullLogger)
+	results, err := query.AllResults()
+	require.Nil(t, err)
+	// Lines logged by other components during the harvest are included.
+	require.Equal(t, 8, len(results))
+	assert.Equal(t, "Invoking remote method preconnect", results[0].Message)
+	assert.Equal(t, "Finished receiving data back from the collector for preconnect.", results[7].Message)
+
+	query = HarvestFilter(harvest.Harvest{}).Query(testDb, nullLogger)
+	assert.Equal(t, 0, query.NumRows())
+}
@@ -1,45 +1,41 @@
 package database
 
 import (
-	"database/sql"
-	v0 "github.com/newrelic/node-log-viewer/internal/v0"
+	"fmt"
 	"strings"
-)
 
-const insertSql = `
-	insert into logs (version, time, component, message, original)
-	values (@version, @time, @component, @message, @original)
-`
+	v0 "github.com/newrelic/node-log-viewer/internal/v0"
+)
 
 type InsertTuple struct {
 	ParsedLog *v0.LineEnvelope
 	Source    string
+
+	// Offset and Length locate the line, in bytes, within the source log file.
+	// They are only used by index only caches, see
+	// [LogsDatabase.SetSourceFile].
+	Offset int64
+	Length int64
 }
 
 func (l *LogsDatabase) Insert(tuple InsertTuple) error {
-	log := tuple.ParsedLog
-	source := tuple.Source
-	_, err := l.Connection.Exec(
-		insertSql,
-		sql.Named("version", log.Version),
-		sql.Named("time", log.Time),
-		sql.Named("component", log.SourceComponent),
-		sql.Named("message", log.LogMessage),
-		sql.Named("original", source),
-	)
-	return err
+	return l.BatchInsert([]InsertTuple{tuple})
 }
 
 func (l *LogsDatabase) BatchInsert(tuples []InsertTuple) error {
 	l.logger.Debug("inserting batch of logs", "batch_size", len(tuples))
 
+	if l.source != nil {
+		return l.batchInsertOffsets(tuples)
+	}
+
 	builder := strings.Builder{}
-	builder.WriteString("insert into logs (version, time, component, message, original) values")
+	builder.WriteString("insert into logs (version, time, component, message, original, level, pid, hostname, kind) values")
 
 	values := make([]any, 0)
 	for _, tuple := range tuples {
 		log := tuple.ParsedLog
-		builder.WriteString("\n(?, ?, ?, ?, ?),")
+		builder.WriteString("\n(?, ?, ?, ?, ?, ?, ?, ?, ?),")
 		values = append(
 			values,
 			log.Version,
@@ -47,6 +43,10 @@ func (l *LogsDatabase) BatchInsert(tuples []InsertTuple) error {
 			log.SourceComponent,
 			log.LogMessage,
 			tuple.Source,
+			log.LogLevel.Number(),
+			log.Pid,
+			log.Hostname,
+			log.Kind(),
 		)
 	}
 
@@ -59,3 +59,68 @@ func (l *LogsDatabase) BatchInsert(tuples []InsertTuple) error {
 
 	return nil
 }
+
+// batchInsertOffsets inserts lines into an index only cache. The original
+// text of the lines is not stored, so the insert triggers cannot add the lines
+// to the full text index. Instead, the lines are given explicit row ids and
+// added to the index along with the lines. The trigram index is left empty, as
+// it would be several times the size of the log file, see [planSearch].
+func (l *LogsDatabase) batchInsertOffsets(tuples []InsertTuple) error {
+	tx, err := l.Connection.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var lastRowId int64
+	err = tx.QueryRow(`select coalesce(max(rowid), 0) from logs`).Scan(&lastRowId)
+	if err != nil {
+		return fmt.Errorf("failed to query for last row id: %w", err)
+	}
+
+	logsBuilder := strings.Builder{}
+	logsBuilder.WriteString("insert into logs (rowid, version, time, component, message, source_offset, source_length, level, pid, hostname, kind) values")
+	indexBuilder := strings.Builder{}
+	indexBuilder.WriteString("insert into logs_fts (rowid, component, message, original) values")
+
+	logsValues := make([]any, 0)
+	indexValues := make([]any, 0)
+	for i, tuple := range tuples {
+		log := tuple.ParsedLog
+		rowId := lastRowId + int64(i) + 1
+		logsBuilder.WriteString("\n(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?),")
+		logsValues = append(
+			logsValues,
+			rowId,
+			log.Version,
+			log.Time,
+			log.SourceComponent,
+			log.LogMessage,
+			tuple.Offset,
+			tuple.Length,
+			log.LogLevel.Number(),
+			log.Pid,
+			log.Hostname,
+			log.Kind(),
+		)
+
+		indexBuilder.WriteString("\n(?, ?, ?, ?),")
+		indexValues = append(indexValues, rowId, log.SourceComponent, log.LogMessage, tuple.Source)
+	}
+
+	statement, _ := strings.CutSuffix(logsBuilder.String(), ",")
+	_, err = tx.Exec(statement, logsValues...)
+	if err != nil {
+		l.logger.Error("failed to insert lines into database", "error", err)
+		return err
+	}
+
+	statement, _ = strings.CutSuffix(indexBuilder.String(), ",")
+	_, err = tx.Exec(statement, indexValues...)
+	if err != nil {
+		l.logger.Error("failed to index lines", "error", err)
+		return err
+	}
+
+	return tx.Commit()
+}
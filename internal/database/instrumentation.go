@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/newrelic/node-log-viewer/internal/instrumentation"
+)
+
+// Instrumentation builds the inventory of instrumented modules, see
+// [instrumentation.Builder]. When sessionId is not zero, only that session's
+// lines are considered.
+func (l *LogsDatabase) Instrumentation(ctx context.Context, sessionId int) ([]instrumentation.Module, error) {
+	rows, err := l.Connection.QueryContext(
+		ctx,
+		fmt.Sprintf(
+			`
+				select
+					rowid,
+					unixepoch(time, 'subsec'),
+					coalesce(pid, 0),
+					coalesce(level, 0),
+					component,
+					coalesce(message, ''),
+					coalesce(json_extract(%[1]s, '$.module'), ''),
+					coalesce(json_extract(%[1]s, '$.framework'), ''),
+					coalesce(json_extract(%[1]s, '$.datastore'), '')
+				from logs
+				where (component = '%[2]s' or component like '%%%[3]s') %[4]s
+				order by rowid
+			`,
+			originalColumn,
+			instrumentation.ShimmerComponent,
+			instrumentation.ShimSuffix,
+			sessionCondition(sessionId),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instrumentation lines: %w", err)
+	}
+	defer rows.Close()
+
+	builder := instrumentation.New()
+	for rows.Next() {
+		var line instrumentation.Line
+		var seconds *float64
+		err = rows.Scan(
+			&line.LogId,
+			&seconds,
+			&line.Pid,
+			&line.Level,
+			&line.Component,
+			&line.Message,
+			&line.Module,
+			&line.Framework,
+			&line.Datastore,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan instrumentation line: %w", err)
+		}
+		line.Time = unixTime(seconds)
+		builder.Add(line)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read instrumentation lines: %w", err)
+	}
+	return builder.Modules(), nil
+}
+
+// InstrumentationFilter creates a filter that limits the lines to those
+// logged while instrumenting the module.
+func InstrumentationFilter(m instrumentation.Module) Filter {
+	return logIdsFilter(m.LogIds)
+}
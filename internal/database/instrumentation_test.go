@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/node-log-viewer/internal/instrumentation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrumentation(t *testing.T) {
+	testDb := openTestDbCopy(t)
+
+	modules, err := testDb.Instrumentation(context.Background(), 0)
+	require.Nil(t, err)
+	require.Equal(t, 10, len(modules))
+
+	fs := modules[3]
+	assert.Equal(t, "fs", fs.Name)
+	assert.Equal(t, []string{"Shim"}, fs.Shims)
+	assert.Equal(t, 35, fs.Properties)
+	assert.Equal(t, instrumentation.StatusInstrumented, fs.Status())
+
+	http := modules[5]
+	assert.Equal(t, "http", http.Name)
+	assert.Equal(t, instrumentation.StatusPartial, http.Status())
+	assert.Equal(t, 6, len(http.Issues))
+	assert.Equal(t, 5, len(http.Pids))
+
+	results, err := InstrumentationFilter(http).Query(testDb, nullLogger).AllResults()
+	require.Nil(t, err)
+	assert.Equal(t, 11, len(results))
+
+	// The earlier sessions only logged that http is disabled.
+	modules, err = testDb.Instrumentation(context.Background(), 5)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(modules))
+	assert.Equal(t, instrumentation.StatusNotInstrumented, modules[0].Status())
+}
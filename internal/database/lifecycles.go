@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/newrelic/node-log-viewer/internal/lifecycle"
+	"github.com/newrelic/node-log-viewer/internal/session"
+)
+
+// SessionLifecycle is a session along with its reconstructed state machine.
+type SessionLifecycle struct {
+	session.Session
+	Lifecycle lifecycle.Lifecycle
+}
+
+// Lifecycles reconstructs the state machine of each session, see
+// [lifecycle.Tracker]. The lifecycles are ordered by session. When sessionId
+// is not zero, only that session's lifecycle is returned.
+func (l *LogsDatabase) Lifecycles(ctx context.Context, sessionId int) ([]SessionLifecycle, error) {
+	sessions, err := l.Sessions()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := l.Connection.QueryContext(
+		ctx,
+		fmt.Sprintf(
+			`
+				select
+					rowid,
+					unixepoch(time, 'subsec'),
+					coalesce(session_id, 0),
+					coalesce(component, ''),
+					coalesce(message, '')
+				from logs
+				where (message like 'Agent state changed from %%' or component in (%s)) %s
+				order by rowid
+			`,
+			quoteSqlStrings(lifecycle.Components),
+			sessionCondition(sessionId),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lifecycle lines: %w", err)
+	}
+	defer rows.Close()
+
+	trackers := make(map[int]*lifecycle.Tracker)
+	for rows.Next() {
+		var line lifecycle.Line
+		var seconds *float64
+		var id int
+		err = rows.Scan(&line.LogId, &seconds, &id, &line.Component, &line.Message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan lifecycle line: %w", err)
+		}
+		line.Time = unixTime(seconds)
+
+		tracker := trackers[id]
+		if tracker == nil {
+			tracker = lifecycle.New()
+			trackers[id] = tracker
+		}
+		tracker.Add(line)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read lifecycle lines: %w", err)
+	}
+
+	result := make([]SessionLifecycle, 0, len(sessions))
+	for _, s := range sessions {
+		if sessionId != 0 && s.Id != sessionId {
+			continue
+		}
+		tracker := trackers[s.Id]
+		if tracker == nil {
+			tracker = lifecycle.New()
+		}
+		result = append(result, SessionLifecycle{Session: s, Lifecycle: tracker.Lifecycle(s.End)})
+	}
+	return result, nil
+}
+
+// LifecycleFilter creates a filter that limits the lines to the state changes
+// of the lifecycle, and the lines that show its problems.
+func LifecycleFilter(l lifecycle.Lifecycle) Filter {
+	return logIdsFilter(l.LogIds())
+}
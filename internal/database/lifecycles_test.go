@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/node-log-viewer/internal/lifecycle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLifecycles(t *testing.T) {
+	testDb := openTestDbCopy(t)
+
+	lifecycles, err := testDb.Lifecycles(context.Background(), 0)
+	require.Nil(t, err)
+	require.Equal(t, 9, len(lifecycles))
+
+	neverStarted := make([]int, 0)
+	for _, l := range lifecycles {
+		for _, problem := range l.Lifecycle.Problems {
+			if problem.Kind == lifecycle.ProblemNeverStarted {
+				neverStarted = append(neverStarted, l.Pid)
+			}
+		}
+	}
+	assert.Equal(t, []int{12658, 13659}, neverStarted)
+	assert.Equal(t, "connecting", lifecycles[1].Lifecycle.State())
+
+	restarted := lifecycles[7]
+	assert.Equal(t, 19573, restarted.Pid)
+	assert.Equal(t, "started", restarted.Lifecycle.State())
+	assert.Equal(t, 8, len(restarted.Lifecycle.Transitions))
+	kinds := make([]lifecycle.ProblemKind, 0)
+	for _, problem := range restarted.Lifecycle.Problems {
+		kinds = append(kinds, problem.Kind)
+	}
+	assert.Equal(t, []lifecycle.ProblemKind{lifecycle.ProblemRestarted, lifecycle.ProblemDisconnected}, kinds)
+
+	scoped, err := testDb.Lifecycles(context.Background(), restarted.Id)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(scoped))
+	assert.Equal(t, restarted.Lifecycle, scoped[0].Lifecycle)
+
+	query := LifecycleFilter(restarted.Lifecycle).Query(testDb, nullLogger)
+	results, err := query.AllResults()
+	require.Nil(t, err)
+	require.Equal(t, 9, len(results))
+	assert.Equal(t, "Agent endpoint metric_data returned 409 status. Restarting.", results[4].Message)
+
+	query = LifecycleFilter(lifecycle.Lifecycle{}).Query(testDb, nullLogger)
+	assert.Equal(t, 0, query.NumRows())
+}
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"io/fs"
+	"strings"
 
 	"github.com/georgysavva/scany/v2/dbscan"
 	"github.com/georgysavva/scany/v2/sqlscan"
@@ -25,16 +27,30 @@ type LogsDatabase struct {
 	DatabaseFile string
 	logger       *log.Logger
 	scanner      *sqlscan.API
+
+	// source is the source log file of an index only cache, see
+	// [LogsDatabase.SetSourceFile]. It is nil for caches that store the lines.
+	source *SourceFile
 }
 
 type DbParams struct {
 	DatabaseFilePath string
-	DoMigration      bool
-	Logger           *log.Logger
+
+	// DoMigration applies pending migrations when the database is opened, see
+	// [LogsDatabase.MigrateUp]. Otherwise, opening a database with pending
+	// migrations fails with [ErrMigrationsPending].
+	DoMigration bool
+	Logger      *log.Logger
 }
 
+// ErrMigrationsPending is returned when a cache that was created by a
+// previous version is opened without applying the migrations.
+var ErrMigrationsPending = errors.New("cache file requires migrations")
+
 type DbRow struct {
 	RowId     int `db:"rowid"`
+	LogId     int
+	IsHit     bool
 	Version   int
 	Time      rfc3339.DateTime
 	Component string
@@ -50,7 +66,7 @@ func New(params DbParams) (*LogsDatabase, error) {
 		logger:       params.Logger,
 	}
 
-	db, err := sql.Open("sqlite", params.DatabaseFilePath)
+	db, err := sql.Open("sqlite", connectionString(params.DatabaseFilePath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database file: %w", err)
 	}
@@ -72,24 +88,97 @@ func New(params DbParams) (*LogsDatabase, error) {
 
 	if params.DoMigration == true {
 		err = result.MigrateUp()
+	} else {
+		err = result.verifyMigrated()
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	err = result.loadSourceFile()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// connectionString adds the connection parameters to the database file path.
+// Queries are materialized in the background while other queries are read,
+// so the write-ahead log is used to allow reads during writes, and
+// connections wait for locks to be released instead of failing immediately.
+func connectionString(databaseFilePath string) string {
+	separator := "?"
+	if strings.Contains(databaseFilePath, "?") {
+		separator = "&"
+	}
+	return databaseFilePath + separator + "_pragma=busy_timeout(10000)&_pragma=journal_mode(wal)"
+}
+
+// dropQueryTables removes the tables of materialized queries left behind in
+// the cache file by previous sessions.
+func (l *LogsDatabase) dropQueryTables() error {
+	rows, err := l.Connection.Query(
+		`select name from sqlite_schema where type = 'table' and (name = 'mv' or name like ? escape '\')`,
+		escapeLikePattern(queryTablePrefix)+"%",
+	)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		err = rows.Scan(&name)
 		if err != nil {
-			db.Close()
-			return nil, err
+			rows.Close()
+			return err
 		}
+		names = append(names, name)
 	}
+	rows.Close()
 
-	return result, nil
+	for _, name := range names {
+		_, err = l.Connection.Exec(fmt.Sprintf(`drop table if exists "%s"`, name))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (l *LogsDatabase) Close() {
+	if l.source != nil {
+		closeSource(l.source.Path)
+	}
 	err := l.Connection.Close()
 	if err != nil {
 		l.logger.Error("error closing database", "error", err)
 	}
 }
 
+// MigrateUp applies the pending schema migrations. It also upgrades the data
+// of caches created by previous versions: lines cached before sessions were
+// introduced are segmented, see [LogsDatabase.SegmentSessions], and the tables
+// of materialized queries left behind by previous runs are removed.
 func (l *LogsDatabase) MigrateUp() error {
-	return migrateUp(l.Connection)
+	err := migrateUp(l.Connection)
+	if err != nil {
+		return err
+	}
+
+	err = l.SegmentSessions()
+	if err != nil {
+		l.logger.Warn("could not segment sessions", "error", err)
+	}
+
+	err = l.dropQueryTables()
+	if err != nil {
+		l.logger.Warn("could not remove stale query tables", "error", err)
+	}
+	return nil
 }
 
 func (l *LogsDatabase) HasCachedLogs() (bool, error) {
@@ -106,6 +195,54 @@ func (l *LogsDatabase) HasCachedLogs() (bool, error) {
 	}
 }
 
+// verifyMigrated returns [ErrMigrationsPending] if the schema of the cache is
+// older than the latest migration. Unlike the migrator, it does not modify the
+// cache.
+func (l *LogsDatabase) verifyMigrated() error {
+	fsDriver, err := migrateFS.New(migrations.FS, "sql")
+	if err != nil {
+		return fmt.Errorf("failed to setup migrations fs: %w", err)
+	}
+	latest, err := fsDriver.First()
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %w", err)
+	}
+	for {
+		next, err := fsDriver.Next(latest)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read migrations: %w", err)
+		}
+		latest = next
+	}
+
+	var hasVersion bool
+	err = l.Connection.QueryRow(
+		`select count(*) > 0 from sqlite_schema where type = 'table' and name = 'schema_migrations'`,
+	).Scan(&hasVersion)
+	if err != nil {
+		return fmt.Errorf("failed to check for migrations: %w", err)
+	}
+	if hasVersion == false {
+		return ErrMigrationsPending
+	}
+
+	var version uint
+	var dirty bool
+	err = l.Connection.QueryRow(`select version, dirty from schema_migrations`).Scan(&version, &dirty)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrMigrationsPending
+	case err != nil:
+		return fmt.Errorf("failed to check for migrations: %w", err)
+	case dirty == true || version < latest:
+		return ErrMigrationsPending
+	}
+	return nil
+}
+
 func migrateUp(db *sql.DB) error {
 	// Set up the driver for the migration library:
 	driver, err := migrateSqlite.WithInstance(db, &migrateSqlite.Config{})
@@ -0,0 +1,36 @@
+-- The default fts5 tokenizer only matches whole tokens, e.g. `aggreg` will
+-- not match `base_aggregator`. The trigram tokenizer indexes every three
+-- character sequence, which allows for substring matching.
+create virtual table logs_trigram using fts5(
+  version unindexed,
+  time unindexed,
+  component,
+  message,
+  original,
+  content='logs',
+  tokenize='trigram'
+);
+
+-- Caches created prior to this migration already have rows in the `logs`
+-- table that need to be indexed.
+insert into logs_trigram (logs_trigram) values ('rebuild');
+
+create trigger logs_trigram_after_insert after insert on logs
+  begin
+    insert into logs_trigram (rowid, component, message, original)
+    values (new.rowid, new.component, new.message, new.original);
+  end;
+
+create trigger logs_trigram_after_delete after delete on logs
+  begin
+    insert into logs_trigram (logs_trigram, rowid, component, message, original)
+    values ('delete', old.rowid, old.component, old.message, old.original);
+  end;
+
+create trigger logs_trigram_after_update after update on logs
+  begin
+    insert into logs_trigram (logs_trigram, rowid, component, message, original)
+    values ('delete', old.rowid, old.component, old.message, old.original);
+    insert into logs_trigram (rowid, component, message, original)
+    values (new.rowid, new.component, new.message, new.original);
+  end;
@@ -0,0 +1,11 @@
+-- Named filters saved alongside the cached logs so that they travel with the
+-- cache file when it is shared.
+create table saved_filters (
+  name text primary key,
+  description text not null default '',
+  search text not null default '',
+  mode text not null default 'words',
+  components text not null default '[]',
+  min_level integer not null default 0,
+  context_lines integer not null default 0
+);
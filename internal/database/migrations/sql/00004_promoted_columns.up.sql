@@ -0,0 +1,43 @@
+-- Promote commonly grouped, and filtered, attributes of the log lines to
+-- their own columns so that they do not need to be extracted from the
+-- original JSON for every query.
+alter table logs add column level integer;
+alter table logs add column pid integer;
+alter table logs add column hostname text;
+
+-- The full text indexes only need to be updated when an indexed column
+-- changes. Otherwise, backfilling the promoted columns would rebuild the
+-- indexes for every line.
+drop trigger logs_after_update;
+create trigger logs_after_update after update of component, message, original on logs
+  begin
+    insert into logs_fts (logs_fts, rowid, component, message, original)
+    values ('delete', old.rowid, old.component, old.message, old.original);
+    insert into logs_fts (rowid, component, message, original)
+    values (new.rowid, new.component, new.message, new.original);
+  end;
+
+drop trigger logs_trigram_after_update;
+create trigger logs_trigram_after_update after update of component, message, original on logs
+  begin
+    insert into logs_trigram (logs_trigram, rowid, component, message, original)
+    values ('delete', old.rowid, old.component, old.message, old.original);
+    insert into logs_trigram (rowid, component, message, original)
+    values (new.rowid, new.component, new.message, new.original);
+  end;
+
+update logs
+set
+  level = json_extract(original, '$.level'),
+  pid = json_extract(original, '$.pid'),
+  hostname = json_extract(original, '$.hostname')
+where json_valid(original);
+
+create index logs_component_idx on logs (component);
+create index logs_level_idx on logs (level);
+create index logs_pid_idx on logs (pid);
+
+-- Saved filters may also be narrowed by the promoted columns and by time.
+alter table saved_filters add column conditions text not null default '{}';
+alter table saved_filters add column since text not null default '';
+alter table saved_filters add column until text not null default '';
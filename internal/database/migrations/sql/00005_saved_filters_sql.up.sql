@@ -0,0 +1,4 @@
+-- Saved filters may select their lines with an ad-hoc SQL statement, e.g. one
+-- written in the SQL console.
+alter table saved_filters add column sql text not null default '';
+alter table saved_filters add column sql_column text not null default '';
@@ -0,0 +1,7 @@
+-- Lines marked while triaging a log. They are keyed by the `logs` row id so
+-- that they travel with the cache file when it is shared.
+create table bookmarks (
+  log_id integer primary key,
+  note text not null default '',
+  created text not null
+);
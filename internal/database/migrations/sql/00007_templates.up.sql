@@ -0,0 +1,18 @@
+-- Message templates mined from the cached lines, and the template of each
+-- line. They are populated on demand, see `LogsDatabase.MineTemplates`.
+create table templates (
+  id integer primary key,
+  pattern text not null
+);
+
+create table line_templates (
+  log_id integer primary key,
+  template_id integer not null
+);
+
+create index line_templates_template_idx on line_templates (template_id);
+create index templates_pattern_idx on templates (pattern);
+
+-- Saved filters may be limited to, or exclude, lines with given templates.
+alter table saved_filters add column templates text not null default '[]';
+alter table saved_filters add column hidden_templates text not null default '[]';
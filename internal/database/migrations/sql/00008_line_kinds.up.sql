@@ -0,0 +1,16 @@
+-- The kind of each line, e.g. an error line, is computed once when the line
+-- is cached so that listing lines does not require decoding the original
+-- line. The numbers match `common.Type`. A line may be expanded in the line
+-- detail view when it holds more than a message.
+alter table logs add column kind integer not null default 0;
+alter table logs add column expandable integer generated always as (kind != 0) virtual;
+
+update logs
+set kind = case nr_kind(original)
+  when 'data' then 1
+  when 'embedded_data' then 2
+  when 'error' then 3
+  when 'attributes' then 4
+  else 0
+end
+where json_valid(original);
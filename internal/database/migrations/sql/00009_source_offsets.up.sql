@@ -0,0 +1,46 @@
+-- Caches created in the index only mode do not hold a copy of each line.
+-- Instead, the byte offset and length of each line within the source log file
+-- are recorded, and the line is read from the source file when it is needed.
+-- See `LogsDatabase.SetSourceFile`.
+alter table logs add column source_offset integer;
+alter table logs add column source_length integer;
+
+-- The source file of an index only cache, along with what is needed to verify
+-- that it has not changed since it was indexed.
+create table source_file (
+  id integer primary key check (id = 1),
+  path text not null,
+  size integer not null,
+  modified text not null,
+  checksum text not null
+);
+
+-- The original text of every line, regardless of whether it is stored in the
+-- cache or read from the source file.
+create view log_sources as
+select
+  logs.rowid as log_id,
+  coalesce(
+    logs.original,
+    nr_source(source_file.path, logs.source_offset, logs.source_length)
+  ) as original
+from logs
+left join source_file;
+
+-- Lines of an index only cache are added to the full text indexes when they
+-- are inserted, as the triggers do not have the original text to index.
+drop trigger logs_after_insert;
+create trigger logs_after_insert after insert on logs
+  when new.original is not null
+  begin
+    insert into logs_fts (rowid, component, message, original)
+    values (new.rowid, new.component, new.message, new.original);
+  end;
+
+drop trigger logs_trigram_after_insert;
+create trigger logs_trigram_after_insert after insert on logs
+  when new.original is not null
+  begin
+    insert into logs_trigram (rowid, component, message, original)
+    values (new.rowid, new.component, new.message, new.original);
+  end;
@@ -0,0 +1,16 @@
+-- Agent sessions, i.e. the lifetimes of the agent processes, detected from
+-- the cached lines, and the session of each line. They are populated after
+-- the lines have been cached, see `LogsDatabase.SegmentSessions`.
+create table sessions (
+  id integer primary key,
+  pid integer not null,
+  hostname text not null,
+  agent_version text not null,
+  node_version text not null,
+  start_time text not null,
+  end_time text not null,
+  line_count integer not null
+);
+
+alter table logs add column session_id integer;
+create index logs_session_id_idx on logs (session_id);
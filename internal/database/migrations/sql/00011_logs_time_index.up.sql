@@ -0,0 +1,5 @@
+-- Index the lines by time, overall and within their session, so that the lines
+-- logged closest to a point in time can be found without scanning every line.
+-- Queries must use the same expression for the indexes to apply.
+create index logs_time_idx on logs (unixepoch(time, 'subsec'));
+create index logs_session_time_idx on logs (session_id, unixepoch(time, 'subsec'));
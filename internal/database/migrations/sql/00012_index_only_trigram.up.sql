@@ -0,0 +1,6 @@
+-- The trigram index of an index only cache is several times the size of the
+-- log file, which defeats the purpose of not storing the lines. Substring
+-- searches of such caches scan the lines instead.
+insert into logs_trigram (logs_trigram)
+select 'delete-all'
+where exists (select 1 from source_file);
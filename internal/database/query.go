@@ -10,10 +10,15 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"iter"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/hashicorp/golang-lru/arc/v2"
 	"github.com/newrelic/node-log-viewer/internal/common"
@@ -21,161 +26,498 @@ import (
 	v0 "github.com/newrelic/node-log-viewer/internal/v0"
 )
 
+// Query is a set of log lines, e.g. the lines matching a search. The result
+// set is materialized into a table of its own the first time it is needed, or
+// ahead of time with [Query.Materialize]. A Query is safe for concurrent use,
+// so that it may be materialized in the background while another query is
+// being displayed.
 type Query struct {
-	db           *LogsDatabase
-	logger       *log.Logger
-	rowCache     *arc.ARCCache[int, common.Envelope]
+	db       *LogsDatabase
+	logger   *log.Logger
+	rowCache *arc.ARCCache[int, resultRow]
+	text     string
+
+	// table is the name of the table the result set is materialized into. It
+	// is unique to the query.
+	table string
+
+	// mutex guards materialized and numRows.
+	mutex        sync.Mutex
 	materialized bool
 	numRows      int
-	text         string
+
+	// filter is a SQL expression, evaluated against the `logs` table, that
+	// selects the lines matching the query. An empty filter selects all lines.
+	filter string
+
+	// contextLines is the number of surrounding lines to include with each
+	// line that matches the filter, similar to `grep -C`.
+	contextLines int
+
+	// err is the error found while building the query, e.g. an unsupported
+	// search term. It is returned by [Query.Materialize].
+	err error
+}
+
+// resultRow is a row from the materialized view of a query. Separator rows
+// mark a gap between non-contiguous chunks of lines in a context query, and
+// do not have a summary.
+type resultRow struct {
+	summary     *LineSummary
+	logId       int
+	isHit       bool
+	isSeparator bool
+}
+
+// LineSummary is the part of a line that is needed to list it. It is read
+// from the columns of the `logs` table that are computed when the line is
+// cached, so the original line does not need to be decoded. Use
+// [Query.GetRow] to get the full line.
+type LineSummary struct {
+	line v0.LineEnvelope
+	kind common.Type
+}
+
+func (s *LineSummary) Kind() common.Type {
+	return s.kind
+}
+
+// IsExpandable indicates if the line holds more than its message, e.g. the
+// payload of a collector request, that can be inspected in detail.
+func (s *LineSummary) IsExpandable() bool {
+	return s.kind != common.TypeMessage
+}
+
+func (s *LineSummary) Component() string {
+	return s.line.Component()
+}
+
+func (s *LineSummary) Level() common.LogLevel {
+	return s.line.Level()
+}
+
+func (s *LineSummary) Message() string {
+	return s.line.Message()
 }
 
+func (s *LineSummary) TimeStampString() string {
+	return s.line.TimeStampString()
+}
+
+func (s *LineSummary) Time() time.Time {
+	return s.line.Time.Time
+}
+
+// queryTableCounter is used to give each query's materialized table a unique
+// name.
+var queryTableCounter atomic.Int64
+
+// queryTablePrefix prefixes the names of the tables that query results are
+// materialized into.
+const queryTablePrefix = "mv_"
+
 // AllResults issues the base query statement and returns the set of
-// [database.DbRow].
+// [database.DbRow]. Separator rows are not included. The whole result set is
+// loaded into memory; prefer [Query.Results] for result sets that may be
+// large, e.g. when exporting lines.
 func (q *Query) AllResults() ([]DbRow, error) {
-	if q.materialized == false {
-		err := q.materialize()
+	dbRows := make([]DbRow, 0)
+	for row, err := range q.Results(context.Background()) {
 		if err != nil {
 			return nil, err
 		}
+		dbRows = append(dbRows, row)
 	}
+	return dbRows, nil
+}
 
-	rows, err := q.db.Connection.Query(`select rowid, * from mv`)
+// Results iterates the rows of the result set in order. Separator rows are not
+// included. Rows are read from the database as the iteration proceeds, so
+// only the current row is held in memory. The iteration stops at the first
+// error, which is yielded with a zero [DbRow].
+func (q *Query) Results(ctx context.Context) iter.Seq2[DbRow, error] {
+	return func(yield func(DbRow, error) bool) {
+		err := q.Materialize(ctx)
+		if err != nil {
+			yield(DbRow{}, err)
+			return
+		}
+
+		rows, err := q.db.Connection.QueryContext(
+			ctx,
+			fmt.Sprintf(
+				`
+					select m.rowid, m.*, s.original
+					from %s m
+					join log_sources s on s.log_id = m.log_id
+					where m.log_id > 0
+					order by m.row_num
+				`,
+				q.table,
+			),
+		)
+		if err != nil {
+			yield(DbRow{}, fmt.Errorf("failed to query for all records: %w", err))
+			return
+		}
+		defer rows.Close()
+
+		scanner := q.db.scanner.NewRowScanner(rows)
+		for rows.Next() {
+			var row DbRow
+			err = scanner.Scan(&row)
+			if err != nil {
+				yield(DbRow{}, fmt.Errorf("failed to scan query result: %w", err))
+				return
+			}
+			if yield(row, nil) == false {
+				return
+			}
+		}
+
+		err = rows.Err()
+		if err != nil {
+			yield(DbRow{}, fmt.Errorf("failed to read query results: %w", err))
+		}
+	}
+}
+
+// GetRow returns the envelope for the given row number of the result set. The
+// original line is decoded on every call, so it is meant for inspecting a
+// single line, e.g. in the line detail view; use [Query.GetSummary] to list
+// lines. A nil envelope is returned for separator rows, or if the row could
+// not be fetched.
+func (q *Query) GetRow(number int) common.Envelope {
+	row, ok := q.fetchRow(number)
+	if ok == false || row.isSeparator == true {
+		return nil
+	}
+
+	var original string
+	err := q.db.Connection.QueryRow(
+		fmt.Sprintf(`select original from log_sources where log_id = %d`, row.logId),
+	).Scan(&original)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query for all records: %w", err)
+		q.logger.Error("failed to query for original line", "error", err, "logId", row.logId)
+		return nil
 	}
 
-	var dbRows []DbRow
-	err = q.db.scanner.ScanAll(&dbRows, rows)
+	var envelope *v0.LineEnvelope
+	err = json.Unmarshal([]byte(original), &envelope)
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan query results: %w", err)
+		q.logger.Error("failed to parse original log line", "error", err, "original", original)
+		return nil
 	}
+	return envelope
+}
 
-	return dbRows, nil
+// GetSummary returns the summary of the line for the given row number of the
+// result set. A nil summary is returned for separator rows, or if the row
+// could not be fetched.
+func (q *Query) GetSummary(number int) *LineSummary {
+	row, ok := q.fetchRow(number)
+	if ok == false {
+		return nil
+	}
+	return row.summary
 }
 
-func (q *Query) GetRow(number int) common.Envelope {
-	if q.materialized == false {
-		err := q.materialize()
-		if err != nil {
-			q.logger.Error("could not fetch requested row", "error", err)
-			return nil
-		}
+// LogId returns the identifier of the source log line for the given row
+// number of the result set. Zero is returned for separator rows.
+func (q *Query) LogId(number int) int {
+	row, _ := q.fetchRow(number)
+	return row.logId
+}
+
+// IsHit indicates if the given row number of the result set matches the
+// query's filter, as opposed to being a context line.
+func (q *Query) IsHit(number int) bool {
+	row, _ := q.fetchRow(number)
+	return row.isHit
+}
+
+// IsSeparator indicates if the given row number of the result set is
+// a separator between non-contiguous chunks of lines.
+func (q *Query) IsSeparator(number int) bool {
+	row, _ := q.fetchRow(number)
+	return row.isSeparator
+}
+
+// HasContext indicates if the result set includes context lines around the
+// lines matching the query's filter.
+func (q *Query) HasContext() bool {
+	return q.contextLines > 0
+}
+
+// IsFiltered indicates if the query excludes any lines from the full set of
+// logs.
+func (q *Query) IsFiltered() bool {
+	return q.filter != ""
+}
+
+// WithContext returns a new query, with the same filter, whose result set
+// includes the given number of lines before and after each matching line.
+func (q *Query) WithContext(lines int) *Query {
+	return newQuery(q.db, q.logger, q.filter, lines)
+}
+
+// RowNumberOf returns the row number, within the result set, of the given
+// source log line identifier. Zero is returned if the line is not part of
+// the result set.
+func (q *Query) RowNumberOf(logId int) int {
+	err := q.Materialize(context.Background())
+	if err != nil {
+		q.logger.Error("could not find requested line", "error", err)
+		return 0
+	}
+
+	var rowNum int
+	statement := fmt.Sprintf(`select row_num from %s where log_id = ?`, q.table)
+	err = q.db.Connection.QueryRow(statement, logId).Scan(&rowNum)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0
+	case err != nil:
+		q.logger.Error("failed to query for row number", "error", err, "logId", logId)
+		return 0
+	}
+
+	return rowNum
+}
+
+func (q *Query) fetchRow(number int) (resultRow, bool) {
+	err := q.Materialize(context.Background())
+	if err != nil {
+		q.logger.Error("could not fetch requested row", "error", err)
+		return resultRow{}, false
 	}
 
 	if q.rowCache.Contains(number) {
 		v, _ := q.rowCache.Get(number)
-		return v
+		return v, true
 	}
 
 	statement := fmt.Sprintf(
-		`select * from mv where row_num = %d`,
+		`
+			select
+				log_id, is_hit, time, coalesce(level, 0), coalesce(component, ''),
+				coalesce(message, ''), coalesce(kind, 0)
+			from %s
+			where row_num = %d
+		`,
+		q.table,
 		number,
 	)
 
-	rows, err := q.db.Connection.Query(statement)
-	if err != nil {
-		q.logger.Error("could not query for requested row", "error", err, "statement", statement)
-		return nil
-	}
-
-	var dbRow DbRow
-	err = q.db.scanner.ScanOne(&dbRow, rows)
+	var row resultRow
+	var level int
+	summary := &LineSummary{}
+	err = q.db.Connection.QueryRow(statement).Scan(
+		&row.logId,
+		&row.isHit,
+		&summary.line.Time,
+		&level,
+		&summary.line.SourceComponent,
+		&summary.line.LogMessage,
+		&summary.kind,
+	)
 	switch {
 	case errors.Is(err, sql.ErrNoRows):
 		q.logger.Error("could not fetch requested row", "statement", statement)
-		return nil
+		return resultRow{}, false
 	case err != nil:
 		q.logger.Error("failed to query for row", "error", err, "statement", statement)
-		return nil
+		return resultRow{}, false
 	}
 
-	var envelope *v0.LineEnvelope
-	err = json.Unmarshal([]byte(dbRow.Original), &envelope)
-	if err != nil {
-		q.logger.Error("failed to parse original log line", "error", err, "original", dbRow.Original)
-		return nil
+	if row.logId == 0 {
+		row.isSeparator = true
+		q.rowCache.Add(number, row)
+		return row, true
 	}
 
-	q.rowCache.Add(number, envelope)
-	return envelope
+	summary.line.LogLevel = v0.LevelFromNumber(level)
+	row.summary = summary
+
+	q.rowCache.Add(number, row)
+	return row, true
 }
 
+// NumRows returns the number of rows in the result set, including separator
+// rows. The result set is materialized first if necessary.
 func (q *Query) NumRows() int {
-	if q.materialized == false {
-		err := q.materialize()
-		if err != nil {
-			q.logger.Error("cannot determine number of rows", "error", err)
-			return q.numRows
-		}
+	err := q.Materialize(context.Background())
+	if err != nil {
+		q.logger.Error("cannot determine number of rows", "error", err)
 	}
 
-	if q.numRows > 0 {
-		q.logger.Trace("returning cached number of rows", "numRows", q.numRows)
-		return q.numRows
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.numRows
+}
+
+// Materialize stores the result set in the query's table, and counts its
+// rows, unless that has already been done. It may be called ahead of time,
+// e.g. from a background goroutine, so that the rows can then be fetched
+// without delay. If the context is cancelled before the result set has been
+// stored, the partial result set is discarded and the context's error is
+// returned.
+func (q *Query) Materialize(ctx context.Context) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.materialized == true {
+		return nil
+	}
+	if q.err != nil {
+		return q.err
 	}
 
-	q.logger.Trace("querying for number of rows in view")
-	statement := "select count(*) from mv"
+	statements := []string{
+		fmt.Sprintf(`drop table if exists %s`, q.table),
+		fmt.Sprintf(`create table %s as %s`, q.table, q.text),
+		fmt.Sprintf(`create index %[1]s_row_idx on %[1]s (row_num)`, q.table),
+		fmt.Sprintf(`create index %[1]s_log_idx on %[1]s (log_id)`, q.table),
+	}
+	for _, statement := range statements {
+		_, err := q.db.Connection.ExecContext(ctx, statement)
+		if err != nil {
+			q.dropTable()
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			return fmt.Errorf("failed to materialize query: %w", err)
+		}
+	}
+
+	q.logger.Trace("querying for number of rows in view", "table", q.table)
 	var numRows int
-	err := q.db.Connection.QueryRow(statement).Scan(&numRows)
-	switch {
-	case errors.Is(err, sql.ErrNoRows):
-		q.logger.Error("could not fetch number of rows", "statement", statement)
-		return 0
-	case err != nil:
-		q.logger.Error("failed to query for row count", "error", err, "statement", statement)
-		return 0
+	err := q.db.Connection.QueryRowContext(ctx, fmt.Sprintf(`select count(*) from %s`, q.table)).Scan(&numRows)
+	if err != nil {
+		q.dropTable()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("failed to query for row count: %w", err)
 	}
 
-	q.logger.Trace("finished querying for number of rows in view", "numRows", numRows)
+	q.logger.Trace("finished materializing view", "table", q.table, "numRows", numRows)
 	q.numRows = numRows
-	return numRows
+	q.materialized = true
+	return nil
 }
 
-func (q *Query) materialize() error {
-	statement := fmt.Sprintf(
-		`
-			drop table if exists mv;
-			drop index if exists mv_row_idx;
-			create table mv as %s;
-			create index mv_row_idx on mv (row_num);
-		`,
-		q.text,
-	)
-	_, err := q.db.Connection.Exec(statement)
+// Close discards the materialized result set. The query may still be used
+// afterward, in which case the result set is materialized again.
+func (q *Query) Close() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.dropTable()
+	q.materialized = false
+	q.numRows = 0
+	q.rowCache.Purge()
+}
+
+// dropTable removes the query's table. The mutex must be held by the caller.
+func (q *Query) dropTable() {
+	_, err := q.db.Connection.Exec(fmt.Sprintf(`drop table if exists %s`, q.table))
 	if err != nil {
-		return fmt.Errorf("failed to materialize query: %w", err)
+		q.logger.Error("failed to drop materialized query", "table", q.table, "error", err)
 	}
-	q.materialized = true
-	return nil
 }
 
-func SelectAllQuery(db *LogsDatabase, logger *log.Logger) *Query {
-	cache, _ := arc.NewARC[int, common.Envelope](1_024)
+func newQuery(db *LogsDatabase, logger *log.Logger, filter string, contextLines int) *Query {
+	cache, _ := arc.NewARC[int, resultRow](1_024)
+
+	var text string
+	if contextLines > 0 && filter != "" {
+		text = contextStatement(filter, contextLines)
+	} else {
+		text = selectStatement(filter)
+	}
+
 	return &Query{
-		db:       db,
-		logger:   logger,
-		rowCache: cache,
-		text:     `select row_number() over (order by rowid) as row_num, * from logs_fts`,
+		db:           db,
+		logger:       logger,
+		rowCache:     cache,
+		text:         text,
+		table:        fmt.Sprintf("%s%d", queryTablePrefix, queryTableCounter.Add(1)),
+		filter:       filter,
+		contextLines: contextLines,
 	}
 }
 
-func SearchQuery(searchTerm string, db *LogsDatabase, logger *log.Logger) *Query {
-	cache, _ := arc.NewARC[int, common.Envelope](1_024)
-	statement := fmt.Sprintf(
+// selectStatement builds the statement for a result set consisting of only
+// the lines matching the filter.
+func selectStatement(filter string) string {
+	where := ""
+	if filter != "" {
+		where = "where " + filter
+	}
+	return fmt.Sprintf(
 		`
 			select
-				row_number() over (order by rowid) as row_num, *
-			from logs_fts
-			where logs_fts match '%s'
+				row_number() over (order by rowid) as row_num,
+				rowid as log_id,
+				1 as is_hit,
+				version, time, component, message, level, kind
+			from logs
+			%s
 		`,
-		searchTerm,
+		where,
 	)
-	return &Query{
-		db:       db,
-		logger:   logger,
-		rowCache: cache,
-		text:     statement,
-	}
+}
+
+// contextStatement builds the statement for a result set consisting of the
+// lines matching the filter along with the lines surrounding them. A separator
+// row, i.e. a row with a `log_id` of 0, is added at the start of each chunk of
+// lines that does not directly follow the previous chunk.
+func contextStatement(filter string, contextLines int) string {
+	return fmt.Sprintf(
+		`
+			with
+				hits as (select rowid as id from logs where %[1]s),
+				ctx as (
+					select distinct l.rowid as id
+					from logs l
+					join hits h on l.rowid between h.id - %[2]d and h.id + %[2]d
+				),
+				gaps as (
+					select id, id - lag(id) over (order by id) as gap from ctx
+				),
+				lines as (
+					select id, id as sort_key from gaps
+					union all
+					select 0, id - 0.5 from gaps where gap > 1
+				)
+			select
+				row_number() over (order by lines.sort_key) as row_num,
+				lines.id as log_id,
+				lines.id in (select id from hits) as is_hit,
+				l.version, l.time, l.component, l.message, l.level, l.kind
+			from lines
+			left join logs l on l.rowid = lines.id
+		`,
+		filter,
+		contextLines,
+	)
+}
+
+// SelectAllQuery creates a query that includes every cached log line.
+func SelectAllQuery(db *LogsDatabase, logger *log.Logger) *Query {
+	return newQuery(db, logger, "", 0)
+}
+
+// SearchQuery creates a query that filters the logs to the lines matching the
+// provided fts5 search expression.
+func SearchQuery(searchTerm string, db *LogsDatabase, logger *log.Logger) *Query {
+	return ModeSearchQuery(searchTerm, SearchModeWords, db, logger)
+}
+
+// ModeSearchQuery creates a query that filters the logs to the lines matching
+// the search term according to the given [SearchMode]. The appropriate index
+// is chosen based on the mode and the search term.
+func ModeSearchQuery(searchTerm string, mode SearchMode, db *LogsDatabase, logger *log.Logger) *Query {
+	return Filter{Search: searchTerm, Mode: mode}.Query(db, logger)
 }
@@ -1,26 +1,49 @@
 package database
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/newrelic/node-log-viewer/internal/common"
 	"github.com/newrelic/node-log-viewer/internal/log"
+	v0 "github.com/newrelic/node-log-viewer/internal/v0"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 var nullLogger = log.NewDiscardLogger()
 
-func TestQuery(t *testing.T) {
+// openTestDbCopy opens a copy of the test database, so that neither the
+// migrations nor the tests modify the fixture. The copy is removed when the
+// test finishes.
+func openTestDbCopy(t *testing.T) *LogsDatabase {
+	t.Helper()
+	contents, err := os.ReadFile("./testdata/http-server.log.sqlite")
+	require.Nil(t, err)
+	file := filepath.Join(t.TempDir(), "http-server.log.sqlite")
+	require.Nil(t, os.WriteFile(file, contents, 0644))
+
 	testDb, err := New(DbParams{
-		DatabaseFilePath: "./testdata/http-server.log.sqlite",
+		DatabaseFilePath: file,
 		DoMigration:      true,
 		Logger:           nullLogger,
 	})
 	require.Nil(t, err)
-
 	t.Cleanup(func() {
 		testDb.Close()
 	})
+	return testDb
+}
+
+func TestQuery(t *testing.T) {
+	testDb := openTestDbCopy(t)
 
 	t.Run("can get specified row", func(t *testing.T) {
 		query := SelectAllQuery(testDb, nullLogger)
@@ -29,6 +52,41 @@ func TestQuery(t *testing.T) {
 		assert.Equal(t, "Agent state changed from stopped to starting.", envelope.Message())
 	})
 
+	t.Run("summarizes rows from the computed columns", func(t *testing.T) {
+		query := SelectAllQuery(testDb, nullLogger)
+		summary := query.GetSummary(4)
+		require.NotNil(t, summary)
+		assert.Equal(t, "Agent state changed from stopped to starting.", summary.Message())
+		assert.Equal(t, common.TypeMessage, summary.Kind())
+		assert.Equal(t, false, summary.IsExpandable())
+		assert.Equal(t, query.GetRow(4).Component(), summary.Component())
+		assert.Equal(t, query.GetRow(4).Level().String(), summary.Level().String())
+		assert.Equal(t, query.GetRow(4).TimeStampString(), summary.TimeStampString())
+
+		query = ModeSearchQuery("data[0].high_security = false", SearchModeWords, testDb, nullLogger)
+		summary = query.GetSummary(1)
+		require.NotNil(t, summary)
+		assert.Equal(t, common.TypeDataIncluded, summary.Kind())
+		assert.Equal(t, true, summary.IsExpandable())
+	})
+
+	t.Run("stores the kind of every line", func(t *testing.T) {
+		var mismatched int
+		err := testDb.Connection.QueryRow(`
+			select count(*) from logs
+			where kind != case nr_kind(original)
+				when 'data' then 1
+				when 'embedded_data' then 2
+				when 'error' then 3
+				when 'attributes' then 4
+				else 0
+			end
+			or expandable != (nr_kind(original) != 'message')
+		`).Scan(&mismatched)
+		require.Nil(t, err)
+		assert.Equal(t, 0, mismatched)
+	})
+
 	t.Run("search query returns expected rows", func(t *testing.T) {
 		query := SearchQuery("shim", testDb, nullLogger)
 		numRows := query.NumRows()
@@ -39,4 +97,200 @@ func TestQuery(t *testing.T) {
 		assert.Equal(t, 1, rows[0].RowId)
 		assert.Equal(t, 385, rows[len(rows)-1].RowId)
 	})
+
+	t.Run("substring search matches partial words", func(t *testing.T) {
+		query := ModeSearchQuery("aggreg", SearchModeWords, testDb, nullLogger)
+		assert.Equal(t, 0, query.NumRows())
+
+		query = ModeSearchQuery("aggreg", SearchModeSubstring, testDb, nullLogger)
+		assert.Equal(t, 4_456, query.NumRows())
+	})
+
+	t.Run("substring search handles short terms", func(t *testing.T) {
+		query := ModeSearchQuery("5%", SearchModeSubstring, testDb, nullLogger)
+		numRows := query.NumRows()
+		assert.Equal(t, 0, numRows)
+
+		query = ModeSearchQuery("v2", SearchModeSubstring, testDb, nullLogger)
+		numRows = query.NumRows()
+		assert.Greater(t, numRows, 0)
+	})
+
+	t.Run("field predicates match attributes of lines", func(t *testing.T) {
+		query := ModeSearchQuery("rss > 80000000", SearchModeWords, testDb, nullLogger)
+		assert.Equal(t, 1, query.NumRows())
+
+		query = ModeSearchQuery("compressed = false", SearchModeWords, testDb, nullLogger)
+		assert.Equal(t, 96, query.NumRows())
+
+		// The `data` attribute is a JSON document serialized to a string.
+		query = ModeSearchQuery("data[0].high_security = false", SearchModeWords, testDb, nullLogger)
+		assert.Equal(t, 4, query.NumRows())
+		query = ModeSearchQuery("data.0.utilization.hostname = 'localhost'", SearchModeWords, testDb, nullLogger)
+		assert.Equal(t, 1, query.NumRows())
+
+		query = ModeSearchQuery("compressed = false AND metric_data", SearchModeWords, testDb, nullLogger)
+		assert.Equal(t, 50, query.NumRows())
+
+		// Substring searches are literal.
+		query = ModeSearchQuery("compressed = false", SearchModeSubstring, testDb, nullLogger)
+		assert.Equal(t, 0, query.NumRows())
+	})
+
+	t.Run("context query includes surrounding lines", func(t *testing.T) {
+		query := ModeSearchQuery("new_relic_response", SearchModeSubstring, testDb, nullLogger)
+		assert.Equal(t, 4, query.NumRows())
+		assert.Equal(t, false, query.HasContext())
+
+		query = query.WithContext(2)
+		assert.Equal(t, true, query.HasContext())
+		// Chunks of 5, 5, and 7 lines with a separator between each chunk.
+		assert.Equal(t, 19, query.NumRows())
+
+		assert.Equal(t, 295, query.LogId(1))
+		assert.Equal(t, false, query.IsHit(1))
+		assert.Equal(t, 297, query.LogId(3))
+		assert.Equal(t, true, query.IsHit(3))
+
+		assert.Equal(t, true, query.IsSeparator(6))
+		assert.Nil(t, query.GetRow(6))
+		assert.Equal(t, 376, query.LogId(7))
+		assert.Equal(t, true, query.IsSeparator(12))
+		assert.Equal(t, 418, query.LogId(19))
+
+		rows, err := query.AllResults()
+		assert.Nil(t, err)
+		assert.Equal(t, 17, len(rows))
+	})
+
+	t.Run("materializes queries concurrently", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		query := ModeSearchQuery("aggreg", SearchModeSubstring, testDb, nullLogger)
+		err := query.Materialize(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		other := SelectAllQuery(testDb, nullLogger)
+		require.Nil(t, other.Materialize(context.Background()))
+
+		wg := sync.WaitGroup{}
+		counts := make([]int, 4)
+		for i := range counts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				counts[i] = query.NumRows()
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, []int{4_456, 4_456, 4_456, 4_456}, counts)
+		assert.Equal(t, 8_092, other.NumRows())
+		assert.Equal(t, "Agent state changed from stopped to starting.", other.GetRow(4).Message())
+
+		query.Close()
+		other.Close()
+		assert.Equal(t, 4_456, query.NumRows())
+	})
+
+	t.Run("finds row number of a source line", func(t *testing.T) {
+		query := SelectAllQuery(testDb, nullLogger)
+		assert.Equal(t, 378, query.RowNumberOf(378))
+
+		query = ModeSearchQuery("new_relic_response", SearchModeSubstring, testDb, nullLogger)
+		assert.Equal(t, 2, query.RowNumberOf(378))
+		assert.Equal(t, 0, query.RowNumberOf(379))
+	})
+
+	t.Run("lists distinct components and field names", func(t *testing.T) {
+		components, err := testDb.Components(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, 30, len(components))
+		assert.Contains(t, components, "remote_method")
+
+		fields, err := testDb.FieldNames(context.Background())
+		assert.Nil(t, err)
+		assert.Contains(t, fields, "msg")
+		assert.Contains(t, fields, "data")
+		assert.NotContains(t, fields, "utilization")
+	})
+}
+
+func TestQuery_Results(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generates a large synthetic cache")
+	}
+
+	testDb, err := New(DbParams{
+		DatabaseFilePath: filepath.Join(t.TempDir(), "large.sqlite"),
+		DoMigration:      true,
+		Logger:           nullLogger,
+	})
+	require.Nil(t, err)
+	t.Cleanup(func() {
+		testDb.Close()
+	})
+
+	// Lines of 32KiB each, for a total of 64MiB of original text.
+	const numLines = 2_048
+	const lineSize = 32 * 1_024
+	padding := strings.Repeat("x", lineSize)
+	for batch := 0; batch < numLines/128; batch++ {
+		tuples := make([]InsertTuple, 0, 128)
+		for i := 0; i < 128; i++ {
+			var envelope v0.LineEnvelope
+			source := fmt.Sprintf(
+				`{"v":0,"level":30,"name":"newrelic","hostname":"localhost","pid":1,"time":"2025-03-06T18:08:04.184Z","msg":"line %d","padding":"%s"}`,
+				batch*128+i,
+				padding,
+			)
+			require.Nil(t, json.Unmarshal([]byte(source), &envelope))
+			tuples = append(tuples, InsertTuple{ParsedLog: &envelope, Source: source})
+		}
+		require.Nil(t, testDb.BatchInsert(tuples))
+	}
+
+	t.Run("iterates rows in constant memory", func(t *testing.T) {
+		query := SelectAllQuery(testDb, nullLogger)
+		t.Cleanup(query.Close)
+		require.Nil(t, query.Materialize(context.Background()))
+
+		var stats runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		baseline := stats.HeapAlloc
+		peak := baseline
+
+		count := 0
+		retained := make([]int, 0, numLines)
+		for row, err := range query.Results(context.Background()) {
+			require.Nil(t, err)
+			retained = append(retained, row.LogId)
+			count += 1
+			if count%256 == 0 {
+				runtime.GC()
+				runtime.ReadMemStats(&stats)
+				peak = max(peak, stats.HeapAlloc)
+			}
+		}
+
+		assert.Equal(t, numLines, count)
+		assert.Equal(t, numLines, len(retained))
+		// Holding every row would require at least the 64MiB of original text.
+		assert.Less(t, peak-baseline, uint64(8*1_024*1_024))
+	})
+
+	t.Run("stops reading rows when the iteration stops", func(t *testing.T) {
+		query := SelectAllQuery(testDb, nullLogger)
+		t.Cleanup(query.Close)
+
+		count := 0
+		for _, err := range query.Results(context.Background()) {
+			require.Nil(t, err)
+			count += 1
+			if count == 10 {
+				break
+			}
+		}
+		assert.Equal(t, 10, count)
+	})
 }
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/newrelic/node-log-viewer/internal/sampler"
+)
+
+// Samples extracts the time series of the `sampler` component, see
+// [sampler.Extractor]. When sessionId is not zero, only that session's
+// samples are extracted.
+func (l *LogsDatabase) Samples(ctx context.Context, sessionId int) ([]sampler.Series, error) {
+	fields := make([]string, 0, len(sampler.MemoryFields))
+	for _, field := range sampler.MemoryFields {
+		fields = append(
+			fields,
+			fmt.Sprintf("iif(message = '%s', json_extract(%s, '$.%s'), null)", sampler.MemoryMessage, originalColumn, field),
+		)
+	}
+
+	rows, err := l.Connection.QueryContext(
+		ctx,
+		fmt.Sprintf(
+			`
+				select
+					rowid,
+					unixepoch(time, 'subsec'),
+					coalesce(message, ''),
+					%s
+				from logs
+				where component = '%s' %s
+				order by rowid
+			`,
+			strings.Join(fields, ", "),
+			sampler.Component,
+			sessionCondition(sessionId),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sampler lines: %w", err)
+	}
+	defer rows.Close()
+
+	extractor := sampler.New()
+	values := make([]*float64, len(sampler.MemoryFields))
+	for rows.Next() {
+		var line sampler.Line
+		var seconds *float64
+		destinations := []any{&line.LogId, &seconds, &line.Message}
+		for i := range values {
+			destinations = append(destinations, &values[i])
+		}
+		err = rows.Scan(destinations...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sampler line: %w", err)
+		}
+		line.Time = unixTime(seconds)
+
+		line.Memory = make(map[string]float64)
+		for i, value := range values {
+			if value != nil {
+				line.Memory[sampler.MemoryFields[i]] = *value
+			}
+		}
+		extractor.Add(line)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read sampler lines: %w", err)
+	}
+	return extractor.Series(), nil
+}
+
+// NearestLogId returns the id of the line logged closest to the given time.
+// When sessionId is not zero, only that session's lines are considered. It
+// returns zero when there are no lines. The lines on either side of the time
+// are looked up by the time indexes, rather than by ordering every line by
+// its distance to the time.
+func (l *LogsDatabase) NearestLogId(ctx context.Context, at time.Time, sessionId int) (int, error) {
+	seconds := float64(at.UnixMicro()) / 1e6
+
+	logId := 0
+	distance := math.Inf(1)
+	sides := []struct {
+		operator  string
+		direction string
+	}{
+		{operator: "<=", direction: "desc"},
+		{operator: ">=", direction: "asc"},
+	}
+	for _, side := range sides {
+		row := l.Connection.QueryRowContext(
+			ctx,
+			fmt.Sprintf(
+				`
+					select rowid, unixepoch(time, 'subsec')
+					from logs
+					where unixepoch(time, 'subsec') %s ? %s
+					order by unixepoch(time, 'subsec') %s
+					limit 1
+				`,
+				side.operator,
+				sessionCondition(sessionId),
+				side.direction,
+			),
+			seconds,
+		)
+
+		var candidate int
+		var candidateSeconds float64
+		err := row.Scan(&candidate, &candidateSeconds)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to find nearest line: %w", err)
+		}
+		if math.Abs(candidateSeconds-seconds) < distance {
+			logId = candidate
+			distance = math.Abs(candidateSeconds - seconds)
+		}
+	}
+	return logId, nil
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/newrelic/node-log-viewer/internal/sampler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSamples(t *testing.T) {
+	testDb := openTestDbCopy(t)
+
+	series, err := testDb.Samples(context.Background(), 0)
+	require.Nil(t, err)
+	names := make([]string, 0, len(series))
+	for _, s := range series {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, "Memory/rss", names[0])
+	assert.Contains(t, names, "Nodejs/EventLoop/CPU/Usage")
+	assert.Contains(t, names, "GC/System/Pauses")
+
+	rss := series[0]
+	assert.Equal(t, sampler.UnitBytes, rss.Unit)
+	assert.Equal(t, 598, len(rss.Points))
+	assert.Equal(t, 84197376.0, rss.Points[0].Value)
+	assert.Equal(t, time.Date(2025, 3, 6, 18, 8, 9, 169_000_000, time.UTC), rss.Points[0].Time)
+
+	// Only the last session sampled its memory.
+	series, err = testDb.Samples(context.Background(), 1)
+	require.Nil(t, err)
+	assert.Empty(t, series)
+
+	logId, err := testDb.NearestLogId(context.Background(), rss.Points[0].Time.Add(time.Millisecond), 0)
+	require.Nil(t, err)
+	assert.Equal(t, rss.Points[0].LogId, logId)
+
+	logId, err = testDb.NearestLogId(context.Background(), rss.Points[0].Time.Add(-time.Millisecond), 9)
+	require.Nil(t, err)
+	assert.Equal(t, rss.Points[0].LogId, logId)
+
+	// Times outside of the log resolve to its first and last lines.
+	logId, err = testDb.NearestLogId(context.Background(), time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), 0)
+	require.Nil(t, err)
+	assert.Equal(t, 1, logId)
+	logId, err = testDb.NearestLogId(context.Background(), time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC), 0)
+	require.Nil(t, err)
+	assert.Equal(t, 8_092, logId)
+
+	logId, err = testDb.NearestLogId(context.Background(), rss.Points[0].Time, 100)
+	require.Nil(t, err)
+	assert.Equal(t, 0, logId)
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// SearchMode indicates how a search term should be matched against the
+// cached log lines.
+type SearchMode int
+
+const (
+	// SearchModeWords matches whole tokens using the default fts5 tokenizer.
+	// The search term may be any valid fts5 query expression, e.g.
+	// `remote_method AND error`.
+	SearchModeWords SearchMode = iota
+
+	// SearchModeSubstring matches the search term anywhere within a line,
+	// e.g. `aggreg` will match `base_aggregator`. The term is treated as a
+	// literal string.
+	SearchModeSubstring
+)
+
+// minTrigramLength is the shortest term the trigram index is able to match.
+// Shorter terms can only be matched by scanning every line.
+const minTrigramLength = 3
+
+func (m SearchMode) String() string {
+	switch m {
+	case SearchModeSubstring:
+		return "Substring"
+	default:
+		return "Words"
+	}
+}
+
+// key is the serialized representation of the mode, e.g. as stored in
+// a saved filter.
+func (m SearchMode) key() string {
+	switch m {
+	case SearchModeSubstring:
+		return "substring"
+	default:
+		return "words"
+	}
+}
+
+func (m SearchMode) MarshalText() ([]byte, error) {
+	return []byte(m.key()), nil
+}
+
+func (m *SearchMode) UnmarshalText(text []byte) error {
+	switch strings.ToLower(strings.TrimSpace(string(text))) {
+	case "", "words":
+		*m = SearchModeWords
+	case "substring":
+		*m = SearchModeSubstring
+	default:
+		return fmt.Errorf("unknown search mode: %s", text)
+	}
+	return nil
+}
+
+// SearchModes lists the supported modes in the order they should be
+// presented to the user.
+var SearchModes = []SearchMode{SearchModeWords, SearchModeSubstring}
+
+// planSearch returns a filter expression, evaluated against the `logs` table,
+// that selects the lines matching the search term according to the given
+// mode. Word searches use the `logs_fts` index, while substring searches use
+// the `logs_trigram` index when the cache has one, and the term is long
+// enough for the trigram tokenizer to handle it.
+func planSearch(searchTerm string, mode SearchMode, hasTrigramIndex bool) string {
+	var filter string
+
+	switch {
+	case mode == SearchModeSubstring && (hasTrigramIndex == false || utf8.RuneCountInString(searchTerm) < minTrigramLength):
+		filter = fmt.Sprintf(
+			`%s like '%%%s%%' escape '\'`,
+			originalColumn,
+			escapeSqlString(escapeLikePattern(searchTerm)),
+		)
+
+	case mode == SearchModeSubstring:
+		filter = fmt.Sprintf(
+			`rowid in (select rowid from logs_trigram where logs_trigram match '%s')`,
+			escapeSqlString(quoteFtsString(searchTerm)),
+		)
+
+	default:
+		filter = fmt.Sprintf(
+			`rowid in (select rowid from logs_fts where logs_fts match '%s')`,
+			escapeSqlString(searchTerm),
+		)
+	}
+
+	return filter
+}
+
+// escapeSqlString escapes single quotes so that the input can be embedded in
+// a single quoted SQL string literal.
+func escapeSqlString(input string) string {
+	return strings.ReplaceAll(input, `'`, `''`)
+}
+
+// quoteFtsString wraps the input in double quotes so that fts5 treats it as
+// a single string instead of a query expression.
+func quoteFtsString(input string) string {
+	return `"` + strings.ReplaceAll(input, `"`, `""`) + `"`
+}
+
+// escapeLikePattern escapes the wildcard characters recognized by the `like`
+// operator. It is meant to be paired with `escape '\'`.
+func escapeLikePattern(input string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(input)
+}
@@ -0,0 +1,217 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/newrelic/node-log-viewer/internal/session"
+)
+
+// SegmentSessions splits the cached lines into agent sessions, see
+// [session.Segmenter], and records the session of each line. It is run once
+// the lines have been cached, and when caches created before sessions were
+// introduced are migrated, see [LogsDatabase.MigrateUp]. Segmenting is
+// skipped when every line already has a session.
+func (l *LogsDatabase) SegmentSessions() error {
+	var unassigned int
+	err := l.Connection.QueryRow(`select count(*) from logs where session_id is null`).Scan(&unassigned)
+	if err != nil {
+		return fmt.Errorf("failed to check for sessions: %w", err)
+	}
+	if unassigned == 0 {
+		return nil
+	}
+
+	l.logger.Debug("segmenting sessions", "unassigned_lines", unassigned)
+	rows, err := l.Connection.Query(`
+		select
+			rowid,
+			unixepoch(time, 'subsec'),
+			coalesce(pid, 0),
+			coalesce(hostname, ''),
+			coalesce(message, '')
+		from logs
+		order by rowid
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to read lines: %w", err)
+	}
+
+	// Consecutive lines mostly belong to the same session, so the sessions
+	// are recorded as runs of row ids.
+	type run struct {
+		session int
+		first   int
+		last    int
+	}
+	runs := make([]run, 0)
+	segmenter := session.New()
+	for rows.Next() {
+		var line session.Line
+		var seconds *float64
+		err = rows.Scan(&line.LogId, &seconds, &line.Pid, &line.Hostname, &line.Message)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan line: %w", err)
+		}
+		line.Time = unixTime(seconds)
+
+		id := segmenter.Add(line)
+		if len(runs) > 0 && runs[len(runs)-1].session == id && runs[len(runs)-1].last == line.LogId-1 {
+			runs[len(runs)-1].last = line.LogId
+			continue
+		}
+		runs = append(runs, run{session: id, first: line.LogId, last: line.LogId})
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to read lines: %w", err)
+	}
+
+	tx, err := l.Connection.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`delete from sessions`)
+	if err != nil {
+		return fmt.Errorf("failed to clear sessions: %w", err)
+	}
+
+	insertSession, err := tx.Prepare(`
+		insert into sessions
+			(id, pid, hostname, agent_version, node_version, start_time, end_time, line_count)
+		values (?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare session insert: %w", err)
+	}
+	defer insertSession.Close()
+	for _, s := range segmenter.Sessions() {
+		_, err = insertSession.Exec(
+			s.Id,
+			s.Pid,
+			s.Hostname,
+			s.AgentVersion,
+			s.NodeVersion,
+			s.Start.UTC().Format(time.RFC3339Nano),
+			s.End.UTC().Format(time.RFC3339Nano),
+			s.Lines,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert session: %w", err)
+		}
+	}
+
+	updateLines, err := tx.Prepare(`update logs set session_id = ? where rowid between ? and ?`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare session update: %w", err)
+	}
+	defer updateLines.Close()
+	for _, r := range runs {
+		_, err = updateLines.Exec(r.session, r.first, r.last)
+		if err != nil {
+			return fmt.Errorf("failed to record session of lines: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Sessions returns the agent sessions of the cached lines in the order they
+// were started.
+func (l *LogsDatabase) Sessions() ([]session.Session, error) {
+	rows, err := l.Connection.Query(`
+		select id, pid, hostname, agent_version, node_version, start_time, end_time, line_count
+		from sessions
+		order by id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for sessions: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]session.Session, 0)
+	for rows.Next() {
+		var s session.Session
+		var start, end string
+		err = rows.Scan(&s.Id, &s.Pid, &s.Hostname, &s.AgentVersion, &s.NodeVersion, &start, &end, &s.Lines)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		s.Start, _ = time.Parse(time.RFC3339Nano, start)
+		s.End, _ = time.Parse(time.RFC3339Nano, end)
+		result = append(result, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read sessions: %w", err)
+	}
+	return result, nil
+}
+
+// SessionSummary is a session along with the number of its lines by level,
+// and by component.
+type SessionSummary struct {
+	session.Session
+
+	// Levels is the number of lines at each numeric level.
+	Levels map[int]int
+	// Components is the number of lines logged by each component, ordered by
+	// descending count.
+	Components []AggregateRow
+}
+
+// SummarizeSessions summarizes the sessions with the given ids, in the given
+// order, so that they can be compared. Unknown ids are ignored.
+func (l *LogsDatabase) SummarizeSessions(ctx context.Context, ids []int) ([]SessionSummary, error) {
+	sessions, err := l.Sessions()
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[int]session.Session, len(sessions))
+	for _, s := range sessions {
+		byId[s.Id] = s
+	}
+
+	result := make([]SessionSummary, 0, len(ids))
+	for _, id := range ids {
+		s, found := byId[id]
+		if found == false {
+			continue
+		}
+		summary := SessionSummary{Session: s, Levels: make(map[int]int)}
+		query := Filter{}.WithCondition(FacetSession, strconv.Itoa(id)).Query(l, l.logger)
+
+		levels, err := query.Aggregate(ctx, Aggregation{GroupBy: FacetLevel})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range levels {
+			level, err := strconv.Atoi(row.Value)
+			if err != nil {
+				continue
+			}
+			summary.Levels[level] = row.Count
+		}
+
+		summary.Components, err = query.Aggregate(ctx, Aggregation{GroupBy: FacetComponent})
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, summary)
+	}
+	return result, nil
+}
+
+// sessionCondition is a condition, to be appended to a `where` clause with
+// other conditions, that limits the lines to those of the given session. It
+// is empty when sessionId is zero, i.e. when all lines are considered.
+func sessionCondition(sessionId int) string {
+	if sessionId == 0 {
+		return ""
+	}
+	return fmt.Sprintf("and session_id = %d", sessionId)
+}
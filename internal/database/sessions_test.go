@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessions(t *testing.T) {
+	testDb := openTestDbCopy(t)
+
+	// Lines cached before sessions were introduced are segmented when the
+	// cache is migrated.
+	_, err := testDb.Connection.Exec(`update logs set session_id = null`)
+	require.Nil(t, err)
+	require.Nil(t, testDb.MigrateUp())
+
+	t.Run("segments the lines by pid and startup banner", func(t *testing.T) {
+		sessions, err := testDb.Sessions()
+		require.Nil(t, err)
+		require.Equal(t, 9, len(sessions))
+
+		total := 0
+		for _, s := range sessions {
+			total += s.Lines
+			assert.Equal(t, "12.14.0", s.AgentVersion)
+			assert.Equal(t, "localhost", s.Hostname)
+		}
+		assert.Equal(t, 8_092, total)
+
+		assert.Equal(t, 11385, sessions[0].Pid)
+		assert.Equal(t, "v20.18.3", sessions[0].NodeVersion)
+		assert.Equal(t, time.Date(2025, 2, 28, 18, 9, 54, 345_000_000, time.UTC), sessions[0].Start)
+		assert.Equal(t, 12, sessions[0].Lines)
+
+		last := sessions[8]
+		assert.Equal(t, 99445, last.Pid)
+		assert.Equal(t, "v18.20.7", last.NodeVersion)
+		assert.Equal(t, time.Date(2025, 3, 6, 18, 8, 3, 778_000_000, time.UTC), last.Start)
+		assert.Equal(t, 7_996, last.Lines)
+	})
+
+	t.Run("scopes queries to a session", func(t *testing.T) {
+		query := Filter{}.WithCondition(FacetSession, "8").Query(testDb, nullLogger)
+		assert.Equal(t, 23, query.NumRows())
+
+		harvests, err := testDb.Harvests(context.Background(), 8)
+		require.Nil(t, err)
+		for _, h := range harvests {
+			assert.Equal(t, 19573, h.Pid)
+		}
+
+		templates, err := testDb.Templates(context.Background(), 5, 8)
+		require.Nil(t, err)
+		total := 0
+		for _, template := range templates {
+			total += template.Count
+		}
+		assert.Equal(t, 23, total)
+	})
+
+	t.Run("summarizes sessions", func(t *testing.T) {
+		summaries, err := testDb.SummarizeSessions(context.Background(), []int{8, 1, 100})
+		require.Nil(t, err)
+		require.Equal(t, 2, len(summaries))
+		assert.Equal(t, 8, summaries[0].Id)
+		assert.Equal(t, 1, summaries[1].Id)
+		assert.Equal(t, map[int]int{30: 11, 40: 1}, summaries[1].Levels)
+		assert.Equal(t, 1, summaries[0].Levels[50])
+
+		components := 0
+		for _, row := range summaries[1].Components {
+			components += row.Count
+		}
+		assert.Equal(t, 12, components)
+	})
+}
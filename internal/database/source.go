@@ -0,0 +1,309 @@
+package database
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrSourceChanged is returned when the source log file of an index only
+// cache is missing, or has changed since the cache was created. The byte
+// offsets recorded in the cache are no longer valid for such a file.
+var ErrSourceChanged = errors.New("source log file has changed since it was indexed")
+
+// originalColumn is an expression, evaluated against the `logs` table, for the
+// original text of a line. Index only caches do not store the original text,
+// so it is read from the source log file instead.
+const originalColumn = `coalesce(original, nr_source((select path from source_file), source_offset, source_length))`
+
+// checksumSampleSize is the number of bytes, from both the start and the end of
+// a source file, that are included in its checksum.
+const checksumSampleSize = 64 * 1_024
+
+// SourceFile is the log file that the lines of an index only cache are read
+// from, along with the attributes used to verify that it has not changed.
+type SourceFile struct {
+	Path     string
+	Size     int64
+	Modified time.Time
+
+	// Checksum is a hash of the size of the file, and of the blocks at the
+	// start and the end of the file. Hashing every byte would make verifying a
+	// large file as slow as indexing it.
+	Checksum string
+}
+
+// NewSourceFile inspects the log file at the given path.
+func NewSourceFile(path string) (SourceFile, error) {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return SourceFile{}, fmt.Errorf("could not resolve source file path: %w", err)
+	}
+
+	file, err := os.Open(absolutePath)
+	if err != nil {
+		return SourceFile{}, fmt.Errorf("could not open source file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return SourceFile{}, fmt.Errorf("could not inspect source file: %w", err)
+	}
+
+	checksum, err := sampleChecksum(file, info.Size())
+	if err != nil {
+		return SourceFile{}, fmt.Errorf("could not compute source file checksum: %w", err)
+	}
+
+	return SourceFile{
+		Path:     absolutePath,
+		Size:     info.Size(),
+		Modified: info.ModTime().UTC(),
+		Checksum: checksum,
+	}, nil
+}
+
+func sampleChecksum(file io.ReaderAt, size int64) (string, error) {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\n", size)
+
+	head := io.NewSectionReader(file, 0, min(size, checksumSampleSize))
+	_, err := io.Copy(hash, head)
+	if err != nil {
+		return "", err
+	}
+
+	if size > checksumSampleSize {
+		start := max(checksumSampleSize, size-checksumSampleSize)
+		tail := io.NewSectionReader(file, start, size-start)
+		_, err = io.Copy(hash, tail)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// verify returns [ErrSourceChanged] if the file at the source's path does not
+// match the source.
+func (s SourceFile) verify() error {
+	current, err := NewSourceFile(s.Path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSourceChanged, err)
+	}
+	if current.Size != s.Size || current.Modified.Equal(s.Modified) == false || current.Checksum != s.Checksum {
+		return fmt.Errorf("%w: %s", ErrSourceChanged, s.Path)
+	}
+	return nil
+}
+
+// SetSourceFile switches the cache to the index only mode. Lines inserted
+// afterward are not stored in the cache. Instead, their byte offsets within the
+// log file at the given path are recorded, see [InsertTuple], and the lines
+// are read from the file when they are needed. This keeps the cache file
+// small at the cost of slower access to the lines. Caches that already hold
+// lines cannot be switched.
+func (l *LogsDatabase) SetSourceFile(path string) error {
+	hasCachedLogs, err := l.HasCachedLogs()
+	if err != nil {
+		return err
+	}
+	if hasCachedLogs == true {
+		return fmt.Errorf("cache already holds log lines")
+	}
+
+	source, err := NewSourceFile(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = l.Connection.Exec(
+		`insert or replace into source_file (id, path, size, modified, checksum) values (1, ?, ?, ?, ?)`,
+		source.Path,
+		source.Size,
+		source.Modified.Format(time.RFC3339Nano),
+		source.Checksum,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record source file: %w", err)
+	}
+
+	err = openSource(source)
+	if err != nil {
+		return err
+	}
+	if l.source != nil {
+		closeSource(l.source.Path)
+	}
+	l.source = &source
+	return nil
+}
+
+// SourceFile returns the source log file of an index only cache. The boolean
+// is false for caches that store the lines themselves.
+func (l *LogsDatabase) SourceFile() (SourceFile, bool) {
+	if l.source == nil {
+		return SourceFile{}, false
+	}
+	return *l.source, true
+}
+
+// hasTrigramIndex indicates if the lines of the cache are in the
+// `logs_trigram` index. Index only caches do not fill the index, see
+// [LogsDatabase.batchInsertOffsets].
+func (l *LogsDatabase) hasTrigramIndex() bool {
+	return l.source == nil
+}
+
+// loadSourceFile reads the source log file of an index only cache, and
+// verifies that it has not changed since the cache was created.
+func (l *LogsDatabase) loadSourceFile() error {
+	var source SourceFile
+	var modified string
+	err := l.Connection.QueryRow(
+		`select path, size, modified, checksum from source_file where id = 1`,
+	).Scan(&source.Path, &source.Size, &modified, &source.Checksum)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to read source file: %w", err)
+	}
+
+	source.Modified, err = time.Parse(time.RFC3339Nano, modified)
+	if err != nil {
+		return fmt.Errorf("failed to read source file modification time: %w", err)
+	}
+
+	err = source.verify()
+	if err != nil {
+		return err
+	}
+
+	err = openSource(source)
+	if err != nil {
+		return err
+	}
+	l.source = &source
+	return nil
+}
+
+// maxSourceLength is the largest number of bytes read by `nr_source`. Lines
+// are split with a buffer of this size when they are ingested, so no line
+// recorded in a cache is longer.
+const maxSourceLength = 1_024 * 1_024
+
+// sourceFiles holds the open source log files that lines are read from by the
+// `nr_source` function. The function is registered with the driver, so the
+// files are shared by every connection, and by every cache on the same file.
+// Only the files recorded as the source of an open cache, see [openSource],
+// can be read.
+var sourceFiles = struct {
+	sync.Mutex
+	files map[string]*sharedSource
+}{files: make(map[string]*sharedSource)}
+
+// sharedSource is an open source log file, along with the number of open
+// caches that read from it.
+type sharedSource struct {
+	file   *os.File
+	source SourceFile
+	refs   int
+}
+
+// unchanged returns [ErrSourceChanged] if the size or the modification time
+// of the open file no longer match the source. The checksum only samples the
+// file, and is only compared when a cache is opened, so this is checked
+// before every read to keep a file that is rewritten in place from returning
+// the wrong bytes.
+func (s *sharedSource) unchanged() error {
+	info, err := s.file.Stat()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSourceChanged, err)
+	}
+	if info.Size() != s.source.Size || info.ModTime().Equal(s.source.Modified) == false {
+		return fmt.Errorf("%w: %s", ErrSourceChanged, s.source.Path)
+	}
+	return nil
+}
+
+// openSource opens the source log file of a cache, so that it can be read by
+// `nr_source`. Every call must be paired with a call to [closeSource].
+func openSource(source SourceFile) error {
+	sourceFiles.Lock()
+	defer sourceFiles.Unlock()
+
+	if shared, found := sourceFiles.files[source.Path]; found {
+		if shared.source.Size != source.Size || shared.source.Modified.Equal(source.Modified) == false || shared.source.Checksum != source.Checksum {
+			return fmt.Errorf("%w: %s", ErrSourceChanged, source.Path)
+		}
+		shared.refs++
+		return nil
+	}
+	file, err := os.Open(source.Path)
+	if err != nil {
+		return fmt.Errorf("could not open source file: %w", err)
+	}
+	shared := &sharedSource{file: file, source: source, refs: 1}
+	err = shared.unchanged()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	sourceFiles.files[source.Path] = shared
+	return nil
+}
+
+// readSource reads the bytes at the given location of a source log file.
+func readSource(path string, offset int64, length int64) (string, error) {
+	if offset < 0 || length < 0 {
+		return "", fmt.Errorf("invalid location %d+%d", offset, length)
+	}
+	if length > maxSourceLength {
+		return "", fmt.Errorf("length %d exceeds %d bytes", length, maxSourceLength)
+	}
+
+	sourceFiles.Lock()
+	shared, found := sourceFiles.files[path]
+	sourceFiles.Unlock()
+	if found == false {
+		return "", fmt.Errorf("%s is not the source file of an open cache", path)
+	}
+
+	err := shared.unchanged()
+	if err != nil {
+		return "", err
+	}
+	buffer := make([]byte, length)
+	_, err = shared.file.ReadAt(buffer, offset)
+	if err != nil {
+		return "", err
+	}
+	return string(buffer), nil
+}
+
+// closeSource releases the source log file at the given path. The file is
+// closed once no open cache reads from it.
+func closeSource(path string) {
+	sourceFiles.Lock()
+	defer sourceFiles.Unlock()
+
+	shared, found := sourceFiles.files[path]
+	if found == false {
+		return
+	}
+	shared.refs--
+	if shared.refs == 0 {
+		shared.file.Close()
+		delete(sourceFiles.files, path)
+	}
+}
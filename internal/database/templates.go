@@ -0,0 +1,219 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/newrelic/node-log-viewer/internal/templates"
+)
+
+// Template is a message template mined from the cached lines, along with
+// statistics about the lines that share the template.
+type Template struct {
+	Id      int
+	Pattern string
+	Count   int
+	First   time.Time
+	Last    time.Time
+
+	// Histogram is the number of lines with the template in each of the
+	// equally sized time buckets spanning the whole log.
+	Histogram []int
+}
+
+// MineTemplates groups the messages of the cached lines by template, see
+// [templates.Miner]. Mining is skipped when every line already has a
+// template.
+func (l *LogsDatabase) MineTemplates(ctx context.Context) error {
+	var unmined int
+	err := l.Connection.QueryRowContext(
+		ctx,
+		`select count(*) from logs where rowid not in (select log_id from line_templates)`,
+	).Scan(&unmined)
+	if err != nil {
+		return fmt.Errorf("failed to check for mined templates: %w", err)
+	}
+	if unmined == 0 {
+		return nil
+	}
+
+	l.logger.Debug("mining message templates", "unmined_lines", unmined)
+	rows, err := l.Connection.QueryContext(ctx, `select rowid, coalesce(message, '') from logs order by rowid`)
+	if err != nil {
+		return fmt.Errorf("failed to read messages: %w", err)
+	}
+
+	miner := templates.New(templates.Params{})
+	assignments := make(map[int]int)
+	for rows.Next() {
+		var logId int
+		var message string
+		err = rows.Scan(&logId, &message)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan message: %w", err)
+		}
+		assignments[logId] = miner.Add(message).Id
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to read messages: %w", err)
+	}
+
+	tx, err := l.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `delete from line_templates; delete from templates;`)
+	if err != nil {
+		return fmt.Errorf("failed to clear templates: %w", err)
+	}
+
+	insertTemplate, err := tx.Prepare(`insert into templates (id, pattern) values (?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare template insert: %w", err)
+	}
+	defer insertTemplate.Close()
+	for _, cluster := range miner.Clusters() {
+		_, err = insertTemplate.ExecContext(ctx, cluster.Id, cluster.Template())
+		if err != nil {
+			return fmt.Errorf("failed to insert template: %w", err)
+		}
+	}
+
+	insertLine, err := tx.Prepare(`insert into line_templates (log_id, template_id) values (?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare line template insert: %w", err)
+	}
+	defer insertLine.Close()
+	for logId, templateId := range assignments {
+		_, err = insertLine.ExecContext(ctx, logId, templateId)
+		if err != nil {
+			return fmt.Errorf("failed to insert line template: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to store templates: %w", err)
+	}
+	l.logger.Debug("mined message templates", "templates", len(miner.Clusters()))
+	return nil
+}
+
+// Templates returns the mined message templates ordered by descending number
+// of lines. Templates are mined first if necessary. Each template's histogram
+// has the given number of buckets. When sessionId is not zero, only the lines
+// of that session are counted, see [LogsDatabase.Sessions].
+func (l *LogsDatabase) Templates(ctx context.Context, buckets int, sessionId int) ([]Template, error) {
+	err := l.MineTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	buckets = max(buckets, 1)
+
+	rows, err := l.Connection.QueryContext(ctx, fmt.Sprintf(
+		`
+			select
+				templates.id, templates.pattern, count(*),
+				min(unixepoch(logs.time, 'subsec')), max(unixepoch(logs.time, 'subsec'))
+			from templates
+			join line_templates on line_templates.template_id = templates.id
+			join logs on logs.rowid = line_templates.log_id
+			where true %s
+			group by templates.id
+			order by count(*) desc, templates.id
+		`,
+		sessionCondition(sessionId),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for templates: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]Template, 0)
+	index := make(map[int]int)
+	for rows.Next() {
+		var template Template
+		var first, last *float64
+		err = rows.Scan(&template.Id, &template.Pattern, &template.Count, &first, &last)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		template.First = unixTime(first)
+		template.Last = unixTime(last)
+		template.Histogram = make([]int, buckets)
+		index[template.Id] = len(result)
+		result = append(result, template)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// The span of the whole log is computed up front, rather than in a common
+	// table expression, as sqlite would otherwise evaluate it for every line.
+	var start, end *float64
+	err = l.Connection.QueryRowContext(
+		ctx,
+		fmt.Sprintf(
+			`select min(unixepoch(time, 'subsec')), max(unixepoch(time, 'subsec')) from logs where true %s`,
+			sessionCondition(sessionId),
+		),
+	).Scan(&start, &end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for the span of the log: %w", err)
+	}
+	if start == nil || end == nil {
+		return result, nil
+	}
+	width := max(*end-*start, 0.001)
+
+	histogramRows, err := l.Connection.QueryContext(
+		ctx,
+		fmt.Sprintf(
+			`
+				select
+					line_templates.template_id,
+					min(cast((unixepoch(logs.time, 'subsec') - ?1) * ?2 / ?3 as integer), ?2 - 1) as bucket,
+					count(*)
+				from line_templates
+				join logs on logs.rowid = line_templates.log_id
+				where logs.time is not null %s
+				group by 1, 2
+			`,
+			sessionCondition(sessionId),
+		),
+		*start,
+		buckets,
+		width,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for template histograms: %w", err)
+	}
+	defer histogramRows.Close()
+
+	for histogramRows.Next() {
+		var templateId, bucket, count int
+		err = histogramRows.Scan(&templateId, &bucket, &count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan template histogram: %w", err)
+		}
+		i, found := index[templateId]
+		if found == false || bucket < 0 || bucket >= buckets {
+			continue
+		}
+		result[i].Histogram[bucket] += count
+	}
+
+	return result, histogramRows.Err()
+}
+
+func unixTime(seconds *float64) time.Time {
+	if seconds == nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(*seconds * 1_000)).UTC()
+}
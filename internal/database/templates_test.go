@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplates(t *testing.T) {
+	testDb := openTestDbCopy(t)
+
+	t.Run("mines templates with statistics", func(t *testing.T) {
+		_, err := testDb.Connection.Exec(`delete from line_templates`)
+		require.Nil(t, err)
+
+		templates, err := testDb.Templates(context.Background(), 10, 0)
+		require.Nil(t, err)
+		require.Greater(t, len(templates), 100)
+
+		assert.Equal(t, "Recorded metric <*> <*>", templates[0].Pattern)
+		assert.Equal(t, 737, templates[0].Count)
+		assert.Equal(t, 10, len(templates[0].Histogram))
+		assert.Equal(t, 737, templates[0].Histogram[9])
+
+		total := 0
+		for _, template := range templates {
+			total += template.Count
+		}
+		assert.Equal(t, 8_092, total)
+
+		var state Template
+		for _, template := range templates {
+			if template.Pattern == "Agent state changed from <*> to <*>" {
+				state = template
+			}
+		}
+		assert.Equal(t, 36, state.Count)
+		assert.Equal(t, time.Date(2025, 2, 28, 18, 9, 54, 621_000_000, time.UTC), state.First)
+	})
+
+	t.Run("filters lines by template", func(t *testing.T) {
+		filter := Filter{}.WithTemplate("No log events to send.")
+		assert.True(t, filter.UsesTemplates())
+		require.Nil(t, testDb.MineTemplates(context.Background()))
+		assert.Equal(t, 598, filter.Query(testDb, nullLogger).NumRows())
+
+		filter = Filter{}.
+			ToggleHiddenTemplate("Recorded metric <*> <*>").
+			ToggleHiddenTemplate("No log events to send.")
+		assert.Equal(t, 8_092-737-598, filter.Query(testDb, nullLogger).NumRows())
+
+		filter = filter.ToggleHiddenTemplate("No log events to send.")
+		assert.Equal(t, []string{"Recorded metric <*> <*>"}, filter.HiddenTemplates)
+
+		filter = filter.WithTemplate("Recorded metric <*> <*>")
+		assert.Empty(t, filter.HiddenTemplates)
+		assert.Equal(t, 737, filter.Query(testDb, nullLogger).NumRows())
+	})
+}
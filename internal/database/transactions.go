@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/newrelic/node-log-viewer/internal/transaction"
+)
+
+// Transactions reconstructs the transactions traced in the log, see
+// [transaction.Builder]. The transactions are ordered by their first line.
+// When sessionId is not zero, only that session's transactions are returned.
+func (l *LogsDatabase) Transactions(ctx context.Context, sessionId int) ([]transaction.Transaction, error) {
+	rows, err := l.Connection.QueryContext(
+		ctx,
+		fmt.Sprintf(
+			`
+				select
+					rowid,
+					unixepoch(time, 'subsec'),
+					coalesce(pid, 0),
+					coalesce(component, ''),
+					coalesce(message, ''),
+					coalesce(iif(message = '%[1]s', json_extract(%[2]s, '$.name'), null), ''),
+					coalesce(iif(message = '%[1]s', json_extract(%[2]s, '$.module'), null), ''),
+					coalesce(iif(component = 'transaction', json_extract(%[2]s, '$.transactionId'), null), ''),
+					coalesce(iif(component = 'transaction', json_extract(%[2]s, '$.transactionName'), null), ''),
+					coalesce(iif(component = 'transaction', json_extract(%[2]s, '$.requestURL'), null), '')
+				from logs
+				where (message like 'Adding segment %%' or message = '%[1]s' or component in (%[3]s)) %[4]s
+				order by rowid
+			`,
+			transaction.CreatedMessage,
+			originalColumn,
+			quoteSqlStrings(transaction.Components),
+			sessionCondition(sessionId),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction lines: %w", err)
+	}
+	defer rows.Close()
+
+	builder := transaction.New()
+	for rows.Next() {
+		var line transaction.Line
+		var seconds *float64
+		err = rows.Scan(
+			&line.LogId,
+			&seconds,
+			&line.Pid,
+			&line.Component,
+			&line.Message,
+			&line.Name,
+			&line.Module,
+			&line.TransactionId,
+			&line.TransactionName,
+			&line.RequestURL,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction line: %w", err)
+		}
+		line.Time = unixTime(seconds)
+		builder.Add(line)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read transaction lines: %w", err)
+	}
+	return builder.Transactions(), nil
+}
+
+// TransactionFilter creates a filter that limits the lines to those logged by
+// the transaction's process from the transaction's first line through its
+// last line. Only some lines name the transaction they belong to, so the
+// lines of other transactions in flight at the same time are included.
+func TransactionFilter(tx transaction.Transaction) Filter {
+	if len(tx.LogIds) == 0 {
+		return Filter{SQL: "select rowid from logs where false"}
+	}
+	return Filter{
+		SQL: fmt.Sprintf(
+			"select rowid from logs where coalesce(pid, 0) = %d and rowid between %d and %d",
+			tx.Pid,
+			tx.LogIds[0],
+			tx.LogIds[len(tx.LogIds)-1],
+		),
+	}
+}
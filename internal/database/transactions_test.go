@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/newrelic/node-log-viewer/internal/transaction"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactions(t *testing.T) {
+	testDb := openTestDbCopy(t)
+
+	transactions, err := testDb.Transactions(context.Background(), 0)
+	require.Nil(t, err)
+	require.Equal(t, 34, len(transactions))
+
+	tx := transactions[0]
+	assert.Equal(t, "a0d386f884c0ce24", tx.Id)
+	assert.Equal(t, 99445, tx.Pid)
+	assert.Equal(t, "WebTransaction/NormalizedUri/*", tx.Name)
+	assert.Equal(t, "/", tx.URL)
+	assert.Equal(t, 8*time.Millisecond, tx.Duration())
+	// The two naming state lines, the root segment, and the two naming lines.
+	assert.Equal(t, 5, len(tx.LogIds))
+	require.Equal(t, 1, len(tx.Segments))
+	assert.Equal(t, "/", tx.Segments[0].Name)
+
+	results, err := TransactionFilter(tx).Query(testDb, nullLogger).AllResults()
+	require.Nil(t, err)
+	require.Equal(t, 5, len(results))
+	assert.Equal(t, "Adding segment / to ROOT in a0d386f884c0ce24", results[2].Message)
+	for _, result := range results {
+		assert.Contains(t, result.Original, `"pid":99445`)
+	}
+	assert.Equal(t, 0, TransactionFilter(transaction.Transaction{}).Query(testDb, nullLogger).NumRows())
+
+	transactions, err = testDb.Transactions(context.Background(), 1)
+	require.Nil(t, err)
+	assert.Empty(t, transactions)
+}
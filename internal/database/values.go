@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// searchColumns are the columns of the fts5 tables that may be targeted with
+// a column filter in a search expression, e.g. `component:remote_method`.
+var searchColumns = []string{"component", "message", "original"}
+
+// SearchColumns returns the names of the columns that may be used in an fts5
+// column filter.
+func SearchColumns() []string {
+	return append([]string{}, searchColumns...)
+}
+
+// Components returns the distinct, non-empty, component names present in the
+// cached logs.
+func (l *LogsDatabase) Components(ctx context.Context) ([]string, error) {
+	return l.distinctStrings(ctx, `
+		select distinct component from logs
+		where component is not null and component != ''
+		order by component
+	`)
+}
+
+// fieldSampleSize is the number of lines, spread evenly across the log, whose
+// attributes are read by [LogsDatabase.FieldNames].
+const fieldSampleSize = 10_000
+
+// FieldNames returns the distinct top level attribute names present in the
+// cached log lines, e.g. `msg` and `data`. Decoding every line of a large log
+// would take too long, and the lines of a kind share their attributes, so
+// only a sample of the lines is decoded.
+func (l *LogsDatabase) FieldNames(ctx context.Context) ([]string, error) {
+	var lastRowId int
+	err := l.Connection.QueryRowContext(ctx, `select coalesce(max(rowid), 0) from logs`).Scan(&lastRowId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for last row id: %w", err)
+	}
+	step := max(lastRowId/fieldSampleSize, 1)
+
+	return l.distinctStrings(ctx, fmt.Sprintf(
+		`
+			select distinct j.key
+			from (select %s as original from logs where rowid %% %d = 0) as sample, json_each(sample.original) as j
+			where json_valid(sample.original)
+			order by j.key
+		`,
+		originalColumn,
+		step,
+	))
+}
+
+func (l *LogsDatabase) distinctStrings(ctx context.Context, statement string) ([]string, error) {
+	rows, err := l.Connection.QueryContext(ctx, statement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for distinct values: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]string, 0)
+	for rows.Next() {
+		var value string
+		err = rows.Scan(&value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan distinct value: %w", err)
+		}
+		result = append(result, value)
+	}
+	return result, rows.Err()
+}
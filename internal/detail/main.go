@@ -1,4 +1,6 @@
-package tui
+// Package detail renders log lines, along with any data attached to them,
+// for inspection, e.g. in the line detail view.
+package detail
 
 import (
 	"bytes"
@@ -8,8 +10,41 @@ import (
 	v0 "github.com/newrelic/node-log-viewer/internal/v0"
 	"gopkg.in/yaml.v3"
 	"strconv"
+	"strings"
 )
 
+// Lines renders the line as a set of text lines: the line's message followed
+// by a readable, YAML like, representation of the data attached to the line
+// according to the line's kind, e.g. the decoded `data` attribute of a
+// collector request.
+func Lines(line common.Envelope) ([]string, error) {
+	lines := strings.Split(line.Message(), "\n")
+
+	var prepared []string
+	var err error
+	switch line.Kind() {
+	case common.TypeDataIncluded:
+		prepared, err = prepareDataIncludedLines(line)
+
+	case common.TypeEmbeddedDataIncluded:
+		prepared, err = prepareEmbeddedDataLines(line)
+
+	case common.TypeError:
+		prepared = prepareErrorLines(line)
+
+	case common.TypeExtraAttributes:
+		prepared, err = prepareExtraAttrsLines(line)
+
+	case common.TypeMessage:
+		// Nothing to do.
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return append(lines, prepared...), nil
+}
+
 func prepareDataIncludedLines(line common.Envelope) ([]string, error) {
 	l := line.(*v0.LineEnvelope)
 	result := make([]string, 0)
@@ -31,6 +66,10 @@ func prepareDataIncludedLines(line common.Envelope) ([]string, error) {
 		result = newLines
 	}
 
+	if l.Component() == payloadComponent {
+		return appendPayload(l, "data", "Data", result)
+	}
+
 	result = append(result, "\nData:")
 	var js any
 	err := json.Unmarshal([]byte(dataAttribute.(string)), &js)
@@ -96,6 +135,26 @@ func prepareExtraAttrsLines(line common.Envelope) ([]string, error) {
 		"\nAttributes:",
 	}
 
+	// The body posted to the collector is rendered like the data of the
+	// preceding "Calling" line.
+	_, hasBody := l.OtherFields["body"].(string)
+	if l.Component() == payloadComponent && hasBody == true {
+		attrs := make(map[string]any)
+		for k, v := range l.OtherFields {
+			if k != "body" {
+				attrs[k] = v
+			}
+		}
+		if len(attrs) == 0 {
+			return appendPayload(l, "body", "Body", nil)
+		}
+		result, err := appendAsYaml(attrs, result)
+		if err != nil {
+			return nil, err
+		}
+		return appendPayload(l, "body", "Body", result)
+	}
+
 	result, err := appendAsYaml(l.OtherFields, result)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,79 @@
+package detail
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	v0 "github.com/newrelic/node-log-viewer/internal/v0"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseLine(t *testing.T, line map[string]any) *v0.LineEnvelope {
+	line["v"] = 0
+	line["level"] = 10
+	line["time"] = "2025-03-06T18:08:07.039Z"
+	line["component"] = "remote_method"
+	encoded, err := json.Marshal(line)
+	require.Nil(t, err)
+
+	envelope := &v0.LineEnvelope{}
+	require.Nil(t, json.Unmarshal(encoded, envelope))
+	return envelope
+}
+
+func Test_Lines(t *testing.T) {
+	metricData := `["run",1741284484.104,1741284487.037,[[{"name":"Supportability/Nodejs/Version/18"},[1,0,0,0,0,0]],[{"name":"HttpDispatcher"},[3,0.25,0.25,0.05,0.125,0.03]]]]`
+
+	t.Run("renders metric data as a table", func(t *testing.T) {
+		line := parseLine(t, map[string]any{
+			"msg":        "Calling metric_data on collector API",
+			"data":       metricData,
+			"compressed": false,
+		})
+		lines, err := Lines(line)
+		require.Nil(t, err)
+
+		text := strings.Join(lines, "\n")
+		assert.Contains(t, text, "\nData:\n\tMetrics for run run from ")
+		assert.Contains(t, text, "(2 metrics)")
+		assert.Contains(t, lines, "\tName                              Scope  Calls  Total  Exclusive  Min   Max    Sum of squares")
+		assert.Contains(t, lines, "\tHttpDispatcher                           3      0.25   0.25       0.05  0.125  0.03")
+	})
+
+	t.Run("decodes compressed bodies", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		writer := gzip.NewWriter(buffer)
+		writer.Write([]byte(`["run",{"reservoir_size":10,"events_seen":4},[[{"type":"Span","name":"GET /","duration":0.5},{},{"http.statusCode":200}]]]`))
+		writer.Close()
+
+		line := parseLine(t, map[string]any{
+			"msg":  "Posting to https://collector.newrelic.com:443/agent_listener/invoke_raw_method?method=span_event_data",
+			"body": base64.StdEncoding.EncodeToString(buffer.Bytes()),
+		})
+		lines, err := Lines(line)
+		require.Nil(t, err)
+
+		assert.Contains(t, lines, "\nBody:")
+		assert.Contains(t, lines, "\tSpan events for run run: 1 sent, 4 seen, reservoir size 10")
+		assert.Contains(t, lines, "\t1  Span             GET /  0.5")
+		assert.Contains(t, lines, "\t\tAgent attributes:")
+		assert.Contains(t, lines, "\t\t\thttp.statusCode  200")
+	})
+
+	t.Run("renders other payloads as yaml", func(t *testing.T) {
+		line := parseLine(t, map[string]any{
+			"msg":        "Calling preconnect on collector API",
+			"data":       `[{"high_security":false}]`,
+			"compressed": false,
+		})
+		lines, err := Lines(line)
+		require.Nil(t, err)
+		assert.Contains(t, lines, "\t- high_security: false")
+		assert.Contains(t, lines, "\tcompressed: false")
+	})
+}
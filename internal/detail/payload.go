@@ -0,0 +1,213 @@
+package detail
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/newrelic/node-log-viewer/internal/harvest"
+	"github.com/newrelic/node-log-viewer/internal/payload"
+	v0 "github.com/newrelic/node-log-viewer/internal/v0"
+)
+
+// payloadComponent is the component whose lines hold collector payloads.
+const payloadComponent = "remote_method"
+
+// eventTypeNames are the headings of the event payloads.
+var eventTypeNames = map[string]string{
+	"analytic_event_data": "Transaction events",
+	"span_event_data":     "Span events",
+	"error_event_data":    "Error events",
+	"custom_event_data":   "Custom events",
+}
+
+// appendPayload decodes the collector payload held by the attribute of
+// a `remote_method` line, see [payload.Decode]. Payloads of the data
+// collection methods are rendered as tables, all others as YAML.
+func appendPayload(line *v0.LineEnvelope, attribute string, heading string, lines []string) ([]string, error) {
+	value, _ := line.OtherFields[attribute].(string)
+	lines = append(lines, "\n"+heading+":")
+
+	document, err := payload.Decode(value)
+	if err != nil {
+		lines = append(lines, "\tCould not decode payload: "+err.Error())
+		return appendAsYaml(value, lines)
+	}
+
+	parsed, err := payload.Parse(harvest.Method(line.Message()), document)
+	if err != nil {
+		if errors.Is(err, payload.ErrUnsupportedMethod) == false {
+			lines = append(lines, "\tCould not parse payload: "+err.Error())
+		}
+		var js any
+		err = json.Unmarshal(document, &js)
+		if err != nil {
+			return nil, err
+		}
+		return appendAsYaml(js, lines)
+	}
+
+	switch p := parsed.(type) {
+	case *payload.MetricData:
+		return appendMetricData(p, lines), nil
+	case *payload.EventData:
+		return appendEventData(p, lines), nil
+	case *payload.LogData:
+		return appendLogData(p, lines), nil
+	}
+	return lines, nil
+}
+
+func appendMetricData(data *payload.MetricData, lines []string) []string {
+	lines = append(
+		lines,
+		fmt.Sprintf(
+			"\tMetrics for run %s from %s to %s (%d metrics)",
+			data.RunId,
+			payloadTime(data.Start),
+			payloadTime(data.End),
+			len(data.Metrics),
+		),
+		"",
+	)
+
+	rows := [][]string{{"Name", "Scope", "Calls", "Total", "Exclusive", "Min", "Max", "Sum of squares"}}
+	for _, metric := range data.Metrics {
+		rows = append(rows, []string{
+			metric.Name,
+			metric.Scope,
+			formatValue(metric.CallCount),
+			formatValue(metric.Total),
+			formatValue(metric.Exclusive),
+			formatValue(metric.Min),
+			formatValue(metric.Max),
+			formatValue(metric.SumOfSquares),
+		})
+	}
+	return appendTable(rows, "\t", lines)
+}
+
+func appendEventData(data *payload.EventData, lines []string) []string {
+	heading := eventTypeNames[data.Method()]
+	summary := fmt.Sprintf("\t%s for run %s: %d sent, %d seen", heading, data.RunId, len(data.Events), data.EventsSeen)
+	if data.ReservoirSize > 0 {
+		summary += fmt.Sprintf(", reservoir size %d", data.ReservoirSize)
+	}
+	lines = append(lines, summary, "")
+
+	rows := [][]string{{"#", "Type", "Timestamp", "Name", "Duration"}}
+	for i, event := range data.Events {
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1),
+			event.Type(),
+			payloadTime(event.Timestamp()),
+			formatAttribute(event.Intrinsics, "name"),
+			formatAttribute(event.Intrinsics, "duration"),
+		})
+	}
+	lines = appendTable(rows, "\t", lines)
+
+	for i, event := range data.Events {
+		lines = append(lines, "", fmt.Sprintf("\tEvent %d:", i+1))
+		lines = appendAttributes("Intrinsics", event.Intrinsics, lines)
+		lines = appendAttributes("User attributes", event.UserAttributes, lines)
+		lines = appendAttributes("Agent attributes", event.AgentAttributes, lines)
+	}
+	return lines
+}
+
+func appendLogData(data *payload.LogData, lines []string) []string {
+	lines = append(lines, fmt.Sprintf("\tLog events: %d sent", len(data.Logs)))
+	lines = appendAttributes("Common attributes", data.Common, lines)
+	lines = append(lines, "")
+
+	rows := [][]string{{"Timestamp", "Level", "Message", "Attributes"}}
+	for _, log := range data.Logs {
+		attributes := make([]string, 0, len(log.Attributes))
+		for _, key := range slices.Sorted(maps.Keys(log.Attributes)) {
+			attributes = append(attributes, key+"="+formatValue(log.Attributes[key]))
+		}
+		rows = append(rows, []string{
+			payloadTime(log.Timestamp),
+			log.Level,
+			log.Message,
+			strings.Join(attributes, " "),
+		})
+	}
+	return appendTable(rows, "\t", lines)
+}
+
+// appendAttributes renders the attributes as a two column table of names and
+// values, ordered by name. Nothing is rendered when there are no attributes.
+func appendAttributes(heading string, attributes map[string]any, lines []string) []string {
+	if len(attributes) == 0 {
+		return lines
+	}
+
+	lines = append(lines, "\t\t"+heading+":")
+	rows := make([][]string, 0, len(attributes))
+	for _, key := range slices.Sorted(maps.Keys(attributes)) {
+		rows = append(rows, []string{key, formatValue(attributes[key])})
+	}
+	return appendTable(rows, "\t\t\t", lines)
+}
+
+// appendTable renders the rows with aligned columns, each line prefixed with
+// the indent.
+func appendTable(rows [][]string, indent string, lines []string) []string {
+	builder := &strings.Builder{}
+	writer := tabwriter.NewWriter(builder, 0, 0, 2, ' ', 0)
+	replacer := strings.NewReplacer("\t", " ", "\n", " ")
+	for _, row := range rows {
+		cells := make([]string, 0, len(row))
+		for _, cell := range row {
+			cells = append(cells, replacer.Replace(cell))
+		}
+		fmt.Fprintln(writer, strings.Join(cells, "\t"))
+	}
+	writer.Flush()
+
+	for _, line := range strings.Split(strings.TrimRight(builder.String(), "\n"), "\n") {
+		lines = append(lines, indent+strings.TrimRight(line, " "))
+	}
+	return lines
+}
+
+func formatAttribute(attributes map[string]any, name string) string {
+	value, found := attributes[name]
+	if found == false {
+		return ""
+	}
+	return formatValue(value)
+}
+
+// formatValue renders numbers without exponents or trailing zeros, and
+// everything other than strings as JSON.
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return "null"
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+func payloadTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(time.Now().Location()).Format("2006-01-02 15:04:05.000")
+}
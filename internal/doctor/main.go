@@ -0,0 +1,117 @@
+// Package doctor runs a set of rules over what is known about each agent
+// session, e.g. its lifecycle and its harvests, and reports the problems they
+// find. Each finding has a severity, an explanation of what it means, and the
+// ids of the lines that show it, so that the checks which are otherwise done
+// by hand on every log can be run at once.
+package doctor
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/newrelic/node-log-viewer/internal/config"
+	"github.com/newrelic/node-log-viewer/internal/harvest"
+	"github.com/newrelic/node-log-viewer/internal/lifecycle"
+	"github.com/newrelic/node-log-viewer/internal/session"
+	"github.com/newrelic/node-log-viewer/internal/triage"
+)
+
+// WarnLevel is the level at and above which lines are collected as
+// [Session.Warnings].
+const WarnLevel = 40
+
+// Severity is how urgently a finding should be looked at.
+type Severity int
+
+const (
+	// SeverityInfo indicates something worth knowing that is usually
+	// harmless.
+	SeverityInfo Severity = iota
+	// SeverityWarning indicates something that may cause data to be lost.
+	SeverityWarning
+	// SeverityError indicates that the agent did not, or could not, report
+	// data.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	}
+	return "error"
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Line is the part of a log line that the rules inspect.
+type Line struct {
+	LogId     int
+	Time      time.Time
+	Level     int
+	Component string
+	Message   string
+}
+
+// Session is what is known about a single session.
+type Session struct {
+	session.Session
+	Lifecycle lifecycle.Lifecycle
+	Harvests  []harvest.Harvest
+	// Config is nil when the session did not log its configuration, which
+	// requires the trace level. ConfigLogIds identifies the lines that hold
+	// it.
+	Config       *config.Config
+	ConfigLogIds []int
+	Errors       []triage.Group
+	// Warnings are the lines logged at [WarnLevel] or above.
+	Warnings []Line
+}
+
+// Finding is a problem found by a rule.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	// Session is zero for findings that concern several sessions.
+	Session     int    `json:"session"`
+	Title       string `json:"title"`
+	Explanation string `json:"explanation"`
+	// LogIds identifies the lines that show the problem, in order.
+	LogIds []int `json:"evidence"`
+}
+
+// Rule is a single check. Check returns the rule's findings, whose Rule
+// field is set by [Diagnose].
+type Rule struct {
+	Name        string
+	Description string
+	Check       func(sessions []Session) []Finding
+}
+
+// Diagnose runs the rules over the sessions. Findings are ordered by
+// severity, the most severe first, then by session and by rule.
+func Diagnose(sessions []Session, rules []Rule) []Finding {
+	result := make([]Finding, 0)
+	order := make(map[string]int, len(rules))
+	for i, rule := range rules {
+		order[rule.Name] = i
+		for _, finding := range rule.Check(sessions) {
+			finding.Rule = rule.Name
+			finding.LogIds = slices.Compact(slices.Sorted(slices.Values(finding.LogIds)))
+			result = append(result, finding)
+		}
+	}
+	slices.SortStableFunc(result, func(a Finding, b Finding) int {
+		return cmp.Or(
+			cmp.Compare(b.Severity, a.Severity),
+			cmp.Compare(a.Session, b.Session),
+			cmp.Compare(order[a.Rule], order[b.Rule]),
+		)
+	})
+	return result
+}
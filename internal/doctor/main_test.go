@@ -0,0 +1,120 @@
+package doctor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/newrelic/node-log-viewer/internal/config"
+	"github.com/newrelic/node-log-viewer/internal/harvest"
+	"github.com/newrelic/node-log-viewer/internal/lifecycle"
+	"github.com/newrelic/node-log-viewer/internal/session"
+	"github.com/newrelic/node-log-viewer/internal/triage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Diagnose(t *testing.T) {
+	start := time.Date(2025, 3, 4, 13, 51, 32, 0, time.UTC)
+	sessions := []Session{
+		{
+			Session: session.Session{Id: 1},
+			Lifecycle: lifecycle.Lifecycle{
+				Transitions: []lifecycle.Transition{{LogId: 3, From: "stopped", To: "starting"}, {LogId: 4, From: "starting", To: "connecting"}},
+				Problems:    []lifecycle.Problem{{Kind: lifecycle.ProblemNeverStarted, Detail: "last state connecting"}},
+			},
+			Harvests: []harvest.Harvest{
+				{Method: "preconnect", Outcome: harvest.OutcomeFailed, Status: 401, LogIds: []int{5, 6}},
+				{Method: "preconnect", Outcome: harvest.OutcomeFailed, Status: 401, LogIds: []int{7, 8}},
+			},
+			Warnings: []Line{
+				{LogId: 2, Level: 40, Message: "`feature_flag.opentelemetry_bridge` is not enabled, skipping setup of opentelemetry-bridge"},
+			},
+		},
+		{
+			Session: session.Session{Id: 2},
+			Lifecycle: lifecycle.Lifecycle{
+				Problems: []lifecycle.Problem{
+					{Kind: lifecycle.ProblemRestarted, LogId: 20, Detail: "metric_data returned 409 status"},
+					{Kind: lifecycle.ProblemDisconnected, LogId: 21},
+				},
+			},
+			Harvests: []harvest.Harvest{
+				{Method: "metric_data", Outcome: harvest.OutcomeFinished, LogIds: []int{12, 13}},
+				{Method: "span_event_data", Outcome: harvest.OutcomeMissing, LogIds: []int{14}},
+			},
+			Config:       &config.Config{Settings: map[string]any{"high_security": true}},
+			ConfigLogIds: []int{11},
+			Errors: []triage.Group{
+				{Key: triage.Key{Message: "Could not list packages in <*>", Code: "ENOENT"}, Count: 8, Level: 10, First: start, Last: start, LogIds: []int{30, 31}},
+				{Key: triage.Key{Message: "Could not read <*>", Code: "ENOENT"}, Count: 2, Level: 50, First: start, Last: start, LogIds: []int{32}},
+			},
+		},
+		{
+			Session:      session.Session{Id: 3},
+			Lifecycle:    lifecycle.Lifecycle{Problems: []lifecycle.Problem{{Kind: lifecycle.ProblemNeverStarted, Detail: "last state unknown"}}},
+			Config:       &config.Config{Settings: map[string]any{"high_security": false}},
+			ConfigLogIds: []int{40},
+			Warnings: []Line{
+				{LogId: 41, Level: 50, Message: "High Security Mode mismatch. The agent will not connect."},
+			},
+		},
+	}
+
+	findings := Diagnose(sessions, Rules)
+	titles := make([]string, 0, len(findings))
+	for _, f := range findings {
+		titles = append(titles, f.Title)
+	}
+	require.Equal(
+		t,
+		[]string{
+			"high_security differs between sessions",
+			"Agent never started",
+			"Collector responded with 401 status 2 times",
+			"High security mode issue",
+			"Agent restarted by the collector",
+			"1 harvest without a response",
+			"1 warning about feature flags",
+			"Missing files looked up 8 times",
+		},
+		titles,
+	)
+
+	mismatch := findings[0]
+	assert.Equal(t, "high-security", mismatch.Rule)
+	assert.Equal(t, SeverityError, mismatch.Severity)
+	assert.Equal(t, 0, mismatch.Session)
+	assert.Contains(t, mismatch.Explanation, "sessions 3 connected with false, while sessions 2 connected with true")
+	assert.Equal(t, []int{11, 40}, mismatch.LogIds)
+
+	assert.Equal(t, []int{3, 4}, findings[1].LogIds)
+	assert.Contains(t, findings[2].Explanation, "license key")
+	assert.Equal(t, []int{5, 6, 7, 8}, findings[2].LogIds)
+	assert.Equal(t, []int{20}, findings[4].LogIds)
+	assert.Equal(t, SeverityWarning, findings[5].Severity)
+	assert.Equal(t, "feature-flags", findings[6].Rule)
+	assert.Equal(t, SeverityInfo, findings[7].Severity)
+	assert.Equal(t, []int{30, 31}, findings[7].LogIds)
+}
+
+func Test_checkHighSecurity(t *testing.T) {
+	// Sessions that did not log the setting do not count as disagreeing.
+	sessions := []Session{
+		{Session: session.Session{Id: 1}, Config: &config.Config{Settings: map[string]any{"high_security": true}}},
+		{Session: session.Session{Id: 2}, Config: &config.Config{Settings: map[string]any{"app_name": "test"}}},
+		{Session: session.Session{Id: 3}},
+	}
+	assert.Empty(t, checkHighSecurity(sessions))
+
+	sessions[1].Config.Settings["high_security"] = false
+	findings := checkHighSecurity(sessions)
+	require.Equal(t, 1, len(findings))
+	assert.Contains(t, findings[0].Explanation, "sessions 2 connected with false, while sessions 1 connected with true")
+}
+
+func Test_Severity(t *testing.T) {
+	text, err := SeverityWarning.MarshalText()
+	require.Nil(t, err)
+	assert.Equal(t, "warning", string(text))
+	assert.Equal(t, "error", SeverityError.String())
+}
@@ -0,0 +1,346 @@
+package doctor
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/node-log-viewer/internal/config"
+	"github.com/newrelic/node-log-viewer/internal/harvest"
+	"github.com/newrelic/node-log-viewer/internal/lifecycle"
+)
+
+// LoopThreshold is the number of times the same missing file must be looked
+// up for the lookups to be reported as a loop.
+const LoopThreshold = 5
+
+// maxMessages is the number of distinct messages quoted in an explanation.
+const maxMessages = 3
+
+var (
+	matchFeatureFlag  = regexp.MustCompile(`feature_flag`)
+	matchHighSecurity = regexp.MustCompile(`(?i)high[ _]security`)
+)
+
+// collectorStatuses explains the collector responses that prevent the agent
+// from reporting data.
+var collectorStatuses = map[int]string{
+	401: "The collector rejected the license key. Verify that `license_key` holds the ingest license key of the account the application reports to.",
+	403: "The collector refused the agent's data. The license key may have been revoked, or the account may not be allowed to send this data.",
+	410: "The collector told the agent to shut down, e.g. because the application was deleted. The agent stops reporting until it is restarted.",
+}
+
+// Rules is the built-in rule set.
+var Rules = []Rule{
+	{
+		Name:        "agent-lifecycle",
+		Description: "Sessions that never reached the started state, errored, or were restarted by the collector.",
+		Check:       checkLifecycle,
+	},
+	{
+		Name:        "collector-status",
+		Description: "Collector responses with a 401, 403 or 410 status.",
+		Check:       checkCollectorStatus,
+	},
+	{
+		Name:        "high-security",
+		Description: "Sessions that disagree on high_security, and high security mode issues logged by the agent.",
+		Check:       checkHighSecurity,
+	},
+	{
+		Name:        "feature-flags",
+		Description: "Warnings about feature flags.",
+		Check:       checkFeatureFlags,
+	},
+	{
+		Name:        "missing-harvests",
+		Description: "Harvests for which no response from the collector was logged.",
+		Check:       checkMissingHarvests,
+	},
+	{
+		Name:        "enoent-loop",
+		Description: fmt.Sprintf("Missing files that were looked up at least %d times.", LoopThreshold),
+		Check:       checkEnoentLoops,
+	},
+}
+
+func checkLifecycle(sessions []Session) []Finding {
+	result := make([]Finding, 0)
+	for _, s := range sessions {
+		for _, problem := range s.Lifecycle.Problems {
+			if problem.Kind == lifecycle.ProblemNeverStarted && len(s.Lifecycle.Transitions) == 0 {
+				// State changes are logged at the info level, so a log without
+				// any does not tell whether the agent started.
+				continue
+			}
+			evidence := []int{problem.LogId}
+			if problem.LogId == 0 {
+				evidence = s.Lifecycle.LogIds()
+			}
+
+			finding := Finding{Session: s.Id, LogIds: evidence}
+			switch problem.Kind {
+			case lifecycle.ProblemNeverStarted:
+				finding.Severity = SeverityError
+				finding.Title = "Agent never started"
+				finding.Explanation = fmt.Sprintf(
+					"The agent never reached the `%s` state (%s), so it did not report any data. Look for connection errors, e.g. an invalid license key or an unreachable collector, after its last state change.",
+					lifecycle.StateStarted,
+					problem.Detail,
+				)
+			case lifecycle.ProblemErrored:
+				finding.Severity = SeverityError
+				finding.Title = "Agent errored"
+				finding.Explanation = "The agent reached the `errored` state and stopped reporting data. The lines before the state change usually hold the cause."
+			case lifecycle.ProblemRestarted:
+				finding.Severity = SeverityWarning
+				finding.Title = "Agent restarted by the collector"
+				finding.Explanation = fmt.Sprintf(
+					"The collector told the agent to restart (%s). The agent connects again, but the data collected in between may be lost. Restarts usually follow a change of the server side configuration.",
+					problem.Detail,
+				)
+			default:
+				// The other problems are either reported by other rules, e.g. a
+				// shut down by the collector, or follow from the above.
+				continue
+			}
+			result = append(result, finding)
+		}
+	}
+	return result
+}
+
+func checkCollectorStatus(sessions []Session) []Finding {
+	result := make([]Finding, 0)
+	for _, s := range sessions {
+		byStatus := make(map[int][]harvest.Harvest)
+		for _, h := range s.Harvests {
+			if _, found := collectorStatuses[h.Status]; found == true {
+				byStatus[h.Status] = append(byStatus[h.Status], h)
+			}
+		}
+
+		for _, status := range slices.Sorted(maps.Keys(byStatus)) {
+			harvests := byStatus[status]
+			methods := make([]string, 0)
+			evidence := make([]int, 0)
+			for _, h := range harvests {
+				if slices.Contains(methods, h.Method) == false {
+					methods = append(methods, h.Method)
+				}
+				evidence = append(evidence, h.LogIds...)
+			}
+			result = append(result, Finding{
+				Severity: SeverityError,
+				Session:  s.Id,
+				Title:    fmt.Sprintf("Collector responded with %d status %s", status, plural(len(harvests), "time")),
+				Explanation: fmt.Sprintf(
+					"%s The status was returned for %s.",
+					collectorStatuses[status],
+					strings.Join(methods, ", "),
+				),
+				LogIds: evidence,
+			})
+		}
+	}
+	return result
+}
+
+func checkHighSecurity(sessions []Session) []Finding {
+	result := make([]Finding, 0)
+
+	// Group the sessions that logged their high_security setting by its
+	// value. Sessions whose configuration lacks the setting are skipped, as
+	// its default is not known for every agent version.
+	values := make(map[string][]int)
+	evidence := make([]int, 0)
+	for _, s := range sessions {
+		if s.Config == nil {
+			continue
+		}
+		setting, found := s.Config.Settings["high_security"]
+		if found == false {
+			continue
+		}
+		value := config.FormatValue(setting)
+		values[value] = append(values[value], s.Id)
+		evidence = append(evidence, s.ConfigLogIds...)
+	}
+	if len(values) > 1 {
+		groups := make([]string, 0, len(values))
+		for _, value := range slices.Sorted(maps.Keys(values)) {
+			groups = append(groups, fmt.Sprintf("sessions %s connected with %s", joinInts(values[value]), value))
+		}
+		result = append(result, Finding{
+			Severity: SeverityError,
+			Title:    "high_security differs between sessions",
+			Explanation: fmt.Sprintf(
+				"The %s. The collector rejects agents whose high_security setting does not match the high security mode of the account, so every agent reporting to the account must use the same setting.",
+				strings.Join(groups, ", while "),
+			),
+			LogIds: evidence,
+		})
+	}
+
+	for _, s := range sessions {
+		lines := matchingWarnings(s, matchHighSecurity)
+		if len(lines) == 0 {
+			continue
+		}
+		result = append(result, Finding{
+			Severity: SeverityError,
+			Session:  s.Id,
+			Title:    "High security mode issue",
+			Explanation: fmt.Sprintf(
+				"The agent logged: %s. The agent's high_security setting must match the high security mode of the account.",
+				quoteMessages(lines),
+			),
+			LogIds: lineIds(lines),
+		})
+	}
+	return result
+}
+
+func checkFeatureFlags(sessions []Session) []Finding {
+	result := make([]Finding, 0)
+	for _, s := range sessions {
+		lines := matchingWarnings(s, matchFeatureFlag)
+		if len(lines) == 0 {
+			continue
+		}
+		result = append(result, Finding{
+			Severity: SeverityInfo,
+			Session:  s.Id,
+			Title:    fmt.Sprintf("%s about feature flags", plural(len(lines), "warning")),
+			Explanation: fmt.Sprintf(
+				"The agent logged: %s. Feature flags toggle experimental behavior, so a warning usually means that a feature is disabled rather than broken. Check the flag if the application relies on the feature.",
+				quoteMessages(lines),
+			),
+			LogIds: lineIds(lines),
+		})
+	}
+	return result
+}
+
+func checkMissingHarvests(sessions []Session) []Finding {
+	result := make([]Finding, 0)
+	for _, s := range sessions {
+		methods := make([]string, 0)
+		evidence := make([]int, 0)
+		count := 0
+		for _, h := range s.Harvests {
+			if h.Outcome != harvest.OutcomeMissing {
+				continue
+			}
+			count++
+			if slices.Contains(methods, h.Method) == false {
+				methods = append(methods, h.Method)
+			}
+			evidence = append(evidence, h.LogIds...)
+		}
+		if count == 0 {
+			continue
+		}
+		result = append(result, Finding{
+			Severity: SeverityWarning,
+			Session:  s.Id,
+			Title:    fmt.Sprintf("%s without a response", plural(count, "harvest")),
+			Explanation: fmt.Sprintf(
+				"No response from the collector was logged for harvests of %s. Their data may have been lost, e.g. because the collector could not be reached, or because the process exited while they were in flight.",
+				strings.Join(methods, ", "),
+			),
+			LogIds: evidence,
+		})
+	}
+	return result
+}
+
+func checkEnoentLoops(sessions []Session) []Finding {
+	result := make([]Finding, 0)
+	for _, s := range sessions {
+		for _, group := range s.Errors {
+			if group.Code != "ENOENT" || group.Count < LoopThreshold {
+				continue
+			}
+			severity := SeverityInfo
+			if group.Level >= WarnLevel {
+				severity = SeverityWarning
+			}
+			result = append(result, Finding{
+				Severity: severity,
+				Session:  s.Id,
+				Title:    fmt.Sprintf("Missing files looked up %d times", group.Count),
+				Explanation: fmt.Sprintf(
+					"%q failed with ENOENT %d times between %s and %s, e.g. for %s. Lookups logged below the warn level, such as the agent's scan of module directories, are usually harmless; repeated lookups at higher levels point at a misconfigured path.",
+					group.Message,
+					group.Count,
+					group.First.UTC().Format("2006-01-02T15:04:05.000Z"),
+					group.Last.UTC().Format("2006-01-02T15:04:05.000Z"),
+					strings.Join(group.Paths, ", "),
+				),
+				LogIds: group.LogIds,
+			})
+		}
+	}
+	return result
+}
+
+// matchingWarnings returns the session's warnings whose message matches.
+func matchingWarnings(s Session, match *regexp.Regexp) []Line {
+	result := make([]Line, 0)
+	for _, line := range s.Warnings {
+		if match.MatchString(line.Message) {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// quoteMessages quotes the distinct messages of the lines, up to
+// [maxMessages] of them.
+func quoteMessages(lines []Line) string {
+	messages := make([]string, 0, maxMessages)
+	distinct := 0
+	for _, line := range lines {
+		quoted := strconv.Quote(line.Message)
+		if slices.Contains(messages, quoted) {
+			continue
+		}
+		distinct++
+		if len(messages) < maxMessages {
+			messages = append(messages, quoted)
+		}
+	}
+	result := strings.Join(messages, ", ")
+	if distinct > maxMessages {
+		result += fmt.Sprintf(" and %d other messages", distinct-maxMessages)
+	}
+	return result
+}
+
+func lineIds(lines []Line) []int {
+	result := make([]int, 0, len(lines))
+	for _, line := range lines {
+		result = append(result, line.LogId)
+	}
+	return result
+}
+
+func joinInts(values []int) string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		result = append(result, strconv.Itoa(value))
+	}
+	return strings.Join(result, ", ")
+}
+
+// plural renders the count along with the noun, e.g. "1 time" or "2 times".
+func plural(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
@@ -0,0 +1,239 @@
+package filters
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/log"
+	"github.com/newrelic/node-log-viewer/internal/misc"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed defaults.yaml
+var defaultsFile []byte
+
+// Source indicates where a saved filter was loaded from.
+type Source int
+
+const (
+	// SourceDefault filters are shipped with the log viewer.
+	SourceDefault Source = iota
+	// SourceConfig filters are stored in the user's config file.
+	SourceConfig
+	// SourceCache filters are stored in the cache file.
+	SourceCache
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceConfig:
+		return "config"
+	case SourceCache:
+		return "cache"
+	default:
+		return "default"
+	}
+}
+
+// SavedFilter is a [database.Filter] along with the location it was loaded
+// from.
+type SavedFilter struct {
+	database.Filter
+	Source Source
+}
+
+// ErrNotFound is returned when a named filter does not exist in any source.
+var ErrNotFound = errors.New("filter not found")
+
+type filtersDocument struct {
+	Filters []database.Filter `yaml:"filters"`
+}
+
+// Store provides access to the saved filters from all sources: the defaults
+// shipped with the log viewer, the user's config file, and the cache file.
+type Store struct {
+	fs         afero.Fs
+	configFile string
+	db         *database.LogsDatabase
+	logger     *log.Logger
+}
+
+type StoreParams struct {
+	// Fs is the file system the config file is read from and written to.
+	Fs afero.Fs
+	// ConfigFile is the path to the user's filters file. When empty, filters
+	// cannot be saved to, or loaded from, a config file.
+	ConfigFile string
+	// Database is the cache that filters may be saved in. May be nil.
+	Database *database.LogsDatabase
+	Logger   *log.Logger
+}
+
+func NewStore(params StoreParams) *Store {
+	return &Store{
+		fs:         params.Fs,
+		configFile: params.ConfigFile,
+		db:         params.Database,
+		logger:     params.Logger,
+	}
+}
+
+// DefaultConfigFile returns the path to the user's filters file, e.g.
+// `~/.config/nrlv/filters.yaml` on Linux.
+func DefaultConfigFile() (string, error) {
+	configDir, err := misc.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "filters.yaml"), nil
+}
+
+// Defaults returns the filters shipped with the log viewer.
+func Defaults() ([]database.Filter, error) {
+	var doc filtersDocument
+	err := yaml.Unmarshal(defaultsFile, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse default filters: %w", err)
+	}
+	return doc.Filters, nil
+}
+
+// All returns the filters from every source. When more than one source has
+// a filter with the same name, the cache file takes precedence over the
+// config file, which takes precedence over the defaults.
+func (s *Store) All() ([]SavedFilter, error) {
+	result := make([]SavedFilter, 0)
+	indexes := make(map[string]int)
+	add := func(filters []database.Filter, source Source) {
+		for _, filter := range filters {
+			saved := SavedFilter{Filter: filter, Source: source}
+			if i, found := indexes[filter.Name]; found {
+				result[i] = saved
+				continue
+			}
+			indexes[filter.Name] = len(result)
+			result = append(result, saved)
+		}
+	}
+
+	defaults, err := Defaults()
+	if err != nil {
+		return nil, err
+	}
+	add(defaults, SourceDefault)
+
+	configFilters, err := s.readConfig()
+	if err != nil {
+		return nil, err
+	}
+	add(configFilters, SourceConfig)
+
+	if s.db != nil {
+		cacheFilters, err := s.db.SavedFilters()
+		if err != nil {
+			return nil, err
+		}
+		add(cacheFilters, SourceCache)
+	}
+
+	return result, nil
+}
+
+// Find returns the filter with the given name. If no such filter exists,
+// [ErrNotFound] is returned.
+func (s *Store) Find(name string) (SavedFilter, error) {
+	filters, err := s.All()
+	if err != nil {
+		return SavedFilter{}, err
+	}
+	for _, filter := range filters {
+		if filter.Name == name {
+			return filter, nil
+		}
+	}
+	return SavedFilter{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// SaveToConfig writes the filter to the user's config file, replacing any
+// filter with the same name.
+func (s *Store) SaveToConfig(filter database.Filter) error {
+	if s.configFile == "" {
+		return fmt.Errorf("no config file available for saving filters")
+	}
+	if filter.Name == "" {
+		return fmt.Errorf("filter must have a name")
+	}
+
+	filters, err := s.readConfig()
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i, existing := range filters {
+		if existing.Name == filter.Name {
+			filters[i] = filter
+			replaced = true
+		}
+	}
+	if replaced == false {
+		filters = append(filters, filter)
+	}
+
+	data, err := yaml.Marshal(filtersDocument{Filters: filters})
+	if err != nil {
+		return fmt.Errorf("failed to serialize filters: %w", err)
+	}
+
+	err = s.fs.MkdirAll(filepath.Dir(s.configFile), 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	err = afero.WriteFile(s.fs, s.configFile, data, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write filters config file: %w", err)
+	}
+
+	s.logger.Debug("saved filter to config file", "name", filter.Name, "file", s.configFile)
+	return nil
+}
+
+// SaveToCache stores the filter in the cache file, replacing any filter with
+// the same name.
+func (s *Store) SaveToCache(filter database.Filter) error {
+	if s.db == nil {
+		return fmt.Errorf("no cache available for saving filters")
+	}
+	err := s.db.SaveFilter(filter)
+	if err != nil {
+		return err
+	}
+	s.logger.Debug("saved filter to cache file", "name", filter.Name)
+	return nil
+}
+
+func (s *Store) readConfig() ([]database.Filter, error) {
+	if s.configFile == "" {
+		return nil, nil
+	}
+
+	data, err := afero.ReadFile(s.fs, s.configFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read filters config file: %w", err)
+	}
+
+	var doc filtersDocument
+	err = yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse filters config file `%s`: %w", s.configFile, err)
+	}
+	return doc.Filters, nil
+}
@@ -0,0 +1,146 @@
+package filters
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/log"
+	v0 "github.com/newrelic/node-log-viewer/internal/v0"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var nullLogger = log.NewDiscardLogger()
+
+var testLines = []string{
+	`{"v":0,"level":30,"name":"newrelic","hostname":"localhost","pid":1,"time":"2025-02-28T18:09:54.621Z","msg":"Agent state changed from stopped to starting."}`,
+	`{"v":0,"level":10,"name":"newrelic","hostname":"localhost","pid":1,"time":"2025-02-28T18:09:55.621Z","msg":"Invoking remote method metric_data","component":"remote_method"}`,
+	`{"v":0,"level":50,"name":"newrelic","hostname":"localhost","pid":1,"time":"2025-02-28T18:09:56.621Z","msg":"Agent endpoint metric_data returned 409 status. Restarting.","component":"collector_api"}`,
+	`{"v":0,"level":10,"name":"newrelic","hostname":"localhost","pid":1,"time":"2025-02-28T18:09:57.621Z","msg":"Wrapping 8 properties on nodule.","component":"Shim"}`,
+}
+
+func newTestDb(t *testing.T) *database.LogsDatabase {
+	db, err := database.New(database.DbParams{
+		DatabaseFilePath: "file::memory:",
+		DoMigration:      true,
+		Logger:           nullLogger,
+	})
+	require.Nil(t, err)
+
+	tuples := make([]database.InsertTuple, 0, len(testLines))
+	for _, line := range testLines {
+		var envelope *v0.LineEnvelope
+		require.Nil(t, json.Unmarshal([]byte(line), &envelope))
+		tuples = append(tuples, database.InsertTuple{ParsedLog: envelope, Source: line})
+	}
+	require.Nil(t, db.BatchInsert(tuples))
+
+	t.Cleanup(db.Close)
+	return db
+}
+
+func TestDefaults(t *testing.T) {
+	defaults, err := Defaults()
+	require.Nil(t, err)
+
+	names := make([]string, 0)
+	for _, filter := range defaults {
+		names = append(names, filter.Name)
+	}
+	assert.Equal(
+		t,
+		[]string{"remote-method-failures", "harvest-cycle", "instrumentation-wrapping", "errors-only"},
+		names,
+	)
+
+	db := newTestDb(t)
+	store := NewStore(StoreParams{Fs: afero.NewMemMapFs(), Database: db, Logger: nullLogger})
+
+	errorsOnly, err := store.Find("errors-only")
+	require.Nil(t, err)
+	assert.Equal(t, 1, errorsOnly.Query(db, nullLogger).NumRows())
+
+	wrapping, err := store.Find("instrumentation-wrapping")
+	require.Nil(t, err)
+	assert.Equal(t, 1, wrapping.Query(db, nullLogger).NumRows())
+}
+
+func TestStore(t *testing.T) {
+	t.Run("finds nothing for unknown names", func(t *testing.T) {
+		store := NewStore(StoreParams{Fs: afero.NewMemMapFs(), Logger: nullLogger})
+		_, err := store.Find("does-not-exist")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("saves filters to the config file", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		store := NewStore(StoreParams{Fs: fs, ConfigFile: "/config/nrlv/filters.yaml", Logger: nullLogger})
+
+		err := store.SaveToConfig(database.Filter{
+			Name:   "aggregators",
+			Search: "aggreg",
+			Mode:   database.SearchModeSubstring,
+		})
+		require.Nil(t, err)
+
+		found, err := store.Find("aggregators")
+		require.Nil(t, err)
+		assert.Equal(t, SourceConfig, found.Source)
+		assert.Equal(t, database.SearchModeSubstring, found.Mode)
+
+		contents, err := afero.ReadFile(fs, "/config/nrlv/filters.yaml")
+		require.Nil(t, err)
+		assert.Contains(t, string(contents), "mode: substring")
+	})
+
+	t.Run("cache filters take precedence", func(t *testing.T) {
+		db := newTestDb(t)
+		store := NewStore(StoreParams{
+			Fs:         afero.NewMemMapFs(),
+			ConfigFile: "/config/nrlv/filters.yaml",
+			Database:   db,
+			Logger:     nullLogger,
+		})
+
+		require.Nil(t, store.SaveToConfig(database.Filter{Name: "errors-only", MinLevel: 40}))
+		found, err := store.Find("errors-only")
+		require.Nil(t, err)
+		assert.Equal(t, SourceConfig, found.Source)
+		assert.Equal(t, 40, found.MinLevel)
+
+		require.Nil(t, store.SaveToCache(database.Filter{
+			Name:       "errors-only",
+			Components: []string{"collector_api"},
+			MinLevel:   60,
+		}))
+		found, err = store.Find("errors-only")
+		require.Nil(t, err)
+		assert.Equal(t, SourceCache, found.Source)
+		assert.Equal(t, 60, found.MinLevel)
+		assert.Equal(t, []string{"collector_api"}, found.Components)
+
+		all, err := store.All()
+		require.Nil(t, err)
+		assert.Equal(t, 4, len(all))
+	})
+
+	t.Run("cache filters retain conditions and time ranges", func(t *testing.T) {
+		db := newTestDb(t)
+		store := NewStore(StoreParams{Fs: afero.NewMemMapFs(), Database: db, Logger: nullLogger})
+
+		since := time.Date(2025, 2, 28, 18, 9, 55, 0, time.UTC)
+		filter := database.Filter{Name: "one-pid"}.
+			WithCondition(database.FacetPid, "1").
+			WithTimeRange(since, since.Add(2*time.Second))
+		require.Nil(t, store.SaveToCache(filter))
+
+		found, err := store.Find("one-pid")
+		require.Nil(t, err)
+		assert.Equal(t, map[database.Facet]string{database.FacetPid: "1"}, found.Conditions)
+		assert.True(t, since.Equal(found.Since))
+		assert.Equal(t, 2, found.Query(db, nullLogger).NumRows())
+	})
+}
@@ -0,0 +1,283 @@
+// Package harvest stitches the lines that the agent logs while sending data to
+// the collector into harvests. A harvest is a single invocation of a
+// collector method, e.g. `metric_data`, by a single agent process. The
+// `remote_method` component logs the following for each invocation:
+//
+//	Invoking remote method metric_data
+//	Calling metric_data on collector API
+//	Posting to https://collector.newrelic.com:443/...&method=metric_data&...
+//	Finished receiving data back from the collector for metric_data.
+//
+// Failures are logged by the `collector_api` component, e.g. "Agent endpoint
+// metric_data returned 409 status. Restarting.".
+package harvest
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// StatusWindow is how long after the response of a harvest a status logged by
+// the `collector_api` component may be, for the status to be attributed to
+// that harvest.
+const StatusWindow = time.Second
+
+// Components are the components whose lines are needed to track harvests.
+var Components = []string{"remote_method", "collector_api"}
+
+var (
+	matchInvoking = regexp.MustCompile(`^Invoking remote method (\S+)`)
+	matchCalling  = regexp.MustCompile(`^Calling (\S+) on collector API`)
+	matchPosting  = regexp.MustCompile(`^Posting to \S*[?&]method=([^&\s]+)`)
+	matchFinished = regexp.MustCompile(`^Finished receiving data back from the collector for (\S+?)\.?$`)
+	matchStatus   = regexp.MustCompile(`^Agent endpoint (\S+) returned (\d+) status`)
+)
+
+// Outcome is how a harvest ended.
+type Outcome int
+
+const (
+	// OutcomeMissing indicates that no response was logged for the harvest.
+	OutcomeMissing Outcome = iota
+	// OutcomeFinished indicates that the collector's response was received.
+	OutcomeFinished
+	// OutcomeFailed indicates that the collector responded with an error
+	// status.
+	OutcomeFailed
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeFinished:
+		return "finished"
+	case OutcomeFailed:
+		return "failed"
+	}
+	return "missing"
+}
+
+// Line is the part of a log line that is needed to track harvests.
+type Line struct {
+	LogId     int
+	Time      time.Time
+	Pid       int
+	Component string
+	Message   string
+
+	// Compressed is the `compressed` attribute of the "Calling" line.
+	Compressed bool
+	// DataBytes is the size of the `data` attribute of the "Calling" line.
+	DataBytes int
+	// BodyBytes is the size of the `body` attribute of the "Posting" line.
+	BodyBytes int
+}
+
+// Harvest is a single invocation of a collector method.
+type Harvest struct {
+	Method string
+	Pid    int
+
+	// Start is when the method was invoked. End is when the response, or the
+	// failure, was logged. End is zero for missing harvests.
+	Start time.Time
+	End   time.Time
+
+	// PayloadBytes is the size of the body posted to the collector, or the
+	// size of the data to be posted if the body was not logged.
+	PayloadBytes int
+	Compressed   bool
+
+	Outcome Outcome
+	// Status is the HTTP status returned by the collector for failed
+	// harvests.
+	Status int
+
+	// Ambiguous indicates that the harvest overlapped another invocation of
+	// the method by the process. The lines do not identify the invocation
+	// they belong to, so the lines after the invocation, and thereby the
+	// duration and the outcome, may belong to the other harvest.
+	Ambiguous bool
+
+	// LogIds identifies the lines that make up the harvest, in order.
+	LogIds []int
+}
+
+// Duration is the time between the invocation and the response. It is zero
+// for missing harvests.
+func (h *Harvest) Duration() time.Duration {
+	if h.End.IsZero() {
+		return 0
+	}
+	return h.End.Sub(h.Start)
+}
+
+type key struct {
+	pid    int
+	method string
+}
+
+// Tracker assigns lines to harvests. Lines must be added in the order they
+// were logged. A response is assigned to the most recent unanswered
+// invocation of the method by the process, so that an invocation that never
+// received a response does not shift the responses to later invocations.
+// When more than one invocation is unanswered, the lines cannot be matched
+// with certainty, and the harvests are marked as [Harvest.Ambiguous].
+type Tracker struct {
+	harvests []*Harvest
+	// pending holds, per process and method, the harvests that have not
+	// been answered yet, in the order they were started.
+	pending map[key][]*Harvest
+	// latest holds, per process and method, the most recent harvest.
+	latest map[key]*Harvest
+}
+
+func New() *Tracker {
+	return &Tracker{
+		harvests: make([]*Harvest, 0),
+		pending:  make(map[key][]*Harvest),
+		latest:   make(map[key]*Harvest),
+	}
+}
+
+// Add assigns the line to a harvest. Lines that are not part of a harvest
+// are ignored.
+func (t *Tracker) Add(line Line) {
+	switch line.Component {
+	case "remote_method":
+		t.addRemoteMethod(line)
+	case "collector_api":
+		t.addCollectorApi(line)
+	}
+}
+
+func (t *Tracker) addRemoteMethod(line Line) {
+	if match := matchInvoking.FindStringSubmatch(line.Message); match != nil {
+		harvest := t.start(line, match[1])
+		harvest.LogIds = append(harvest.LogIds, line.LogId)
+		return
+	}
+
+	if match := matchCalling.FindStringSubmatch(line.Message); match != nil {
+		harvest := t.unanswered(line, match[1])
+		harvest.Compressed = line.Compressed
+		if harvest.PayloadBytes == 0 {
+			harvest.PayloadBytes = line.DataBytes
+		}
+		harvest.LogIds = append(harvest.LogIds, line.LogId)
+		return
+	}
+
+	if match := matchPosting.FindStringSubmatch(line.Message); match != nil {
+		harvest := t.unanswered(line, match[1])
+		if line.BodyBytes > 0 {
+			harvest.PayloadBytes = line.BodyBytes
+		}
+		harvest.LogIds = append(harvest.LogIds, line.LogId)
+		return
+	}
+
+	if match := matchFinished.FindStringSubmatch(line.Message); match != nil {
+		harvest := t.answer(line, match[1])
+		harvest.End = line.Time
+		if harvest.Outcome == OutcomeMissing {
+			harvest.Outcome = OutcomeFinished
+		}
+		harvest.LogIds = append(harvest.LogIds, line.LogId)
+	}
+}
+
+func (t *Tracker) addCollectorApi(line Line) {
+	match := matchStatus.FindStringSubmatch(line.Message)
+	if match == nil {
+		return
+	}
+
+	// The status is logged right after the response has been received, so it
+	// belongs to the most recent unanswered harvest of the method, or to the
+	// one that was just answered. When only info level lines are logged, the
+	// failure is the only trace of the harvest.
+	k := key{pid: line.Pid, method: match[1]}
+	harvest := t.latest[k]
+	answered := harvest != nil && harvest.Outcome == OutcomeFinished && line.Time.Sub(harvest.End) <= StatusWindow
+	if len(t.pending[k]) > 0 || answered == false {
+		harvest = t.answer(line, match[1])
+		harvest.End = line.Time
+	}
+	harvest.Outcome = OutcomeFailed
+	harvest.Status, _ = strconv.Atoi(match[2])
+	harvest.LogIds = append(harvest.LogIds, line.LogId)
+}
+
+// start begins a new harvest at the time of the line.
+func (t *Tracker) start(line Line, method string) *Harvest {
+	harvest := &Harvest{
+		Method: method,
+		Pid:    line.Pid,
+		Start:  line.Time,
+		LogIds: make([]int, 0),
+	}
+	k := key{pid: line.Pid, method: method}
+	t.harvests = append(t.harvests, harvest)
+	t.pending[k] = append(t.pending[k], harvest)
+	t.latest[k] = harvest
+	return harvest
+}
+
+// unanswered finds the most recent harvest of the method that has not been
+// answered yet. A harvest is started if the invocation was not logged.
+func (t *Tracker) unanswered(line Line, method string) *Harvest {
+	pending := t.pending[key{pid: line.Pid, method: method}]
+	if len(pending) == 0 {
+		return t.start(line, method)
+	}
+	markAmbiguous(pending)
+	return pending[len(pending)-1]
+}
+
+// answer removes, and returns, the most recent harvest of the method that has
+// not been answered yet. A harvest is started if the invocation was not
+// logged.
+func (t *Tracker) answer(line Line, method string) *Harvest {
+	k := key{pid: line.Pid, method: method}
+	pending := t.pending[k]
+	if len(pending) == 0 {
+		t.start(line, method)
+		pending = t.pending[k]
+	}
+	markAmbiguous(pending)
+	harvest := pending[len(pending)-1]
+	t.pending[k] = pending[:len(pending)-1]
+	return harvest
+}
+
+// markAmbiguous marks the unanswered harvests of a method as ambiguous when
+// there is more than one of them.
+func markAmbiguous(pending []*Harvest) {
+	if len(pending) < 2 {
+		return
+	}
+	for _, harvest := range pending {
+		harvest.Ambiguous = true
+	}
+}
+
+// Harvests returns the harvests in the order they were started.
+func (t *Tracker) Harvests() []Harvest {
+	result := make([]Harvest, 0, len(t.harvests))
+	for _, harvest := range t.harvests {
+		result = append(result, *harvest)
+	}
+	return result
+}
+
+// Method returns the collector method that a `remote_method` line is about,
+// e.g. `metric_data`. An empty string is returned for other lines.
+func Method(message string) string {
+	for _, match := range []*regexp.Regexp{matchInvoking, matchCalling, matchPosting, matchFinished} {
+		if found := match.FindStringSubmatch(message); found != nil {
+			return found[1]
+		}
+	}
+	return ""
+}
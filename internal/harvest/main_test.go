@@ -0,0 +1,121 @@
+package harvest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Tracker(t *testing.T) {
+	start := time.Date(2025, 3, 6, 18, 8, 7, 0, time.UTC)
+	at := func(millis int) time.Time {
+		return start.Add(time.Duration(millis) * time.Millisecond)
+	}
+
+	t.Run("pairs invocations with their responses", func(t *testing.T) {
+		tracker := New()
+		tracker.Add(Line{LogId: 1, Time: at(0), Pid: 1, Component: "remote_method", Message: "Invoking remote method metric_data"})
+		tracker.Add(Line{LogId: 2, Time: at(1), Pid: 2, Component: "remote_method", Message: "Invoking remote method metric_data"})
+		tracker.Add(Line{LogId: 3, Time: at(1), Pid: 1, Component: "remote_method", Message: "Calling metric_data on collector API", Compressed: true, DataBytes: 100})
+		tracker.Add(Line{LogId: 4, Time: at(2), Pid: 1, Component: "remote_method", Message: "Posting to https://collector.newrelic.com:443/agent_listener/invoke_raw_method?marshal_format=json&method=metric_data&run_id=1", BodyBytes: 40})
+		tracker.Add(Line{LogId: 5, Time: at(3), Pid: 1, Component: "sampler", Message: "Recorded memory"})
+		tracker.Add(Line{LogId: 6, Time: at(170), Pid: 1, Component: "remote_method", Message: "Finished receiving data back from the collector for metric_data."})
+
+		harvests := tracker.Harvests()
+		require.Equal(t, 2, len(harvests))
+		assert.Equal(t, Harvest{
+			Method:       "metric_data",
+			Pid:          1,
+			Start:        at(0),
+			End:          at(170),
+			PayloadBytes: 40,
+			Compressed:   true,
+			Outcome:      OutcomeFinished,
+			LogIds:       []int{1, 3, 4, 6},
+		}, harvests[0])
+		assert.Equal(t, 170*time.Millisecond, harvests[0].Duration())
+
+		assert.Equal(t, 2, harvests[1].Pid)
+		assert.Equal(t, OutcomeMissing, harvests[1].Outcome)
+		assert.Equal(t, "missing", harvests[1].Outcome.String())
+		assert.Equal(t, time.Duration(0), harvests[1].Duration())
+	})
+
+	t.Run("answers the most recent invocation", func(t *testing.T) {
+		tracker := New()
+		tracker.Add(Line{LogId: 1, Time: at(0), Component: "remote_method", Message: "Invoking remote method span_event_data"})
+		tracker.Add(Line{LogId: 2, Time: at(60_000), Component: "remote_method", Message: "Invoking remote method span_event_data"})
+		tracker.Add(Line{LogId: 3, Time: at(60_090), Component: "remote_method", Message: "Finished receiving data back from the collector for span_event_data."})
+
+		harvests := tracker.Harvests()
+		require.Equal(t, 2, len(harvests))
+		assert.Equal(t, OutcomeMissing, harvests[0].Outcome)
+		assert.Equal(t, []int{1}, harvests[0].LogIds)
+		assert.Equal(t, OutcomeFinished, harvests[1].Outcome)
+		assert.Equal(t, 90*time.Millisecond, harvests[1].Duration())
+
+		// The response may belong to either invocation.
+		assert.True(t, harvests[0].Ambiguous)
+		assert.True(t, harvests[1].Ambiguous)
+	})
+
+	t.Run("records failures reported by the collector api", func(t *testing.T) {
+		tracker := New()
+		tracker.Add(Line{LogId: 1, Time: at(0), Component: "remote_method", Message: "Invoking remote method metric_data"})
+		tracker.Add(Line{LogId: 2, Time: at(50), Component: "remote_method", Message: "Finished receiving data back from the collector for metric_data."})
+		tracker.Add(Line{LogId: 3, Time: at(51), Component: "collector_api", Message: "Agent endpoint metric_data returned 409 status. Restarting."})
+		tracker.Add(Line{LogId: 4, Time: at(52), Component: "collector_api", Message: "Restarting collector."})
+		// Without debug lines, the failure is the only trace of a harvest.
+		tracker.Add(Line{LogId: 5, Time: at(90), Component: "collector_api", Message: "Agent endpoint metric_data returned 410 status. Shutting down."})
+
+		harvests := tracker.Harvests()
+		require.Equal(t, 2, len(harvests))
+		assert.Equal(t, OutcomeFailed, harvests[0].Outcome)
+		assert.Equal(t, 409, harvests[0].Status)
+		assert.Equal(t, at(50), harvests[0].End)
+		assert.Equal(t, []int{1, 2, 3}, harvests[0].LogIds)
+
+		assert.Equal(t, Harvest{
+			Method:  "metric_data",
+			Start:   at(90),
+			End:     at(90),
+			Outcome: OutcomeFailed,
+			Status:  410,
+			LogIds:  []int{5},
+		}, harvests[1])
+	})
+
+	t.Run("only attributes a status to a recent or pending harvest", func(t *testing.T) {
+		tracker := New()
+		tracker.Add(Line{LogId: 1, Time: at(0), Component: "remote_method", Message: "Invoking remote method metric_data"})
+		tracker.Add(Line{LogId: 2, Time: at(50), Component: "remote_method", Message: "Finished receiving data back from the collector for metric_data."})
+		// A minute later, the status belongs to a harvest whose other lines
+		// were not logged.
+		tracker.Add(Line{LogId: 3, Time: at(60_050), Component: "collector_api", Message: "Agent endpoint metric_data returned 503 status."})
+		tracker.Add(Line{LogId: 4, Time: at(120_000), Component: "remote_method", Message: "Invoking remote method metric_data"})
+		tracker.Add(Line{LogId: 5, Time: at(120_080), Component: "collector_api", Message: "Agent endpoint metric_data returned 503 status."})
+
+		harvests := tracker.Harvests()
+		require.Equal(t, 3, len(harvests))
+		assert.Equal(t, OutcomeFinished, harvests[0].Outcome)
+		assert.Equal(t, []int{1, 2}, harvests[0].LogIds)
+
+		assert.Equal(t, OutcomeFailed, harvests[1].Outcome)
+		assert.Equal(t, at(60_050), harvests[1].Start)
+		assert.Equal(t, []int{3}, harvests[1].LogIds)
+
+		assert.Equal(t, OutcomeFailed, harvests[2].Outcome)
+		assert.Equal(t, 503, harvests[2].Status)
+		assert.Equal(t, 80*time.Millisecond, harvests[2].Duration())
+		assert.Equal(t, []int{4, 5}, harvests[2].LogIds)
+	})
+}
+
+func Test_Method(t *testing.T) {
+	assert.Equal(t, "metric_data", Method("Calling metric_data on collector API"))
+	assert.Equal(t, "span_event_data", Method("Posting to https://collector.newrelic.com:443/agent_listener/invoke_raw_method?marshal_format=json&method=span_event_data&run_id=1"))
+	assert.Equal(t, "connect", Method("Finished receiving data back from the collector for connect."))
+	assert.Equal(t, "", Method("Recorded memory"))
+}
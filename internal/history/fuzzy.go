@@ -0,0 +1,63 @@
+package history
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// FuzzyScore determines if every character of the pattern appears, in order,
+// within the candidate, ignoring case. When it does, a score is returned
+// that is higher for better matches: consecutive characters, characters at
+// the start of a word, and a match at the start of the candidate all increase
+// the score, while longer candidates decrease it.
+func FuzzyScore(pattern string, candidate string) (int, bool) {
+	pattern = strings.ToLower(pattern)
+	lowered := strings.ToLower(candidate)
+	if pattern == "" {
+		return 0, true
+	}
+
+	score := 0
+	consecutive := 0
+	patternRunes := []rune(pattern)
+	p := 0
+	var previous rune
+	for i, r := range lowered {
+		if p == len(patternRunes) {
+			break
+		}
+
+		if r != patternRunes[p] {
+			consecutive = 0
+			previous = r
+			continue
+		}
+
+		score++
+		consecutive++
+		score += consecutive * 2
+		switch {
+		case i == 0:
+			score += 8
+		case isWordBoundary(previous):
+			score += 4
+		}
+
+		p++
+		previous = r
+	}
+
+	if p < len(patternRunes) {
+		return 0, false
+	}
+
+	return score - utf8.RuneCountInString(candidate)/8, true
+}
+
+func isWordBoundary(r rune) bool {
+	switch r {
+	case ' ', '_', '-', '.', ':', '/', '"':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/log"
+	"github.com/newrelic/node-log-viewer/internal/misc"
+	"github.com/spf13/afero"
+)
+
+// Entry is a single executed search.
+type Entry struct {
+	Time time.Time           `json:"time"`
+	Term string              `json:"term"`
+	Mode database.SearchMode `json:"mode"`
+}
+
+// History is the set of searches executed by the user across all sessions.
+// It is stored as NDJSON, one [Entry] per line, with the newest entry last.
+type History struct {
+	fs         afero.Fs
+	file       string
+	maxEntries int
+	logger     *log.Logger
+
+	entries []Entry
+}
+
+type Params struct {
+	Fs afero.Fs
+	// File is the path to the history file. When empty, the history is only
+	// retained for the current session.
+	File string
+	// MaxEntries is the number of entries to retain. Older entries are
+	// discarded when the history is loaded. Defaults to 1,000.
+	MaxEntries int
+	Logger     *log.Logger
+}
+
+func New(params Params) *History {
+	maxEntries := params.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = 1_000
+	}
+	return &History{
+		fs:         params.Fs,
+		file:       params.File,
+		maxEntries: maxEntries,
+		logger:     params.Logger,
+		entries:    make([]Entry, 0),
+	}
+}
+
+// DefaultFile returns the path to the user's search history file, e.g.
+// `~/.config/nrlv/search_history.ndjson` on Linux.
+func DefaultFile() (string, error) {
+	configDir, err := misc.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "search_history.ndjson"), nil
+}
+
+// Load reads the history file. Lines that cannot be parsed are skipped. If
+// the file holds more than the maximum number of entries, it is rewritten
+// with only the newest entries.
+func (h *History) Load() error {
+	if h.file == "" {
+		return nil
+	}
+
+	data, err := afero.ReadFile(h.fs, h.file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read search history file: %w", err)
+	}
+
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var entry Entry
+		err = json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil || entry.Term == "" {
+			h.logger.Warn("skipping invalid search history line", "line", scanner.Text())
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) > h.maxEntries {
+		entries = entries[len(entries)-h.maxEntries:]
+		h.entries = entries
+		return h.rewrite()
+	}
+
+	h.entries = entries
+	return nil
+}
+
+// Add records an executed search and appends it to the history file.
+func (h *History) Add(term string, mode database.SearchMode) error {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return nil
+	}
+
+	entry := Entry{Time: time.Now().UTC(), Term: term, Mode: mode}
+	h.entries = append(h.entries, entry)
+	if h.file == "" {
+		return nil
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to serialize search history entry: %w", err)
+	}
+
+	err = h.fs.MkdirAll(filepath.Dir(h.file), 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to create search history directory: %w", err)
+	}
+	file, err := h.fs.OpenFile(h.file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open search history file: %w", err)
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("failed to write search history file: %w", err)
+	}
+	return nil
+}
+
+// Entries returns every entry in the history, oldest first.
+func (h *History) Entries() []Entry {
+	return slices.Clone(h.entries)
+}
+
+// Recent returns the entries, newest first, with repeated search terms
+// reduced to their most recent execution.
+func (h *History) Recent() []Entry {
+	result := make([]Entry, 0)
+	seen := make(map[string]bool)
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		entry := h.entries[i]
+		if seen[entry.Term] == true {
+			continue
+		}
+		seen[entry.Term] = true
+		result = append(result, entry)
+	}
+	return result
+}
+
+// Suggest returns up to limit previously executed search terms that fuzzily
+// match the input, best matches first. Terms that match equally well are
+// ordered by how recently they were executed.
+func (h *History) Suggest(input string, limit int) []string {
+	type candidate struct {
+		term  string
+		score int
+		rank  int
+	}
+
+	candidates := make([]candidate, 0)
+	for rank, entry := range h.Recent() {
+		if entry.Term == input {
+			continue
+		}
+		score, ok := FuzzyScore(input, entry.Term)
+		if ok == false {
+			continue
+		}
+		candidates = append(candidates, candidate{term: entry.Term, score: score, rank: rank})
+	}
+
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		if a.score != b.score {
+			return b.score - a.score
+		}
+		return a.rank - b.rank
+	})
+
+	result := make([]string, 0, limit)
+	for _, c := range candidates {
+		if len(result) == limit {
+			break
+		}
+		result = append(result, c.term)
+	}
+	return result
+}
+
+func (h *History) rewrite() error {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	for _, entry := range h.entries {
+		err := encoder.Encode(entry)
+		if err != nil {
+			return fmt.Errorf("failed to serialize search history entry: %w", err)
+		}
+	}
+
+	err := afero.WriteFile(h.fs, h.file, buf.Bytes(), 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write search history file: %w", err)
+	}
+	return nil
+}
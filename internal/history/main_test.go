@@ -0,0 +1,91 @@
+package history
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var nullLogger = log.NewDiscardLogger()
+
+func TestHistory(t *testing.T) {
+	t.Run("persists entries across sessions", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		params := Params{Fs: fs, File: "/config/nrlv/search_history.ndjson", Logger: nullLogger}
+
+		first := New(params)
+		require.Nil(t, first.Load())
+		require.Nil(t, first.Add("remote_method", database.SearchModeWords))
+		require.Nil(t, first.Add("aggreg", database.SearchModeSubstring))
+		require.Nil(t, first.Add("   ", database.SearchModeWords))
+
+		second := New(params)
+		require.Nil(t, second.Load())
+		entries := second.Entries()
+		require.Equal(t, 2, len(entries))
+		assert.Equal(t, "remote_method", entries[0].Term)
+		assert.Equal(t, "aggreg", entries[1].Term)
+		assert.Equal(t, database.SearchModeSubstring, entries[1].Mode)
+		assert.False(t, entries[1].Time.IsZero())
+	})
+
+	t.Run("discards old entries", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		params := Params{Fs: fs, File: "/history.ndjson", MaxEntries: 2, Logger: nullLogger}
+
+		first := New(params)
+		require.Nil(t, first.Add("one", database.SearchModeWords))
+		require.Nil(t, first.Add("two", database.SearchModeWords))
+		require.Nil(t, first.Add("three", database.SearchModeWords))
+
+		second := New(params)
+		require.Nil(t, second.Load())
+		assert.Equal(t, 2, len(second.Entries()))
+
+		contents, err := afero.ReadFile(fs, "/history.ndjson")
+		require.Nil(t, err)
+		assert.Equal(t, 2, strings.Count(string(contents), "\n"))
+	})
+
+	t.Run("recent entries are unique and newest first", func(t *testing.T) {
+		h := New(Params{Fs: afero.NewMemMapFs(), Logger: nullLogger})
+		h.Add("a", database.SearchModeWords)
+		h.Add("b", database.SearchModeWords)
+		h.Add("a", database.SearchModeWords)
+
+		recent := h.Recent()
+		require.Equal(t, 2, len(recent))
+		assert.Equal(t, "a", recent[0].Term)
+		assert.Equal(t, "b", recent[1].Term)
+	})
+
+	t.Run("suggests fuzzy matches", func(t *testing.T) {
+		h := New(Params{Fs: afero.NewMemMapFs(), Logger: nullLogger})
+		h.Add("remote_method AND error", database.SearchModeWords)
+		h.Add("metric_data", database.SearchModeWords)
+		h.Add("remote_method", database.SearchModeWords)
+		h.Add("span_event_data", database.SearchModeWords)
+
+		assert.Equal(t, []string{"remote_method", "remote_method AND error"}, h.Suggest("rem", 5))
+		assert.Equal(t, []string{"metric_data"}, h.Suggest("mdata", 5))
+		assert.ElementsMatch(t, []string{"metric_data", "span_event_data"}, h.Suggest("edata", 5))
+		assert.Equal(t, []string{"remote_method"}, h.Suggest("rmeth", 1))
+		assert.Empty(t, h.Suggest("zzz", 5))
+	})
+}
+
+func TestFuzzyScore(t *testing.T) {
+	_, ok := FuzzyScore("abc", "a_b_c")
+	assert.True(t, ok)
+	_, ok = FuzzyScore("abc", "acb")
+	assert.False(t, ok)
+
+	prefix, _ := FuzzyScore("rem", "remote_method")
+	inner, _ := FuzzyScore("rem", "some_remote_method")
+	assert.Greater(t, prefix, inner)
+}
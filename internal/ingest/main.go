@@ -0,0 +1,120 @@
+// Package ingest reads agent log files into the cache database.
+package ingest
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/log"
+	v0 "github.com/newrelic/node-log-viewer/internal/v0"
+)
+
+// batchSize is the number of lines inserted into the database at a time.
+const batchSize = 1_000
+
+var matchLeadingK8sTimestamp = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}\s\d{2}:\d{2}:\d{2}\.\d{3,}\s+?`)
+
+// Read reads through a theoretical agent NDJSON log file, validates each
+// line, and stores each validated line in the cache database. Lines that are
+// not agent log lines are skipped.
+func Read(logFile io.Reader, db *database.LogsDatabase, logger *log.Logger) error {
+	scanBuffer := make([]byte, 0, 64*1_024)
+	scanner := bufio.NewScanner(logFile)
+	scanner.Buffer(scanBuffer, 1_024*1_024) // Scan up to 1MB.
+
+	// The byte offset of each line is tracked so that index only caches can
+	// read the line from the log file, see [database.LogsDatabase.SetSourceFile].
+	var offset, lineOffset int64
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		if token != nil {
+			lineOffset = offset
+		}
+		offset += int64(advance)
+		return advance, token, err
+	})
+
+	parsedLinesBuffer := make([]database.InsertTuple, 0)
+
+	// TODO: the scanner only scans up to a maximum number of bytes before it
+	// gives up looking for the token (`\n` in our case) and generates a
+	// `bufio.ErrTooLong` error. We should instead use `bufio.Reader.ReadString`
+	// to parse line by line. But that will require a bit of refactoring of this
+	// loop. For now, 2025-05-28, it's simpler to increase the maximum byte limit.
+	// See:
+	// + https://stackoverflow.com/a/37455465
+	// + https://stackoverflow.com/a/6143530
+	for scanner.Scan() {
+		err := scanner.Err()
+		if err != nil {
+			logger.Error("failed to scan input", "error", err)
+			return err
+		}
+
+		// TODO: when we have a v1 line type, we need to do some text inspection
+		// to determine the type of log line to unmarshal. This may mean we need
+		// different viewers, as well.
+		var envelope *v0.LineEnvelope
+		sourceBytes := scanner.Bytes()
+		sourceString := string(sourceBytes)
+		sourceOffset := lineOffset
+
+		if len(sourceString) == 0 {
+			// Skip empty lines in the source file.
+			continue
+		}
+		if matchLeadingK8sTimestamp.MatchString(sourceString) == true {
+			// Looks like the line starts with a k8s style timestamp. So we
+			// trim it off.
+			idx := strings.Index(sourceString, "{")
+			if idx == -1 {
+				logger.Warn("line with leading timestamp does not seem to be a log line", "line", sourceString)
+				continue
+			}
+			logger.Debug("trimming leading timestamp", "line", sourceString)
+			sourceString = sourceString[idx:]
+			sourceOffset += int64(idx)
+		}
+		if sourceString[0:1] != "{" || sourceString[len(sourceString)-1:] != "}" {
+			// Skip lines that do not look like NDJSON.
+			logger.Warn("skipping parsing of malformed line", "line", sourceString)
+			continue
+		}
+
+		err = json.Unmarshal([]byte(sourceString), &envelope)
+		if err != nil {
+			logger.Warn("failed to parse line", "error", err, "line", sourceString)
+			continue
+		}
+
+		tuple := database.InsertTuple{
+			ParsedLog: envelope,
+			Source:    sourceString,
+			Offset:    sourceOffset,
+			Length:    int64(len(sourceString)),
+		}
+		if len(parsedLinesBuffer) < batchSize {
+			parsedLinesBuffer = append(parsedLinesBuffer, tuple)
+		} else {
+			err = db.BatchInsert(parsedLinesBuffer)
+			if err != nil {
+				return err
+			}
+			parsedLinesBuffer = []database.InsertTuple{tuple}
+		}
+	}
+
+	if len(parsedLinesBuffer) > 0 {
+		err := db.BatchInsert(parsedLinesBuffer)
+		if err != nil {
+			return err
+		}
+	}
+
+	logger.Debug("finished reading log lines from input")
+	return db.SegmentSessions()
+}
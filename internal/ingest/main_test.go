@@ -0,0 +1,218 @@
+package ingest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var nullLogger = log.NewDiscardLogger()
+
+func newDatabase(t *testing.T, file string) *database.LogsDatabase {
+	t.Helper()
+	db, err := database.New(database.DbParams{
+		DatabaseFilePath: file,
+		DoMigration:      true,
+		Logger:           nullLogger,
+	})
+	require.Nil(t, err)
+	return db
+}
+
+// readFile reads the log file into the cache, optionally in the index only
+// mode.
+func readFile(t *testing.T, db *database.LogsDatabase, logFile string, indexOnly bool) {
+	t.Helper()
+	if indexOnly == true {
+		require.Nil(t, db.SetSourceFile(logFile))
+	}
+
+	file, err := os.Open(logFile)
+	require.Nil(t, err)
+	defer file.Close()
+	require.Nil(t, Read(file, db, nullLogger))
+}
+
+func originals(t *testing.T, query *database.Query) []string {
+	t.Helper()
+	rows, err := query.AllResults()
+	require.Nil(t, err)
+
+	result := make([]string, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, row.Original)
+	}
+	return result
+}
+
+func TestRead(t *testing.T) {
+	t.Run("skips lines that are not log lines", func(t *testing.T) {
+		db := newDatabase(t, filepath.Join(t.TempDir(), "cache.sqlite"))
+		defer db.Close()
+		readFile(t, db, "../../testdata/k8s-interleaved.log", false)
+
+		rows, err := database.SelectAllQuery(db, nullLogger).AllResults()
+		require.Nil(t, err)
+		assert.NotEmpty(t, rows)
+		for _, row := range rows {
+			assert.Equal(t, "{", row.Original[0:1])
+		}
+	})
+
+	t.Run("assigns the lines to sessions", func(t *testing.T) {
+		db := newDatabase(t, filepath.Join(t.TempDir(), "cache.sqlite"))
+		defer db.Close()
+		readFile(t, db, "../../testdata/v0/http-server.log", false)
+
+		var unassigned int
+		err := db.Connection.QueryRow(`select count(*) from logs where session_id is null`).Scan(&unassigned)
+		require.Nil(t, err)
+		assert.Equal(t, 0, unassigned)
+
+		sessions, err := db.Sessions()
+		require.Nil(t, err)
+		assert.Equal(t, 9, len(sessions))
+	})
+
+	t.Run("reads lines from the source file of an index only cache", func(t *testing.T) {
+		logFile := filepath.Join(t.TempDir(), "newrelic_agent.log")
+		source, err := os.ReadFile("../../testdata/k8s-interleaved.log")
+		require.Nil(t, err)
+		require.Nil(t, os.WriteFile(logFile, source, 0644))
+
+		stored := newDatabase(t, filepath.Join(t.TempDir(), "stored.sqlite"))
+		defer stored.Close()
+		readFile(t, stored, logFile, false)
+
+		indexed := newDatabase(t, filepath.Join(t.TempDir(), "indexed.sqlite"))
+		defer indexed.Close()
+		readFile(t, indexed, logFile, true)
+
+		var numStored int
+		err = indexed.Connection.QueryRow(`select count(*) from logs where original is not null`).Scan(&numStored)
+		require.Nil(t, err)
+		assert.Equal(t, 0, numStored)
+
+		var numTrigrams int
+		err = indexed.Connection.QueryRow(`select count(*) from logs_trigram where logs_trigram match '"version"'`).Scan(&numTrigrams)
+		require.Nil(t, err)
+		assert.Equal(t, 0, numTrigrams)
+
+		expected := originals(t, database.SelectAllQuery(stored, nullLogger))
+		assert.NotEmpty(t, expected)
+		assert.Equal(t, expected, originals(t, database.SelectAllQuery(indexed, nullLogger)))
+
+		filters := []database.Filter{
+			{Search: "shim"},
+			{Search: "version", Mode: database.SearchModeSubstring},
+			{Search: "ab", Mode: database.SearchModeSubstring},
+			{Search: "pid > 0"},
+		}
+		for _, filter := range filters {
+			assert.Equal(
+				t,
+				originals(t, filter.Query(stored, nullLogger)),
+				originals(t, filter.Query(indexed, nullLogger)),
+				filter.Search,
+			)
+		}
+
+		envelope := database.SelectAllQuery(indexed, nullLogger).GetRow(1)
+		require.NotNil(t, envelope)
+		assert.Equal(t, database.SelectAllQuery(stored, nullLogger).GetRow(1).Message(), envelope.Message())
+	})
+
+	t.Run("refuses an index only cache whose source file changed", func(t *testing.T) {
+		logFile := filepath.Join(t.TempDir(), "newrelic_agent.log")
+		source, err := os.ReadFile("../../testdata/v0/good-line.log")
+		require.Nil(t, err)
+		require.Nil(t, os.WriteFile(logFile, source, 0644))
+
+		cacheFile := filepath.Join(t.TempDir(), "cache.sqlite")
+		db := newDatabase(t, cacheFile)
+		readFile(t, db, logFile, true)
+		sourceFile, found := db.SourceFile()
+		assert.True(t, found)
+		db.Close()
+
+		db = newDatabase(t, cacheFile)
+		db.Close()
+
+		require.Nil(t, os.WriteFile(logFile, append(source, source...), 0644))
+		_, err = database.New(database.DbParams{
+			DatabaseFilePath: cacheFile,
+			DoMigration:      true,
+			Logger:           nullLogger,
+		})
+		assert.ErrorIs(t, err, database.ErrSourceChanged)
+		assert.ErrorContains(t, err, sourceFile.Path)
+
+		require.Nil(t, os.Remove(logFile))
+		_, err = database.New(database.DbParams{
+			DatabaseFilePath: cacheFile,
+			DoMigration:      true,
+			Logger:           nullLogger,
+		})
+		assert.ErrorIs(t, err, database.ErrSourceChanged)
+	})
+	t.Run("only reads valid locations of the source file", func(t *testing.T) {
+		logFile := filepath.Join(t.TempDir(), "newrelic_agent.log")
+		source, err := os.ReadFile("../../testdata/v0/good-line.log")
+		require.Nil(t, err)
+		require.Nil(t, os.WriteFile(logFile, source, 0644))
+
+		db := newDatabase(t, filepath.Join(t.TempDir(), "cache.sqlite"))
+		defer db.Close()
+		readFile(t, db, logFile, true)
+		sourceFile, _ := db.SourceFile()
+
+		var text string
+		err = db.Connection.QueryRow(`select nr_source(?, 0, 1)`, sourceFile.Path).Scan(&text)
+		require.Nil(t, err)
+		assert.Equal(t, "{", text)
+
+		err = db.Connection.QueryRow(`select nr_source(?, 0, -1)`, sourceFile.Path).Scan(&text)
+		assert.ErrorContains(t, err, "invalid location")
+		err = db.Connection.QueryRow(`select nr_source(?, 0, 1 << 40)`, sourceFile.Path).Scan(&text)
+		assert.ErrorContains(t, err, "exceeds")
+		err = db.Connection.QueryRow(`select nr_source(?, 0, 1)`, "../../testdata/v0/http-server.log").Scan(&text)
+		assert.ErrorContains(t, err, "not the source file")
+	})
+	t.Run("shares the source file between caches", func(t *testing.T) {
+		logFile := filepath.Join(t.TempDir(), "newrelic_agent.log")
+		source, err := os.ReadFile("../../testdata/v0/good-line.log")
+		require.Nil(t, err)
+		require.Nil(t, os.WriteFile(logFile, source, 0644))
+
+		first := newDatabase(t, filepath.Join(t.TempDir(), "first.sqlite"))
+		readFile(t, first, logFile, true)
+		second := newDatabase(t, filepath.Join(t.TempDir(), "second.sqlite"))
+		defer second.Close()
+		readFile(t, second, logFile, true)
+
+		// Closing one cache must not close the file for the other.
+		first.Close()
+		assert.NotEmpty(t, originals(t, database.SelectAllQuery(second, nullLogger)))
+	})
+	t.Run("stops reading a source file that changes while the cache is open", func(t *testing.T) {
+		logFile := filepath.Join(t.TempDir(), "newrelic_agent.log")
+		source, err := os.ReadFile("../../testdata/v0/good-line.log")
+		require.Nil(t, err)
+		require.Nil(t, os.WriteFile(logFile, source, 0644))
+
+		db := newDatabase(t, filepath.Join(t.TempDir(), "cache.sqlite"))
+		defer db.Close()
+		readFile(t, db, logFile, true)
+		sourceFile, _ := db.SourceFile()
+
+		require.Nil(t, os.WriteFile(logFile, append(source, source...), 0644))
+		var text string
+		err = db.Connection.QueryRow(`select nr_source(?, 0, 1)`, sourceFile.Path).Scan(&text)
+		assert.ErrorContains(t, err, database.ErrSourceChanged.Error())
+	})
+}
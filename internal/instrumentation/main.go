@@ -0,0 +1,226 @@
+// Package instrumentation builds an inventory of the modules that the agent
+// instrumented from the lines of its shims and of `shimmer`. The shims, e.g.
+// `Shim`, `WebFrameworkShim` and `DatastoreShim`, log the module they wrap in
+// the `module` attribute, and the framework or datastore in the `framework`
+// or `datastore` attribute:
+//
+//	Wrapping 8 properties on nodule.
+//	Replacing "all" with wrapped version
+//	Wrapping "exec" with metric recording.
+//
+// `shimmer` logs the instrumentation of core modules, e.g. "Instrumented
+// http.request.", and the modules it refuses to instrument, e.g.
+// "Instrumentation for http has been disabled via
+// 'config.instrumentation.http.enabled. Not instrumenting package".
+//
+// A module that is missing from the inventory was never loaded after the
+// agent, so the agent had no chance to instrument it.
+package instrumentation
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ShimmerComponent is the component that loads instrumentation. The shims'
+// components all end in ShimSuffix.
+const (
+	ShimmerComponent = "shimmer"
+	ShimSuffix       = "Shim"
+)
+
+var (
+	matchProperties   = regexp.MustCompile(`^Wrapping (\d+) properties on nodule`)
+	matchReplacing    = regexp.MustCompile(`^Replacing "(.+)" with wrapped version`)
+	matchRecording    = regexp.MustCompile(`^Wrapping "(.+)" with (?:metric recording|a segment)`)
+	matchInstrumented = regexp.MustCompile(`^Instrumented ([^.\s]+)\.(\S+?)\.?$`)
+	matchDisabled     = regexp.MustCompile(`^Instrumentation for (\S+) has been disabled`)
+	matchIssue        = regexp.MustCompile(`(?i)not instrumenting|disabled|failed|could not|couldn't|can't|cannot|unable to|\berror\b`)
+	// matchRuntime matches lines logged while the application runs, rather
+	// than while the module is instrumented.
+	matchRuntime = regexp.MustCompile(`^Not recording function`)
+)
+
+// warnLevel is the level at and above which lines are issues regardless of
+// their message.
+const warnLevel = 40
+
+// Status summarizes whether a module was instrumented.
+type Status int
+
+const (
+	// StatusInstrumented indicates that properties were wrapped without any
+	// issue.
+	StatusInstrumented Status = iota
+	// StatusPartial indicates that properties were wrapped, but that issues
+	// were logged as well.
+	StatusPartial
+	// StatusNotInstrumented indicates that no property was wrapped.
+	StatusNotInstrumented
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusInstrumented:
+		return "instrumented"
+	case StatusPartial:
+		return "partial"
+	}
+	return "not instrumented"
+}
+
+// Line is the part of a log line that is needed to build the inventory.
+type Line struct {
+	LogId     int
+	Time      time.Time
+	Pid       int
+	Level     int
+	Component string
+	Message   string
+
+	// Module, Framework and Datastore are the attributes of shim lines.
+	Module    string
+	Framework string
+	Datastore string
+}
+
+// Issue is a line that indicates that a module was not, or only partially,
+// instrumented.
+type Issue struct {
+	LogId   int
+	Time    time.Time
+	Level   int
+	Message string
+}
+
+// Module is the instrumentation of a single module.
+type Module struct {
+	Name string
+	// Framework is the web framework or the datastore, e.g. "Expressjs" or
+	// "MongoDB".
+	Framework string
+	// Shims are the components that instrumented the module, e.g.
+	// "WebFrameworkShim".
+	Shims []string
+	// Properties is the number of properties that the shims set out to wrap.
+	Properties int
+	// Wrapped are the names of the wrapped properties.
+	Wrapped []string
+	Issues  []Issue
+
+	Pids   []int
+	LogIds []int
+}
+
+func (m *Module) Status() Status {
+	switch {
+	case len(m.Wrapped) == 0 && m.Properties == 0:
+		return StatusNotInstrumented
+	case len(m.Issues) > 0:
+		return StatusPartial
+	}
+	return StatusInstrumented
+}
+
+// Builder aggregates lines into the inventory.
+type Builder struct {
+	modules map[string]*Module
+	wrapped map[string]map[string]bool
+	// current is the module that each process instrumented last. Lines that
+	// do not name their module, e.g. those of `shimmer`, are attributed to it.
+	current map[int]string
+}
+
+func New() *Builder {
+	return &Builder{
+		modules: make(map[string]*Module),
+		wrapped: make(map[string]map[string]bool),
+		current: make(map[int]string),
+	}
+}
+
+// Add attributes a line to its module. Lines that cannot be attributed to a
+// module are ignored.
+func (b *Builder) Add(line Line) {
+	if matchRuntime.MatchString(line.Message) {
+		return
+	}
+
+	name := line.Module
+	property := ""
+	if matches := matchInstrumented.FindStringSubmatch(line.Message); matches != nil {
+		name, property = matches[1], matches[2]
+	}
+	if matches := matchDisabled.FindStringSubmatch(line.Message); matches != nil {
+		name = matches[1]
+	}
+	if name == "" {
+		name = b.current[line.Pid]
+	}
+	if name == "" {
+		return
+	}
+	b.current[line.Pid] = name
+
+	module := b.modules[name]
+	if module == nil {
+		module = &Module{Name: name}
+		b.modules[name] = module
+		b.wrapped[name] = make(map[string]bool)
+	}
+	module.LogIds = append(module.LogIds, line.LogId)
+	if slices.Contains(module.Pids, line.Pid) == false {
+		module.Pids = append(module.Pids, line.Pid)
+	}
+	if strings.HasSuffix(line.Component, ShimSuffix) && slices.Contains(module.Shims, line.Component) == false {
+		module.Shims = append(module.Shims, line.Component)
+	}
+	if line.Framework != "" {
+		module.Framework = line.Framework
+	} else if line.Datastore != "" {
+		module.Framework = line.Datastore
+	}
+
+	if matches := matchProperties.FindStringSubmatch(line.Message); matches != nil {
+		count, _ := strconv.Atoi(matches[1])
+		module.Properties += count
+		return
+	}
+	if matches := matchReplacing.FindStringSubmatch(line.Message); matches != nil {
+		property = matches[1]
+	}
+	if matches := matchRecording.FindStringSubmatch(line.Message); matches != nil {
+		property = matches[1]
+	}
+	if property != "" {
+		// Property names may look like issues, e.g. "errorHandler".
+		b.wrapped[name][property] = true
+		return
+	}
+
+	if line.Level >= warnLevel || matchIssue.MatchString(line.Message) {
+		module.Issues = append(module.Issues, Issue{
+			LogId:   line.LogId,
+			Time:    line.Time,
+			Level:   line.Level,
+			Message: line.Message,
+		})
+	}
+}
+
+// Modules returns the inventory ordered by module name.
+func (b *Builder) Modules() []Module {
+	result := make([]Module, 0, len(b.modules))
+	for _, name := range slices.Sorted(maps.Keys(b.modules)) {
+		module := *b.modules[name]
+		module.Wrapped = slices.Sorted(maps.Keys(b.wrapped[name]))
+		slices.Sort(module.Shims)
+		slices.Sort(module.Pids)
+		result = append(result, module)
+	}
+	return result
+}
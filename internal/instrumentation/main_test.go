@@ -0,0 +1,48 @@
+package instrumentation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Builder(t *testing.T) {
+	builder := New()
+	builder.Add(Line{LogId: 1, Pid: 7, Level: 10, Component: "WebFrameworkShim", Message: "Wrapping 8 properties on nodule.", Module: "express", Framework: "Expressjs"})
+	builder.Add(Line{LogId: 2, Pid: 7, Level: 10, Component: "WebFrameworkShim", Message: `Replacing "all" with wrapped version`, Module: "express", Framework: "Expressjs"})
+	builder.Add(Line{LogId: 3, Pid: 7, Level: 10, Component: "WebFrameworkShim", Message: `Replacing "errorHandler" with wrapped version`, Module: "express", Framework: "Expressjs"})
+	builder.Add(Line{LogId: 4, Pid: 7, Level: 40, Component: "shimmer", Message: "Instrumentation for http has been disabled via 'config.instrumentation.http.enabled. Not instrumenting package"})
+	builder.Add(Line{LogId: 5, Pid: 8, Level: 10, Component: "shimmer", Message: "Instrumented http.createServer."})
+	builder.Add(Line{LogId: 6, Pid: 8, Level: 20, Component: "shimmer", Message: "Can't wrap Response.prototype.end from nonexistent object."})
+	builder.Add(Line{LogId: 7, Pid: 8, Level: 10, Component: "Shim", Message: "Not recording function setTimeout, not in a transaction.", Module: "timers"})
+	builder.Add(Line{LogId: 8, Pid: 8, Level: 10, Component: "DatastoreShim", Message: "Created segment", Module: "mongodb", Datastore: "MongoDB"})
+	builder.Add(Line{LogId: 9, Pid: 9, Level: 10, Component: "shimmer", Message: "Instrumentation for koa has been disabled via 'config.instrumentation.koa.enabled. Not instrumenting package"})
+
+	modules := builder.Modules()
+	names := make([]string, 0, len(modules))
+	for _, m := range modules {
+		names = append(names, m.Name)
+	}
+	require.Equal(t, []string{"express", "http", "koa", "mongodb"}, names)
+
+	express := modules[0]
+	assert.Equal(t, "Expressjs", express.Framework)
+	assert.Equal(t, []string{"WebFrameworkShim"}, express.Shims)
+	assert.Equal(t, 8, express.Properties)
+	assert.Equal(t, []string{"all", "errorHandler"}, express.Wrapped)
+	assert.Empty(t, express.Issues)
+	assert.Equal(t, StatusInstrumented, express.Status())
+
+	http := modules[1]
+	assert.Equal(t, []string{"createServer"}, http.Wrapped)
+	assert.Equal(t, []int{7, 8}, http.Pids)
+	assert.Equal(t, []int{4, 5, 6}, http.LogIds)
+	require.Equal(t, 2, len(http.Issues))
+	assert.Equal(t, "Can't wrap Response.prototype.end from nonexistent object.", http.Issues[1].Message)
+	assert.Equal(t, StatusPartial, http.Status())
+
+	assert.Equal(t, StatusNotInstrumented, modules[2].Status())
+	assert.Equal(t, "not instrumented", modules[2].Status().String())
+	assert.Equal(t, "MongoDB", modules[3].Framework)
+}
@@ -0,0 +1,233 @@
+// Package lifecycle reconstructs the state machine of an agent session from
+// the lines that trace it, e.g. "Agent state changed from connecting to
+// connected.". A healthy session goes through:
+//
+//	stopped -> starting -> connecting -> connected -> started
+//
+// When the collector responds to a harvest with a restart status, e.g. 409,
+// the agent disconnects and connects again. Such paths, and sessions that
+// never reach the `started` state, are flagged as problems.
+package lifecycle
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// Components are the components whose lines, in addition to the state
+// changes, are needed to detect problems.
+var Components = []string{"collector_api"}
+
+var (
+	matchStateChange = regexp.MustCompile(`^Agent state changed from (\S+) to (\S+?)\.?$`)
+	matchRestart     = regexp.MustCompile(`^Agent endpoint (\S+) returned (\d+) status\. Restarting`)
+	matchShutdown    = regexp.MustCompile(`^Agent endpoint (\S+) returned (\d+) status\. Shutting down`)
+)
+
+// StateStarted is the state of an agent that is collecting data.
+const StateStarted = "started"
+
+// ProblemKind is the kind of an abnormal path through the state machine.
+type ProblemKind int
+
+const (
+	// ProblemNeverStarted indicates that the session never reached the
+	// `started` state.
+	ProblemNeverStarted ProblemKind = iota
+	// ProblemDisconnected indicates that a started agent disconnected.
+	ProblemDisconnected
+	// ProblemRestarted indicates that the collector told the agent to
+	// restart.
+	ProblemRestarted
+	// ProblemShutDown indicates that the collector told the agent to shut
+	// down.
+	ProblemShutDown
+	// ProblemErrored indicates that the agent reached the `errored` state.
+	ProblemErrored
+	// ProblemUnexpectedTransition indicates a transition that does not start
+	// from the state the previous transition ended in, e.g. because lines are
+	// missing from the log.
+	ProblemUnexpectedTransition
+)
+
+func (k ProblemKind) String() string {
+	switch k {
+	case ProblemNeverStarted:
+		return "never started"
+	case ProblemDisconnected:
+		return "disconnected"
+	case ProblemRestarted:
+		return "restarted by collector"
+	case ProblemShutDown:
+		return "shut down by collector"
+	case ProblemErrored:
+		return "errored"
+	}
+	return "unexpected transition"
+}
+
+// Line is the part of a log line that is needed to track the state machine.
+type Line struct {
+	LogId     int
+	Time      time.Time
+	Component string
+	Message   string
+}
+
+// Transition is a single state change.
+type Transition struct {
+	LogId int
+	Time  time.Time
+	From  string
+	To    string
+}
+
+// Phase is the time an agent spent in a single state.
+type Phase struct {
+	State string
+	Start time.Time
+	// End is when the next transition was logged, or, for the last phase,
+	// when the session's last line was logged.
+	End time.Time
+}
+
+func (p *Phase) Duration() time.Duration {
+	return p.End.Sub(p.Start)
+}
+
+// Problem is an abnormal path through the state machine. LogId identifies the
+// line that shows the problem. It is zero for problems that are shown by the
+// absence of a line, e.g. [ProblemNeverStarted].
+type Problem struct {
+	Kind   ProblemKind
+	LogId  int
+	Time   time.Time
+	Detail string
+}
+
+// Lifecycle is the reconstructed state machine of a single session.
+type Lifecycle struct {
+	Transitions []Transition
+	Phases      []Phase
+	Problems    []Problem
+}
+
+// State is the last state the agent reached. It is empty when no transitions
+// were logged.
+func (l *Lifecycle) State() string {
+	if len(l.Transitions) == 0 {
+		return ""
+	}
+	return l.Transitions[len(l.Transitions)-1].To
+}
+
+// LogIds identifies the lines of the transitions and problems, in order.
+func (l *Lifecycle) LogIds() []int {
+	result := make([]int, 0, len(l.Transitions)+len(l.Problems))
+	for _, transition := range l.Transitions {
+		result = append(result, transition.LogId)
+	}
+	for _, problem := range l.Problems {
+		if problem.LogId != 0 {
+			result = append(result, problem.LogId)
+		}
+	}
+	slices.Sort(result)
+	return slices.Compact(result)
+}
+
+// Tracker reconstructs the state machine of a single session. Lines must be
+// added in the order they were logged.
+type Tracker struct {
+	lifecycle Lifecycle
+	started   bool
+}
+
+func New() *Tracker {
+	return &Tracker{
+		lifecycle: Lifecycle{
+			Transitions: make([]Transition, 0),
+			Phases:      make([]Phase, 0),
+			Problems:    make([]Problem, 0),
+		},
+	}
+}
+
+// Add records the line if it is a state change, or a collector response
+// that changes the state. Other lines are ignored.
+func (t *Tracker) Add(line Line) {
+	if match := matchStateChange.FindStringSubmatch(line.Message); match != nil {
+		t.addTransition(line, match[1], match[2])
+		return
+	}
+
+	if line.Component != "collector_api" {
+		return
+	}
+	if match := matchRestart.FindStringSubmatch(line.Message); match != nil {
+		t.addProblem(ProblemRestarted, line, fmt.Sprintf("%s returned %s status", match[1], match[2]))
+		return
+	}
+	if match := matchShutdown.FindStringSubmatch(line.Message); match != nil {
+		t.addProblem(ProblemShutDown, line, fmt.Sprintf("%s returned %s status", match[1], match[2]))
+	}
+}
+
+func (t *Tracker) addTransition(line Line, from string, to string) {
+	l := &t.lifecycle
+	if state := l.State(); state != "" && state != from {
+		t.addProblem(ProblemUnexpectedTransition, line, fmt.Sprintf("from %s while %s", from, state))
+	}
+
+	if len(l.Phases) > 0 {
+		l.Phases[len(l.Phases)-1].End = line.Time
+	}
+	l.Transitions = append(l.Transitions, Transition{LogId: line.LogId, Time: line.Time, From: from, To: to})
+	l.Phases = append(l.Phases, Phase{State: to, Start: line.Time, End: line.Time})
+
+	switch {
+	case to == StateStarted:
+		t.started = true
+	case to == "errored":
+		t.addProblem(ProblemErrored, line, "")
+	case from == StateStarted && to == "disconnected":
+		t.addProblem(ProblemDisconnected, line, "")
+	}
+}
+
+func (t *Tracker) addProblem(kind ProblemKind, line Line, detail string) {
+	t.lifecycle.Problems = append(t.lifecycle.Problems, Problem{
+		Kind:   kind,
+		LogId:  line.LogId,
+		Time:   line.Time,
+		Detail: detail,
+	})
+}
+
+// Lifecycle returns the reconstructed state machine. The last phase lasts
+// until end, the time of the session's last line.
+func (t *Tracker) Lifecycle(end time.Time) Lifecycle {
+	result := t.lifecycle
+	result.Phases = slices.Clone(t.lifecycle.Phases)
+	result.Problems = slices.Clone(t.lifecycle.Problems)
+	if len(result.Phases) > 0 && end.After(result.Phases[len(result.Phases)-1].Start) {
+		result.Phases[len(result.Phases)-1].End = end
+	}
+	if t.started == false {
+		result.Problems = append(result.Problems, Problem{
+			Kind:   ProblemNeverStarted,
+			Time:   end,
+			Detail: "last state " + stateLabel(result.State()),
+		})
+	}
+	return result
+}
+
+func stateLabel(state string) string {
+	if state == "" {
+		return "unknown"
+	}
+	return state
+}
@@ -0,0 +1,72 @@
+package lifecycle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Tracker(t *testing.T) {
+	start := time.Date(2025, 3, 3, 15, 35, 0, 0, time.UTC)
+	at := func(millis int) time.Time {
+		return start.Add(time.Duration(millis) * time.Millisecond)
+	}
+	changed := func(from string, to string) string {
+		return "Agent state changed from " + from + " to " + to + "."
+	}
+
+	t.Run("reconstructs the phases of a healthy session", func(t *testing.T) {
+		tracker := New()
+		tracker.Add(Line{LogId: 1, Time: at(0), Message: changed("stopped", "starting")})
+		tracker.Add(Line{LogId: 2, Time: at(10), Message: changed("starting", "connecting")})
+		tracker.Add(Line{LogId: 3, Time: at(15), Message: "Connecting to collector"})
+		tracker.Add(Line{LogId: 4, Time: at(1_510), Message: changed("connecting", "connected")})
+		tracker.Add(Line{LogId: 5, Time: at(1_515), Message: changed("connected", "started")})
+
+		lifecycle := tracker.Lifecycle(at(60_000))
+		assert.Equal(t, "started", lifecycle.State())
+		assert.Empty(t, lifecycle.Problems)
+		assert.Equal(t, []int{1, 2, 4, 5}, lifecycle.LogIds())
+		require.Equal(t, 4, len(lifecycle.Phases))
+		assert.Equal(t, Phase{State: "starting", Start: at(0), End: at(10)}, lifecycle.Phases[0])
+		assert.Equal(t, 1_500*time.Millisecond, lifecycle.Phases[1].Duration())
+		assert.Equal(t, 58_485*time.Millisecond, lifecycle.Phases[3].Duration())
+	})
+
+	t.Run("flags sessions that never started", func(t *testing.T) {
+		tracker := New()
+		tracker.Add(Line{LogId: 1, Time: at(0), Message: changed("stopped", "starting")})
+		tracker.Add(Line{LogId: 2, Time: at(10), Message: changed("starting", "connecting")})
+
+		lifecycle := tracker.Lifecycle(at(300))
+		require.Equal(t, 1, len(lifecycle.Problems))
+		assert.Equal(t, Problem{Kind: ProblemNeverStarted, Time: at(300), Detail: "last state connecting"}, lifecycle.Problems[0])
+		assert.Equal(t, "never started", lifecycle.Problems[0].Kind.String())
+		assert.Equal(t, 290*time.Millisecond, lifecycle.Phases[1].Duration())
+
+		lifecycle = New().Lifecycle(at(0))
+		assert.Equal(t, "last state unknown", lifecycle.Problems[0].Detail)
+	})
+
+	t.Run("flags restarts by the collector", func(t *testing.T) {
+		tracker := New()
+		tracker.Add(Line{LogId: 1, Time: at(0), Message: changed("connected", "started")})
+		tracker.Add(Line{LogId: 2, Time: at(10), Component: "collector_api", Message: "Agent endpoint metric_data returned 409 status. Restarting."})
+		tracker.Add(Line{LogId: 3, Time: at(11), Component: "collector_api", Message: "Restarting collector."})
+		tracker.Add(Line{LogId: 4, Time: at(20), Message: changed("started", "disconnected")})
+		tracker.Add(Line{LogId: 5, Time: at(21), Message: changed("disconnected", "connecting")})
+		tracker.Add(Line{LogId: 6, Time: at(30), Message: changed("connected", "started")})
+
+		lifecycle := tracker.Lifecycle(at(40))
+		kinds := make([]ProblemKind, 0)
+		for _, problem := range lifecycle.Problems {
+			kinds = append(kinds, problem.Kind)
+		}
+		assert.Equal(t, []ProblemKind{ProblemRestarted, ProblemDisconnected, ProblemUnexpectedTransition}, kinds)
+		assert.Equal(t, "metric_data returned 409 status", lifecycle.Problems[0].Detail)
+		assert.Equal(t, "from connected while connecting", lifecycle.Problems[2].Detail)
+		assert.Equal(t, []int{1, 2, 4, 5, 6}, lifecycle.LogIds())
+	})
+}
@@ -0,0 +1,17 @@
+package misc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ConfigDir returns the directory where the application stores user level
+// files, e.g. `~/.config/nrlv` on Linux.
+func ConfigDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine user config directory: %w", err)
+	}
+	return filepath.Join(configDir, "nrlv"), nil
+}
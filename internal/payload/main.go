@@ -0,0 +1,362 @@
+// Package payload decodes the collector payloads logged by the
+// `remote_method` component. The `data` attribute of "Calling" lines, and the
+// `body` attribute of "Posting" lines, hold the payload as serialized JSON.
+// When the agent compresses payloads, they are gzip or deflate compressed and
+// logged either base64 encoded or as a serialized Node.js `Buffer`.
+//
+// Payloads of the protocol 17 data collection methods are parsed into typed
+// structures, see [Parse].
+package payload
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedMethod is returned by [Parse] for methods whose payloads are
+// not parsed, e.g. `connect`.
+var ErrUnsupportedMethod = errors.New("unsupported collector method")
+
+// ErrPayloadTooLarge is returned by [Decode] when a compressed payload
+// inflates to more than [MaxInflatedSize] bytes.
+var ErrPayloadTooLarge = errors.New("inflated payload is too large")
+
+// MaxInflatedSize is the largest number of bytes a compressed payload is
+// inflated to. Logged lines are limited to 1MiB, but a highly compressed
+// payload of that size would inflate to a gigabyte or more.
+const MaxInflatedSize = 32 * 1_024 * 1_024
+
+// Decode returns the JSON document held by a payload attribute, inflating
+// and decoding it as necessary.
+func Decode(value string) ([]byte, error) {
+	trimmed := strings.TrimSpace(value)
+	if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+		if raw, isBuffer := nodeBuffer(trimmed); isBuffer == true {
+			return inflate(raw)
+		}
+		if json.Valid([]byte(trimmed)) == false {
+			return nil, errors.New("payload is not valid JSON")
+		}
+		return []byte(trimmed), nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("payload is neither JSON nor base64 encoded: %w", err)
+	}
+	return inflate(raw)
+}
+
+// nodeBuffer recognizes a Node.js `Buffer` serialized to JSON, i.e.
+// `{"type":"Buffer","data":[31,139,...]}`, and returns its bytes.
+func nodeBuffer(value string) ([]byte, bool) {
+	var buffer struct {
+		Type string `json:"type"`
+		Data []int  `json:"data"`
+	}
+	err := json.Unmarshal([]byte(value), &buffer)
+	if err != nil || buffer.Type != "Buffer" {
+		return nil, false
+	}
+
+	result := make([]byte, len(buffer.Data))
+	for i, b := range buffer.Data {
+		if b < 0 || b > math.MaxUint8 {
+			return nil, false
+		}
+		result[i] = byte(b)
+	}
+	return result, true
+}
+
+// inflate detects the compression of the bytes by their header, and returns
+// the decompressed JSON document.
+func inflate(raw []byte) ([]byte, error) {
+	var reader io.Reader
+	var err error
+	switch {
+	case len(raw) >= 2 && raw[0] == 0x1f && raw[1] == 0x8b:
+		reader, err = gzip.NewReader(bytes.NewReader(raw))
+	case len(raw) >= 2 && raw[0]&0x0f == 8 && (uint16(raw[0])<<8|uint16(raw[1]))%31 == 0:
+		reader, err = zlib.NewReader(bytes.NewReader(raw))
+	case json.Valid(raw):
+		return raw, nil
+	default:
+		reader = flate.NewReader(bytes.NewReader(raw))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compressed payload: %w", err)
+	}
+
+	result, err := io.ReadAll(io.LimitReader(reader, MaxInflatedSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inflate payload: %w", err)
+	}
+	if len(result) > MaxInflatedSize {
+		return nil, fmt.Errorf("%w: truncated at %d bytes", ErrPayloadTooLarge, MaxInflatedSize)
+	}
+	if json.Valid(result) == false {
+		return nil, errors.New("inflated payload is not valid JSON")
+	}
+	return result, nil
+}
+
+// Payload is one of [*MetricData], [*EventData], or [*LogData].
+type Payload interface {
+	// Method is the collector method the payload was sent to.
+	Method() string
+}
+
+// Metric is a single timeslice metric. Apdex metrics store the number of
+// satisfying, tolerating, and frustrating requests in the CallCount, Total,
+// and Exclusive fields respectively.
+type Metric struct {
+	Name         string
+	Scope        string
+	CallCount    float64
+	Total        float64
+	Exclusive    float64
+	Min          float64
+	Max          float64
+	SumOfSquares float64
+}
+
+// MetricData is the payload of `metric_data`:
+// `[run_id, start, end, [[{name, scope}, [count, total, ...]], ...]]`.
+type MetricData struct {
+	RunId   string
+	Start   time.Time
+	End     time.Time
+	Metrics []Metric
+}
+
+func (m *MetricData) Method() string {
+	return "metric_data"
+}
+
+// Event is a single event, e.g. a span. Its attributes are grouped by their
+// origin.
+type Event struct {
+	Intrinsics      map[string]any
+	UserAttributes  map[string]any
+	AgentAttributes map[string]any
+}
+
+// Type is the event's `type` intrinsic, e.g. "Span".
+func (e *Event) Type() string {
+	value, _ := e.Intrinsics["type"].(string)
+	return value
+}
+
+// Timestamp is the event's `timestamp` intrinsic.
+func (e *Event) Timestamp() time.Time {
+	return unixMillis(e.Intrinsics["timestamp"])
+}
+
+// EventData is the payload of the event methods, e.g. `span_event_data`:
+// `[run_id, {reservoir_size, events_seen}, [[intrinsics, user, agent], ...]]`.
+// Custom events do not always include the reservoir metadata, and only
+// have intrinsics and user attributes.
+type EventData struct {
+	RunId         string
+	ReservoirSize int
+	EventsSeen    int
+	Events        []Event
+
+	method string
+}
+
+func (e *EventData) Method() string {
+	return e.method
+}
+
+// LogRecord is a single forwarded application log line.
+type LogRecord struct {
+	Timestamp  time.Time
+	Level      string
+	Message    string
+	Attributes map[string]any
+}
+
+// LogData is the payload of `log_event_data`:
+// `[{common: {attributes: {...}}, logs: [{timestamp, level, message, ...}]}]`.
+type LogData struct {
+	Common map[string]any
+	Logs   []LogRecord
+}
+
+func (l *LogData) Method() string {
+	return "log_event_data"
+}
+
+// Parse parses the decoded payload of the collector method.
+// [ErrUnsupportedMethod] is returned for methods that are not parsed.
+func Parse(method string, document []byte) (Payload, error) {
+	switch method {
+	case "metric_data":
+		return parseMetricData(document)
+	case "analytic_event_data", "span_event_data", "error_event_data", "custom_event_data":
+		return parseEventData(method, document)
+	case "log_event_data":
+		return parseLogData(document)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedMethod, method)
+}
+
+func parseMetricData(document []byte) (*MetricData, error) {
+	var envelope []json.RawMessage
+	err := json.Unmarshal(document, &envelope)
+	if err != nil || len(envelope) != 4 {
+		return nil, errors.New("metric_data payload is not a 4 element array")
+	}
+
+	result := &MetricData{}
+	var start, end float64
+	var metrics [][]json.RawMessage
+	err = errors.Join(
+		json.Unmarshal(envelope[0], &result.RunId),
+		json.Unmarshal(envelope[1], &start),
+		json.Unmarshal(envelope[2], &end),
+		json.Unmarshal(envelope[3], &metrics),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metric_data payload: %w", err)
+	}
+	result.Start = unixSeconds(start)
+	result.End = unixSeconds(end)
+
+	result.Metrics = make([]Metric, 0, len(metrics))
+	for i, entry := range metrics {
+		if len(entry) != 2 {
+			return nil, fmt.Errorf("metric %d is not a 2 element array", i)
+		}
+		var spec struct {
+			Name  string `json:"name"`
+			Scope string `json:"scope"`
+		}
+		var values []float64
+		err = errors.Join(json.Unmarshal(entry[0], &spec), json.Unmarshal(entry[1], &values))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse metric %d: %w", i, err)
+		}
+		values = append(values, make([]float64, max(6-len(values), 0))...)
+		result.Metrics = append(result.Metrics, Metric{
+			Name:         spec.Name,
+			Scope:        spec.Scope,
+			CallCount:    values[0],
+			Total:        values[1],
+			Exclusive:    values[2],
+			Min:          values[3],
+			Max:          values[4],
+			SumOfSquares: values[5],
+		})
+	}
+	return result, nil
+}
+
+func parseEventData(method string, document []byte) (*EventData, error) {
+	var envelope []json.RawMessage
+	err := json.Unmarshal(document, &envelope)
+	if err != nil || len(envelope) < 2 {
+		return nil, fmt.Errorf("%s payload is not an array", method)
+	}
+
+	result := &EventData{method: method}
+	err = json.Unmarshal(envelope[0], &result.RunId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s run id: %w", method, err)
+	}
+
+	events := envelope[1]
+	if len(envelope) > 2 {
+		var metadata struct {
+			ReservoirSize int `json:"reservoir_size"`
+			EventsSeen    int `json:"events_seen"`
+		}
+		err = json.Unmarshal(envelope[1], &metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s metadata: %w", method, err)
+		}
+		result.ReservoirSize = metadata.ReservoirSize
+		result.EventsSeen = metadata.EventsSeen
+		events = envelope[2]
+	}
+
+	var entries [][]map[string]any
+	err = json.Unmarshal(events, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s events: %w", method, err)
+	}
+	result.Events = make([]Event, 0, len(entries))
+	for _, entry := range entries {
+		entry = append(entry, make([]map[string]any, max(3-len(entry), 0))...)
+		result.Events = append(result.Events, Event{
+			Intrinsics:      entry[0],
+			UserAttributes:  entry[1],
+			AgentAttributes: entry[2],
+		})
+	}
+	if result.EventsSeen == 0 {
+		result.EventsSeen = len(result.Events)
+	}
+	return result, nil
+}
+
+func parseLogData(document []byte) (*LogData, error) {
+	var envelope []struct {
+		Common struct {
+			Attributes map[string]any `json:"attributes"`
+		} `json:"common"`
+		Logs []map[string]any `json:"logs"`
+	}
+	err := json.Unmarshal(document, &envelope)
+	if err != nil || len(envelope) == 0 {
+		return nil, errors.New("log_event_data payload is not an array of log groups")
+	}
+
+	result := &LogData{
+		Common: envelope[0].Common.Attributes,
+		Logs:   make([]LogRecord, 0),
+	}
+	for _, group := range envelope {
+		for _, log := range group.Logs {
+			record := LogRecord{
+				Timestamp:  unixMillis(log["timestamp"]),
+				Attributes: make(map[string]any),
+			}
+			record.Level, _ = log["level"].(string)
+			record.Message, _ = log["message"].(string)
+			for key, value := range log {
+				switch key {
+				case "timestamp", "level", "message":
+					continue
+				}
+				record.Attributes[key] = value
+			}
+			result.Logs = append(result.Logs, record)
+		}
+	}
+	return result, nil
+}
+
+func unixSeconds(seconds float64) time.Time {
+	return time.UnixMilli(int64(math.Round(seconds * 1000))).UTC()
+}
+
+func unixMillis(value any) time.Time {
+	millis, isNumber := value.(float64)
+	if isNumber == false {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(millis)).UTC()
+}
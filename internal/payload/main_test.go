@@ -0,0 +1,153 @@
+package payload
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const metricData = `["redacted_run_id",1741284484.104,1741284487.037,[[{"name":"Supportability/Nodejs/Version/18"},[1,0,0,0,0,0]],[{"name":"Datastore/operation/Redis/get","scope":"WebTransaction/Expressjs/GET//"},[2,0.004,0.003,0.001,0.003,0.00001]]]]`
+
+func compress(t *testing.T, writer func(io.Writer) io.WriteCloser, data string) []byte {
+	buffer := &bytes.Buffer{}
+	w := writer(buffer)
+	_, err := w.Write([]byte(data))
+	require.Nil(t, err)
+	require.Nil(t, w.Close())
+	return buffer.Bytes()
+}
+
+func Test_Decode(t *testing.T) {
+	gzipped := compress(t, func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }, metricData)
+	deflated := compress(t, func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }, metricData)
+	raw := compress(t, func(w io.Writer) io.WriteCloser {
+		writer, _ := flate.NewWriter(w, flate.DefaultCompression)
+		return writer
+	}, metricData)
+
+	ints := make([]int, len(gzipped))
+	for i, b := range gzipped {
+		ints[i] = int(b)
+	}
+	buffer, err := json.Marshal(map[string]any{"type": "Buffer", "data": ints})
+	require.Nil(t, err)
+
+	tests := map[string]string{
+		"plain JSON":        "  " + metricData,
+		"base64 gzip":       base64.StdEncoding.EncodeToString(gzipped),
+		"base64 zlib":       base64.StdEncoding.EncodeToString(deflated),
+		"base64 raw":        base64.StdEncoding.EncodeToString(raw),
+		"base64 JSON":       base64.StdEncoding.EncodeToString([]byte(metricData)),
+		"serialized Buffer": string(buffer),
+	}
+	for name, value := range tests {
+		t.Run(name, func(t *testing.T) {
+			decoded, err := Decode(value)
+			require.Nil(t, err)
+			assert.JSONEq(t, metricData, string(decoded))
+		})
+	}
+
+	t.Run("rejects other values", func(t *testing.T) {
+		_, err := Decode("not a payload")
+		assert.NotNil(t, err)
+		_, err = Decode(`[1, 2`)
+		assert.NotNil(t, err)
+		_, err = Decode(base64.StdEncoding.EncodeToString([]byte("plain text")))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("refuses to inflate payloads beyond the limit", func(t *testing.T) {
+		padding := strings.Repeat(" ", MaxInflatedSize)
+		bomb := compress(t, func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }, "["+padding+"]")
+		_, err := Decode(base64.StdEncoding.EncodeToString(bomb))
+		assert.ErrorIs(t, err, ErrPayloadTooLarge)
+	})
+}
+
+func Test_Parse(t *testing.T) {
+	t.Run("parses metric data", func(t *testing.T) {
+		parsed, err := Parse("metric_data", []byte(metricData))
+		require.Nil(t, err)
+		assert.Equal(t, &MetricData{
+			RunId: "redacted_run_id",
+			Start: time.Date(2025, 3, 6, 18, 8, 4, 104_000_000, time.UTC),
+			End:   time.Date(2025, 3, 6, 18, 8, 7, 37_000_000, time.UTC),
+			Metrics: []Metric{
+				{Name: "Supportability/Nodejs/Version/18", CallCount: 1},
+				{
+					Name:         "Datastore/operation/Redis/get",
+					Scope:        "WebTransaction/Expressjs/GET//",
+					CallCount:    2,
+					Total:        0.004,
+					Exclusive:    0.003,
+					Min:          0.001,
+					Max:          0.003,
+					SumOfSquares: 0.00001,
+				},
+			},
+		}, parsed)
+		assert.Equal(t, "metric_data", parsed.Method())
+	})
+
+	t.Run("parses event data", func(t *testing.T) {
+		document := `["run",{"reservoir_size":2000,"events_seen":3},[[{"type":"Span","timestamp":1741284603995,"name":"GET /"},{"user":"a"},{"http.statusCode":200}]]]`
+		parsed, err := Parse("span_event_data", []byte(document))
+		require.Nil(t, err)
+		events := parsed.(*EventData)
+		assert.Equal(t, "span_event_data", events.Method())
+		assert.Equal(t, "run", events.RunId)
+		assert.Equal(t, 2000, events.ReservoirSize)
+		assert.Equal(t, 3, events.EventsSeen)
+		require.Equal(t, 1, len(events.Events))
+		assert.Equal(t, "Span", events.Events[0].Type())
+		assert.Equal(t, time.Date(2025, 3, 6, 18, 10, 3, 995_000_000, time.UTC), events.Events[0].Timestamp())
+		assert.Equal(t, map[string]any{"user": "a"}, events.Events[0].UserAttributes)
+		assert.Equal(t, map[string]any{"http.statusCode": float64(200)}, events.Events[0].AgentAttributes)
+	})
+
+	t.Run("parses custom events without metadata", func(t *testing.T) {
+		document := `["run",[[{"type":"Purchase","timestamp":1741284603995},{"amount":3}],[{"type":"Purchase"},{}]]]`
+		parsed, err := Parse("custom_event_data", []byte(document))
+		require.Nil(t, err)
+		events := parsed.(*EventData)
+		assert.Equal(t, 2, len(events.Events))
+		assert.Equal(t, 2, events.EventsSeen)
+		assert.Equal(t, map[string]any{"amount": float64(3)}, events.Events[0].UserAttributes)
+		assert.Nil(t, events.Events[0].AgentAttributes)
+	})
+
+	t.Run("parses log data", func(t *testing.T) {
+		document := `[{"common":{"attributes":{"entity.name":"test-app"}},"logs":[{"timestamp":1741284603995,"level":"info","message":"hello","trace.id":"abc"}]}]`
+		parsed, err := Parse("log_event_data", []byte(document))
+		require.Nil(t, err)
+		assert.Equal(t, &LogData{
+			Common: map[string]any{"entity.name": "test-app"},
+			Logs: []LogRecord{{
+				Timestamp:  time.Date(2025, 3, 6, 18, 10, 3, 995_000_000, time.UTC),
+				Level:      "info",
+				Message:    "hello",
+				Attributes: map[string]any{"trace.id": "abc"},
+			}},
+		}, parsed)
+	})
+
+	t.Run("rejects unsupported methods and malformed payloads", func(t *testing.T) {
+		_, err := Parse("connect", []byte(`[{}]`))
+		assert.ErrorIs(t, err, ErrUnsupportedMethod)
+		_, err = Parse("metric_data", []byte(`["run"]`))
+		assert.NotNil(t, err)
+		_, err = Parse("span_event_data", []byte(`{}`))
+		assert.NotNil(t, err)
+	})
+}
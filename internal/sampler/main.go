@@ -0,0 +1,267 @@
+// Package sampler extracts time series from the lines of the agent's `sampler`
+// component. The sampler logs the memory usage of the process every 5 seconds:
+//
+//	{"msg":"Recorded memory","component":"sampler","rss":84197376,"heapTotal":34078720,...}
+//
+// It also logs the metrics that it records, either as a plain number or as a
+// quoted JSON object of metric statistics:
+//
+//	Recorded metric CPU/User/Utilization: 0.002303134450678284
+//	Recorded metric GC/Scavenge: "{\"total\":0.0011,\"min\":0.0004,...}"
+//
+// The `total` of a metric's statistics is used as its value.
+package sampler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Component is the component whose lines hold samples.
+const Component = "sampler"
+
+// MemoryMessage is the message of the lines that hold memory samples.
+const MemoryMessage = "Recorded memory"
+
+// MemoryFields are the attributes of memory lines, in the order their series
+// are listed.
+var MemoryFields = []string{"rss", "heapTotal", "heapUsed", "external", "arrayBuffers"}
+
+// memoryPrefix prefixes the names of memory series.
+const memoryPrefix = "Memory/"
+
+var matchMetric = regexp.MustCompile(`^Recorded metric (.+?): (.+)$`)
+
+// Unit is the unit of a series' values.
+type Unit int
+
+const (
+	UnitNone Unit = iota
+	UnitBytes
+	UnitSeconds
+	// UnitRatio is a fraction of the available time, e.g. CPU utilization.
+	UnitRatio
+)
+
+// Format renders the value in the unit, e.g. "80.3 MiB" or "1.5ms".
+func (u Unit) Format(value float64) string {
+	if math.IsNaN(value) {
+		return "-"
+	}
+	switch u {
+	case UnitBytes:
+		units := []string{"B", "KiB", "MiB", "GiB"}
+		i := 0
+		for math.Abs(value) >= 1024 && i < len(units)-1 {
+			value /= 1024
+			i++
+		}
+		return strconv.FormatFloat(value, 'f', 1, 64) + " " + units[i]
+	case UnitSeconds:
+		return time.Duration(value * float64(time.Second)).Round(time.Microsecond).String()
+	case UnitRatio:
+		return strconv.FormatFloat(value*100, 'f', 2, 64) + "%"
+	}
+	return strconv.FormatFloat(value, 'g', 4, 64)
+}
+
+// metricUnit guesses the unit of a metric from its name.
+func metricUnit(name string) Unit {
+	switch {
+	case strings.HasSuffix(name, "/Utilization"):
+		return UnitRatio
+	case strings.HasSuffix(name, " Time"),
+		strings.HasPrefix(name, "GC/"),
+		strings.HasPrefix(name, "Nodejs/EventLoop/"):
+		return UnitSeconds
+	}
+	return UnitNone
+}
+
+// Line is the part of a log line that is needed to extract samples.
+type Line struct {
+	LogId   int
+	Time    time.Time
+	Message string
+
+	// Memory holds the memory attributes of the line, see [MemoryFields].
+	Memory map[string]float64
+}
+
+// Point is a single sample.
+type Point struct {
+	LogId int
+	Time  time.Time
+	Value float64
+}
+
+// Series is the samples of a single measurement, in the order they were
+// logged.
+type Series struct {
+	Name   string
+	Unit   Unit
+	Points []Point
+}
+
+// Range returns the smallest and largest values of the series.
+func (s *Series) Range() (float64, float64) {
+	if len(s.Points) == 0 {
+		return 0, 0
+	}
+	low, high := s.Points[0].Value, s.Points[0].Value
+	for _, point := range s.Points[1:] {
+		low = min(low, point.Value)
+		high = max(high, point.Value)
+	}
+	return low, high
+}
+
+// At returns the value of the series at the given time, i.e. the value of the
+// latest point that was sampled at or before it. It returns NaN before the
+// first point.
+func (s *Series) At(at time.Time) float64 {
+	i, _ := slices.BinarySearchFunc(s.Points, at, func(p Point, t time.Time) int {
+		if p.Time.After(t) {
+			return 1
+		}
+		return -1
+	})
+	if i == 0 {
+		return math.NaN()
+	}
+	return s.Points[i-1].Value
+}
+
+// Resample reduces the series to the given number of equally sized buckets
+// between start and end. Each bucket holds the mean of its points. Buckets
+// without points repeat the previous bucket's value, so that the series reads
+// as a continuous line. Buckets before the first point, and after the last,
+// are NaN.
+func (s *Series) Resample(start time.Time, end time.Time, buckets int) []float64 {
+	result := make([]float64, buckets)
+	sums := make([]float64, buckets)
+	counts := make([]int, buckets)
+	span := end.Sub(start)
+	first, last := buckets, -1
+	for _, point := range s.Points {
+		i := 0
+		if span > 0 {
+			i = int(float64(point.Time.Sub(start)) / float64(span) * float64(buckets))
+		}
+		if i < 0 || i > buckets {
+			continue
+		}
+		// The end is included in the last bucket.
+		i = min(i, buckets-1)
+		sums[i] += point.Value
+		counts[i]++
+		first = min(first, i)
+		last = max(last, i)
+	}
+
+	for i := range result {
+		switch {
+		case i < first || i > last:
+			result[i] = math.NaN()
+		case counts[i] > 0:
+			result[i] = sums[i] / float64(counts[i])
+		default:
+			result[i] = result[i-1]
+		}
+	}
+	return result
+}
+
+// Extractor collects the samples of the lines into series.
+type Extractor struct {
+	series map[string]*Series
+}
+
+func New() *Extractor {
+	return &Extractor{series: make(map[string]*Series)}
+}
+
+// Add extracts the samples of a line. Lines that hold no samples are ignored.
+func (e *Extractor) Add(line Line) {
+	if line.Message == MemoryMessage {
+		for _, field := range MemoryFields {
+			if value, found := line.Memory[field]; found == true {
+				e.add(memoryPrefix+field, UnitBytes, Point{LogId: line.LogId, Time: line.Time, Value: value})
+			}
+		}
+		return
+	}
+
+	matches := matchMetric.FindStringSubmatch(line.Message)
+	if matches == nil {
+		return
+	}
+	value, err := metricValue(matches[2])
+	if err != nil {
+		return
+	}
+	e.add(matches[1], metricUnit(matches[1]), Point{LogId: line.LogId, Time: line.Time, Value: value})
+}
+
+func (e *Extractor) add(name string, unit Unit, point Point) {
+	series := e.series[name]
+	if series == nil {
+		series = &Series{Name: name, Unit: unit}
+		e.series[name] = series
+	}
+	series.Points = append(series.Points, point)
+}
+
+// metricValue parses the logged value of a metric, which is either a number or
+// a quoted JSON object of statistics.
+func metricValue(text string) (float64, error) {
+	if strings.HasPrefix(text, `"`) == false {
+		return strconv.ParseFloat(text, 64)
+	}
+
+	var document string
+	err := json.Unmarshal([]byte(text), &document)
+	if err != nil {
+		return 0, err
+	}
+	var stats struct {
+		Total *float64 `json:"total"`
+	}
+	err = json.Unmarshal([]byte(document), &stats)
+	if err != nil {
+		return 0, err
+	}
+	if stats.Total == nil {
+		return 0, fmt.Errorf("metric statistics have no total: %s", document)
+	}
+	return *stats.Total, nil
+}
+
+// Series returns the extracted series. Memory series are listed first, in
+// the order of [MemoryFields], followed by the metrics ordered by name.
+func (e *Extractor) Series() []Series {
+	result := make([]Series, 0, len(e.series))
+	for _, field := range MemoryFields {
+		if series, found := e.series[memoryPrefix+field]; found == true {
+			result = append(result, *series)
+		}
+	}
+
+	names := make([]string, 0, len(e.series))
+	for name := range e.series {
+		if strings.HasPrefix(name, memoryPrefix) == false {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		result = append(result, *e.series[name])
+	}
+	return result
+}
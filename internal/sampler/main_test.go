@@ -0,0 +1,75 @@
+package sampler
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Extractor(t *testing.T) {
+	start := time.Date(2025, 3, 6, 18, 8, 9, 0, time.UTC)
+	at := func(seconds int) time.Time {
+		return start.Add(time.Duration(seconds) * time.Second)
+	}
+
+	extractor := New()
+	extractor.Add(Line{LogId: 1, Time: at(0), Message: MemoryMessage, Memory: map[string]float64{"rss": 100, "heapUsed": 20}})
+	extractor.Add(Line{LogId: 2, Time: at(5), Message: MemoryMessage, Memory: map[string]float64{"rss": 120, "heapUsed": 30}})
+	extractor.Add(Line{LogId: 3, Time: at(10), Message: `Recorded metric GC/Scavenge: "{\"total\":0.0011,\"min\":0.0004,\"count\":2}"`})
+	extractor.Add(Line{LogId: 4, Time: at(10), Message: "Recorded metric CPU/User/Utilization: 0.0023"})
+	extractor.Add(Line{LogId: 5, Time: at(10), Message: `Recorded metric Broken: "{\"min\":1}"`})
+	extractor.Add(Line{LogId: 6, Time: at(10), Message: "Sampling CPU usage"})
+
+	series := extractor.Series()
+	names := make([]string, 0, len(series))
+	for _, s := range series {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"Memory/rss", "Memory/heapUsed", "CPU/User/Utilization", "GC/Scavenge"}, names)
+
+	assert.Equal(t, UnitBytes, series[0].Unit)
+	assert.Equal(t, []Point{{LogId: 1, Time: at(0), Value: 100}, {LogId: 2, Time: at(5), Value: 120}}, series[0].Points)
+	assert.Equal(t, UnitRatio, series[2].Unit)
+	assert.Equal(t, 0.0023, series[2].Points[0].Value)
+	assert.Equal(t, UnitSeconds, series[3].Unit)
+	assert.Equal(t, 0.0011, series[3].Points[0].Value)
+}
+
+func Test_Series(t *testing.T) {
+	start := time.Date(2025, 3, 6, 18, 8, 9, 0, time.UTC)
+	at := func(seconds int) time.Time {
+		return start.Add(time.Duration(seconds) * time.Second)
+	}
+	series := Series{Name: "Memory/rss", Unit: UnitBytes, Points: []Point{
+		{LogId: 1, Time: at(10), Value: 10},
+		{LogId: 2, Time: at(12), Value: 20},
+		{LogId: 3, Time: at(40), Value: 40},
+	}}
+
+	low, high := series.Range()
+	assert.Equal(t, 10.0, low)
+	assert.Equal(t, 40.0, high)
+
+	assert.True(t, math.IsNaN(series.At(at(9))))
+	assert.Equal(t, 10.0, series.At(at(10)))
+	assert.Equal(t, 20.0, series.At(at(39)))
+	assert.Equal(t, 40.0, series.At(at(100)))
+
+	values := series.Resample(at(0), at(50), 5)
+	require.Equal(t, 5, len(values))
+	assert.True(t, math.IsNaN(values[0]))
+	assert.Equal(t, []float64{15, 15, 15, 40}, values[1:])
+	values = series.Resample(at(10), at(40), 3)
+	assert.Equal(t, []float64{15, 15, 40}, values)
+}
+
+func Test_Unit(t *testing.T) {
+	assert.Equal(t, "80.3 MiB", UnitBytes.Format(84197376))
+	assert.Equal(t, "512.0 B", UnitBytes.Format(512))
+	assert.Equal(t, "1.135ms", UnitSeconds.Format(0.001135333))
+	assert.Equal(t, "0.23%", UnitRatio.Format(0.0023))
+	assert.Equal(t, "-", UnitNone.Format(math.NaN()))
+}
@@ -0,0 +1,116 @@
+// Package session splits the lines of a log into agent sessions. A session
+// is the lifetime of a single agent process. A log often holds many sessions,
+// e.g. when an application is restarted, or when several processes share a
+// log file. Each session starts with the agent's startup banner:
+//
+//	Using New Relic for Node.js. Agent version: 12.14.0; Node version: v20.18.3.
+//
+// Lines are assigned to sessions by their pid, so that the sessions of
+// processes that log at the same time are kept apart.
+package session
+
+import (
+	"regexp"
+	"time"
+)
+
+var matchBanner = regexp.MustCompile(`^Using New Relic for Node\.js\. Agent version: ([^;]+); Node version: (\S+?)\.?$`)
+
+// Line is the part of a log line that is needed to detect sessions.
+type Line struct {
+	LogId    int
+	Time     time.Time
+	Pid      int
+	Hostname string
+	Message  string
+}
+
+// Session is the lifetime of a single agent process.
+type Session struct {
+	Id       int
+	Pid      int
+	Hostname string
+
+	// AgentVersion and NodeVersion are taken from the startup banner. They
+	// are empty for sessions whose banner is not part of the log, e.g. when
+	// the log was rotated while the process was running.
+	AgentVersion string
+	NodeVersion  string
+
+	// Start and End are the times of the first and last lines of the session.
+	Start time.Time
+	End   time.Time
+	Lines int
+}
+
+// Duration is the time between the first and last lines of the session.
+func (s *Session) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Segmenter assigns lines to sessions. Lines must be added in the order they
+// were logged.
+type Segmenter struct {
+	sessions []*Session
+	// current holds the session of each process.
+	current map[int]*Session
+	// latest is the session that the most recent line was assigned to.
+	latest *Session
+}
+
+func New() *Segmenter {
+	return &Segmenter{
+		sessions: make([]*Session, 0),
+		current:  make(map[int]*Session),
+	}
+}
+
+// Add assigns the line to a session, and returns the session's id. A
+// startup banner starts a new session, even when the pid has been seen
+// before, because pids are reused. The first line of a process without a
+// banner starts a session without versions, which the banner completes if it
+// follows, e.g. the configuration file is logged before the banner. Lines
+// without a pid belong to the session of the most recent line.
+func (s *Segmenter) Add(line Line) int {
+	session := s.current[line.Pid]
+	if line.Pid == 0 && s.latest != nil {
+		session = s.latest
+	}
+
+	match := matchBanner.FindStringSubmatch(line.Message)
+	if session == nil || (match != nil && session.AgentVersion != "") {
+		session = &Session{
+			Id:    len(s.sessions) + 1,
+			Pid:   line.Pid,
+			Start: line.Time,
+		}
+		s.sessions = append(s.sessions, session)
+		s.current[line.Pid] = session
+	}
+	if match != nil {
+		session.AgentVersion = match[1]
+		session.NodeVersion = match[2]
+	}
+
+	if session.Hostname == "" {
+		session.Hostname = line.Hostname
+	}
+	if line.Time.Before(session.Start) {
+		session.Start = line.Time
+	}
+	if line.Time.After(session.End) {
+		session.End = line.Time
+	}
+	session.Lines += 1
+	s.latest = session
+	return session.Id
+}
+
+// Sessions returns the sessions in the order they were started.
+func (s *Segmenter) Sessions() []Session {
+	result := make([]Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		result = append(result, *session)
+	}
+	return result
+}
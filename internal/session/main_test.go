@@ -0,0 +1,68 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Segmenter(t *testing.T) {
+	start := time.Date(2025, 2, 28, 18, 9, 54, 0, time.UTC)
+	at := func(seconds int) time.Time {
+		return start.Add(time.Duration(seconds) * time.Second)
+	}
+	banner := "Using New Relic for Node.js. Agent version: 12.14.0; Node version: v20.18.3."
+
+	t.Run("starts a session at each banner", func(t *testing.T) {
+		segmenter := New()
+		assert.Equal(t, 1, segmenter.Add(Line{LogId: 1, Time: at(0), Pid: 10, Hostname: "web-1", Message: banner}))
+		assert.Equal(t, 1, segmenter.Add(Line{LogId: 2, Time: at(1), Pid: 10, Message: "Starting agent."}))
+		assert.Equal(t, 2, segmenter.Add(Line{LogId: 3, Time: at(2), Pid: 20, Message: "Agent version: 12.13.0; Node version: v18.20.1."}))
+		assert.Equal(t, 1, segmenter.Add(Line{LogId: 4, Time: at(3), Pid: 10, Message: "Recorded memory"}))
+		assert.Equal(t, 1, segmenter.Add(Line{LogId: 5, Time: at(4), Message: "Line without a pid"}))
+		// The pid is reused by a restarted process.
+		assert.Equal(t, 3, segmenter.Add(Line{LogId: 6, Time: at(10), Pid: 10, Message: banner}))
+
+		sessions := segmenter.Sessions()
+		require.Equal(t, 3, len(sessions))
+		assert.Equal(t, Session{
+			Id:           1,
+			Pid:          10,
+			Hostname:     "web-1",
+			AgentVersion: "12.14.0",
+			NodeVersion:  "v20.18.3",
+			Start:        at(0),
+			End:          at(4),
+			Lines:        4,
+		}, sessions[0])
+		assert.Equal(t, 4*time.Second, sessions[0].Duration())
+
+		assert.Equal(t, 20, sessions[1].Pid)
+		assert.Equal(t, "", sessions[1].AgentVersion)
+		assert.Equal(t, 1, sessions[1].Lines)
+
+		assert.Equal(t, 3, sessions[2].Id)
+		assert.Equal(t, at(10), sessions[2].Start)
+	})
+
+	t.Run("completes sessions whose banner follows other lines", func(t *testing.T) {
+		segmenter := New()
+		assert.Equal(t, 1, segmenter.Add(Line{LogId: 1, Time: at(0), Pid: 10, Message: "Using configuration file /app/newrelic.js."}))
+		assert.Equal(t, 1, segmenter.Add(Line{LogId: 2, Time: at(1), Pid: 10, Message: banner}))
+		assert.Equal(t, 2, segmenter.Add(Line{LogId: 3, Time: at(2), Pid: 10, Message: banner}))
+
+		sessions := segmenter.Sessions()
+		require.Equal(t, 2, len(sessions))
+		assert.Equal(t, "12.14.0", sessions[0].AgentVersion)
+		assert.Equal(t, 2, sessions[0].Lines)
+	})
+
+	t.Run("starts a session for lines without a pid", func(t *testing.T) {
+		segmenter := New()
+		assert.Equal(t, 1, segmenter.Add(Line{LogId: 1, Time: at(0), Message: "Line without a pid"}))
+		assert.Equal(t, 1, segmenter.Add(Line{LogId: 2, Time: at(1), Message: "Another line"}))
+		assert.Equal(t, 1, len(segmenter.Sessions()))
+	})
+}
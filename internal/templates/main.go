@@ -0,0 +1,225 @@
+// Package templates mines message templates from log lines in the style of the
+// Drain algorithm, see https://jiemingzhu.github.io/pub/pjhe_icws2017.pdf.
+// Messages are grouped by their template, i.e. the message with its variable
+// parts masked, so that repetitive lines can be collapsed.
+package templates
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Wildcard replaces the variable parts of a template.
+const Wildcard = "<*>"
+
+// Cluster is a group of messages that share a template.
+type Cluster struct {
+	Id     int
+	Tokens []string
+	Size   int
+}
+
+// Template renders the cluster's template, e.g. `Recorded memory: <*>`.
+func (c *Cluster) Template() string {
+	return strings.Join(c.Tokens, " ")
+}
+
+type Params struct {
+	// Depth is the depth of the parse tree, including the root and the
+	// message length layers. Thus, the leading Depth-2 tokens of a message
+	// select the group of clusters it is compared with. Defaults to 4.
+	Depth int
+
+	// Similarity is the minimum fraction of tokens that a message must share
+	// with a cluster's template to be added to the cluster. Defaults to 0.4.
+	Similarity float64
+
+	// MaxChildren limits the number of children of each tree node. Tokens
+	// beyond the limit are routed to the wildcard child. Defaults to 100.
+	MaxChildren int
+}
+
+// Miner assigns messages to clusters. Messages must be added in order, as a
+// cluster's template is generalized as messages are added to it.
+type Miner struct {
+	depth       int
+	similarity  float64
+	maxChildren int
+
+	// roots holds the first layer of the parse tree, keyed by the number of
+	// tokens in the message.
+	roots    map[int]*node
+	clusters []*Cluster
+}
+
+type node struct {
+	children map[string]*node
+	clusters []*Cluster
+}
+
+func newNode() *node {
+	return &node{children: make(map[string]*node)}
+}
+
+func New(params Params) *Miner {
+	miner := &Miner{
+		depth:       params.Depth,
+		similarity:  params.Similarity,
+		maxChildren: params.MaxChildren,
+		roots:       make(map[int]*node),
+		clusters:    make([]*Cluster, 0),
+	}
+	if miner.depth < 3 {
+		miner.depth = 4
+	}
+	if miner.similarity <= 0 {
+		miner.similarity = 0.4
+	}
+	if miner.maxChildren <= 0 {
+		miner.maxChildren = 100
+	}
+	return miner
+}
+
+// Clusters returns all clusters in the order they were created.
+func (m *Miner) Clusters() []*Cluster {
+	return m.clusters
+}
+
+// Add assigns the message to the most similar cluster, creating a new cluster
+// when there is no sufficiently similar cluster.
+func (m *Miner) Add(message string) *Cluster {
+	tokens := Tokenize(message)
+	leaf := m.leaf(tokens)
+
+	cluster := m.bestMatch(leaf.clusters, tokens)
+	if cluster == nil {
+		cluster = &Cluster{
+			Id:     len(m.clusters) + 1,
+			Tokens: tokens,
+		}
+		m.clusters = append(m.clusters, cluster)
+		leaf.clusters = append(leaf.clusters, cluster)
+	} else {
+		for i, token := range tokens {
+			if cluster.Tokens[i] != token {
+				cluster.Tokens[i] = Wildcard
+			}
+		}
+	}
+
+	cluster.Size += 1
+	return cluster
+}
+
+// leaf descends the parse tree, creating nodes as necessary, to the leaf that
+// holds the clusters the tokens should be compared with.
+func (m *Miner) leaf(tokens []string) *node {
+	current, found := m.roots[len(tokens)]
+	if found == false {
+		current = newNode()
+		m.roots[len(tokens)] = current
+	}
+
+	for i := 0; i < m.depth-2 && i < len(tokens); i++ {
+		key := tokens[i]
+		if hasDigit(key) {
+			key = Wildcard
+		}
+
+		child, found := current.children[key]
+		if found == false {
+			if len(current.children) >= m.maxChildren {
+				key = Wildcard
+				child = current.children[key]
+			}
+			if child == nil {
+				child = newNode()
+				current.children[key] = child
+			}
+		}
+		current = child
+	}
+
+	return current
+}
+
+// bestMatch finds the cluster most similar to the tokens. Ties are broken in
+// favor of the cluster with the fewest wildcards. Nil is returned if no
+// cluster meets the similarity threshold.
+func (m *Miner) bestMatch(clusters []*Cluster, tokens []string) *Cluster {
+	var best *Cluster
+	bestSimilarity := -1.0
+	bestWildcards := 0
+
+	for _, cluster := range clusters {
+		similarity, wildcards := compare(cluster.Tokens, tokens)
+		if similarity > bestSimilarity || (similarity == bestSimilarity && wildcards < bestWildcards) {
+			best = cluster
+			bestSimilarity = similarity
+			bestWildcards = wildcards
+		}
+	}
+
+	if best == nil || bestSimilarity < m.similarity {
+		return nil
+	}
+	return best
+}
+
+// compare returns the fraction of tokens that are equal to the template's
+// tokens, along with the number of wildcards in the template. Both slices
+// must be of the same length.
+func compare(template []string, tokens []string) (float64, int) {
+	if len(tokens) == 0 {
+		return 1, 0
+	}
+
+	equal := 0
+	wildcards := 0
+	for i, token := range template {
+		switch {
+		case token == Wildcard:
+			wildcards += 1
+		case token == tokens[i]:
+			equal += 1
+		}
+	}
+	return float64(equal) / float64(len(tokens)), wildcards
+}
+
+// maskers match tokens that are almost certainly variable, e.g. numbers and
+// identifiers. Such tokens are replaced with the [Wildcard] before mining.
+var maskers = []*regexp.Regexp{
+	// Numbers, including those with units or trailing punctuation, e.g.
+	// `598`, `12.5ms`, `443,`.
+	regexp.MustCompile(`^[-+]?\d+(\.\d+)?[a-zA-Z%]*[.,;:]?$`),
+	// Hexadecimal values, e.g. `0x1f`, and long hexadecimal ids.
+	regexp.MustCompile(`^(0x[0-9a-fA-F]+|[0-9a-fA-F]{8,})[.,;:]?$`),
+	// UUIDs.
+	regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}[.,;:]?$`),
+	// IPv4 addresses, with an optional port.
+	regexp.MustCompile(`^\d{1,3}(\.\d{1,3}){3}(:\d+)?[.,;:]?$`),
+	// Versions, e.g. `v20.18.3` or `12.14.0;`.
+	regexp.MustCompile(`^v?\d+(\.\d+)+[.,;:]?$`),
+}
+
+// Tokenize splits the message on whitespace and masks the tokens that are
+// almost certainly variable.
+func Tokenize(message string) []string {
+	tokens := strings.Fields(message)
+	for i, token := range tokens {
+		for _, masker := range maskers {
+			if masker.MatchString(token) {
+				tokens[i] = Wildcard
+				break
+			}
+		}
+	}
+	return tokens
+}
+
+func hasDigit(token string) bool {
+	return strings.IndexFunc(token, unicode.IsDigit) >= 0
+}
@@ -0,0 +1,47 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Tokenize(t *testing.T) {
+	assert.Equal(
+		t,
+		[]string{"Agent", "version:", Wildcard, "Node", "version:", Wildcard},
+		Tokenize("Agent version: 12.14.0; Node version: v20.18.3."),
+	)
+	assert.Equal(t, []string{"Connected", "to", "collector.newrelic.com:443"}, Tokenize("Connected to collector.newrelic.com:443"))
+	assert.Equal(t, []string{}, Tokenize("  "))
+}
+
+func Test_Miner(t *testing.T) {
+	miner := New(Params{})
+
+	first := miner.Add("No log events to send.")
+	assert.Equal(t, first, miner.Add("No log events to send."))
+
+	recorded := miner.Add("Recorded memory: rss 84197376")
+	assert.Equal(t, recorded, miner.Add("Recorded memory: rss 71204864"))
+	assert.Equal(t, "Recorded memory: rss <*>", recorded.Template())
+
+	// Messages with variable words are generalized once they are similar
+	// enough to an existing template.
+	state := miner.Add("Agent state changed from stopped to starting.")
+	assert.Equal(t, state, miner.Add("Agent state changed from starting to connecting."))
+	assert.Equal(t, "Agent state changed from <*> to <*>", state.Template())
+	assert.Equal(t, 2, state.Size)
+
+	other := miner.Add("Invoking remote method connect")
+	assert.NotEqual(t, state, other)
+
+	require.Equal(t, 4, len(miner.Clusters()))
+	assert.Equal(t, []int{1, 2, 3, 4}, []int{
+		miner.Clusters()[0].Id,
+		miner.Clusters()[1].Id,
+		miner.Clusters()[2].Id,
+		miner.Clusters()[3].Id,
+	})
+}
@@ -0,0 +1,257 @@
+// Package transaction reconstructs the transactions traced by the agent, and
+// their segment trees, from trace level lines. The tracer logs each segment
+// as it is added to a transaction:
+//
+//	Adding segment Datastore/statement/MongoDB/sessions/findOne to /authPackage.User/VerifyToken in c672139ea68587f4
+//
+// The shims log "Created segment" with the segment's name in the `name`
+// attribute, but without the transaction id, so those lines are matched to
+// the segment of the same name in the same process. The `transaction`
+// component logs the naming of a transaction along with its id, and the
+// `name-state` component logs the naming state that precedes it.
+//
+// The agent does not log when a segment ends, so the end of a segment is the
+// time of the last line logged for it or for any of its children.
+package transaction
+
+import (
+	"regexp"
+	"slices"
+	"time"
+)
+
+// Components are the components whose lines, in addition to the segment
+// lines, belong to transactions.
+var Components = []string{"transaction", "name-state"}
+
+// CreatedMessage is the message of the lines that shims log when they create
+// a segment.
+const CreatedMessage = "Created segment"
+
+// rootName is the parent of the first segment of a transaction.
+const rootName = "ROOT"
+
+var matchAdding = regexp.MustCompile(`^Adding segment (.+) to (.+) in ([0-9a-f]+)$`)
+
+// Line is the part of a log line that is needed to reconstruct transactions.
+type Line struct {
+	LogId     int
+	Time      time.Time
+	Pid       int
+	Component string
+	Message   string
+
+	// Name is the `name` attribute, which holds the segment name of "Created
+	// segment" lines.
+	Name string
+	// Module is the `module` attribute of "Created segment" lines, e.g.
+	// "mongodb".
+	Module string
+	// TransactionId, TransactionName and RequestURL are the attributes of the
+	// lines of the `transaction` component.
+	TransactionId   string
+	TransactionName string
+	RequestURL      string
+}
+
+// Segment is a node of a transaction's segment tree.
+type Segment struct {
+	Name   string
+	Module string
+	Start  time.Time
+	End    time.Time
+	LogIds []int
+
+	Children []*Segment
+}
+
+func (s *Segment) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Transaction is a single traced transaction.
+type Transaction struct {
+	Id  string
+	Pid int
+	// Name is the last name the transaction was given, e.g.
+	// "WebTransaction/NormalizedUri/*". It is empty if the transaction was
+	// never named.
+	Name string
+	URL  string
+
+	Start time.Time
+	End   time.Time
+
+	// Segments are the segments added to the root of the transaction.
+	Segments []*Segment
+	// LogIds are the lines logged for the transaction, in order.
+	LogIds []int
+}
+
+func (t *Transaction) Duration() time.Duration {
+	return t.End.Sub(t.Start)
+}
+
+// SegmentCount returns the number of segments in the tree.
+func (t *Transaction) SegmentCount() int {
+	count := 0
+	var walk func(segments []*Segment)
+	walk = func(segments []*Segment) {
+		for _, segment := range segments {
+			count++
+			walk(segment.Children)
+		}
+	}
+	walk(t.Segments)
+	return count
+}
+
+// pidName identifies the segments that "Created segment" lines are matched
+// against.
+type pidName struct {
+	pid  int
+	name string
+}
+
+// Builder groups lines into transactions. Lines must be added in the order
+// they were logged.
+type Builder struct {
+	transactions map[string]*Transaction
+	order        []string
+
+	// path maps each segment to its ancestors, so that their end can be
+	// extended along with it.
+	path map[*Segment][]*Segment
+	// added holds the latest segment of each name per process, and owner the
+	// transaction of each segment.
+	added map[pidName]*Segment
+	owner map[*Segment]*Transaction
+	// created holds "Created segment" lines logged before their segment was
+	// added, and matched holds the segments that a line was matched to.
+	created map[pidName][]Line
+	matched map[*Segment]bool
+	// naming holds `name-state` lines that precede the next transaction line
+	// of their process.
+	naming map[int][]Line
+}
+
+func New() *Builder {
+	return &Builder{
+		transactions: make(map[string]*Transaction),
+		path:         make(map[*Segment][]*Segment),
+		added:        make(map[pidName]*Segment),
+		owner:        make(map[*Segment]*Transaction),
+		created:      make(map[pidName][]Line),
+		matched:      make(map[*Segment]bool),
+		naming:       make(map[int][]Line),
+	}
+}
+
+// Add attributes a line to its transaction. Lines that cannot be attributed
+// are ignored.
+func (b *Builder) Add(line Line) {
+	switch {
+	case line.Component == "name-state":
+		b.naming[line.Pid] = append(b.naming[line.Pid], line)
+
+	case line.Message == CreatedMessage:
+		key := pidName{pid: line.Pid, name: line.Name}
+		segment := b.added[key]
+		if segment == nil || b.matched[segment] == true {
+			b.created[key] = append(b.created[key], line)
+			return
+		}
+		b.attachCreated(segment, line)
+
+	case line.TransactionId != "":
+		tx := b.transaction(line.TransactionId, line)
+		if line.TransactionName != "" {
+			tx.Name = line.TransactionName
+		}
+		if line.RequestURL != "" {
+			tx.URL = line.RequestURL
+		}
+		b.extend(tx, nil, line)
+
+	default:
+		matches := matchAdding.FindStringSubmatch(line.Message)
+		if matches == nil {
+			return
+		}
+		tx := b.transaction(matches[3], line)
+		segment := &Segment{Name: matches[1], Start: line.Time, End: line.Time}
+
+		parent := b.added[pidName{pid: line.Pid, name: matches[2]}]
+		if matches[2] == rootName || parent == nil || b.owner[parent] != tx {
+			tx.Segments = append(tx.Segments, segment)
+		} else {
+			parent.Children = append(parent.Children, segment)
+			b.path[segment] = append(slices.Clone(b.path[parent]), parent)
+		}
+		key := pidName{pid: line.Pid, name: segment.Name}
+		b.added[key] = segment
+		b.owner[segment] = tx
+		b.extend(tx, segment, line)
+
+		if pending := b.created[key]; len(pending) > 0 {
+			b.created[key] = pending[1:]
+			b.attachCreated(segment, pending[0])
+		}
+	}
+}
+
+// transaction returns the transaction with the id, creating it if needed. The
+// naming lines that precede the line are attributed to the transaction.
+func (b *Builder) transaction(id string, line Line) *Transaction {
+	tx := b.transactions[id]
+	if tx == nil {
+		tx = &Transaction{Id: id, Pid: line.Pid, Start: line.Time, End: line.Time}
+		b.transactions[id] = tx
+		b.order = append(b.order, id)
+	}
+	for _, naming := range b.naming[line.Pid] {
+		b.extend(tx, nil, naming)
+	}
+	delete(b.naming, line.Pid)
+	return tx
+}
+
+func (b *Builder) attachCreated(segment *Segment, line Line) {
+	segment.Module = line.Module
+	b.matched[segment] = true
+	b.extend(b.owner[segment], segment, line)
+}
+
+// extend attributes the line to the transaction and, if not nil, to the
+// segment, extending the end of the segment and of its ancestors.
+func (b *Builder) extend(tx *Transaction, segment *Segment, line Line) {
+	tx.LogIds = append(tx.LogIds, line.LogId)
+	if line.Time.Before(tx.Start) {
+		tx.Start = line.Time
+	}
+	if line.Time.After(tx.End) {
+		tx.End = line.Time
+	}
+	if segment == nil {
+		return
+	}
+
+	segment.LogIds = append(segment.LogIds, line.LogId)
+	for _, s := range append(b.path[segment], segment) {
+		if line.Time.After(s.End) {
+			s.End = line.Time
+		}
+	}
+}
+
+// Transactions returns the transactions in the order they were first logged.
+// The log ids of each transaction are sorted.
+func (b *Builder) Transactions() []Transaction {
+	result := make([]Transaction, 0, len(b.order))
+	for _, id := range b.order {
+		tx := *b.transactions[id]
+		tx.LogIds = slices.Compact(slices.Sorted(slices.Values(tx.LogIds)))
+		result = append(result, tx)
+	}
+	return result
+}
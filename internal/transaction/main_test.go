@@ -0,0 +1,73 @@
+package transaction
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Builder(t *testing.T) {
+	start := time.Date(2024, 11, 18, 7, 19, 24, 0, time.UTC)
+	at := func(millis int) time.Time {
+		return start.Add(time.Duration(millis) * time.Millisecond)
+	}
+	adding := func(name string, parent string, id string) string {
+		return "Adding segment " + name + " to " + parent + " in " + id
+	}
+
+	t.Run("builds the segment tree of a transaction", func(t *testing.T) {
+		builder := New()
+		builder.Add(Line{LogId: 1, Time: at(0), Pid: 18, Component: "name-state", Message: "Reset called on name state"})
+		builder.Add(Line{LogId: 2, Time: at(1), Pid: 18, Component: "tracer", Message: adding("/", "ROOT", "c672")})
+		builder.Add(Line{LogId: 3, Time: at(2), Pid: 18, Component: "transaction", Message: "Setting transaction name", TransactionId: "c672", RequestURL: "/verify"})
+		builder.Add(Line{LogId: 4, Time: at(5), Pid: 18, Component: "segment", Message: adding("/authPackage.User/VerifyToken", "/", "c672")})
+		builder.Add(Line{LogId: 5, Time: at(8), Pid: 18, Component: "segment", Message: adding("Datastore/statement/MongoDB/sessions/findOne", "/authPackage.User/VerifyToken", "c672")})
+		builder.Add(Line{LogId: 6, Time: at(9), Pid: 18, Component: "DatastoreShim", Message: CreatedMessage, Name: "Datastore/statement/MongoDB/sessions/findOne", Module: "mongodb"})
+		builder.Add(Line{LogId: 7, Time: at(10), Pid: 18, Component: "DatastoreShim", Message: CreatedMessage, Name: "Datastore/operation/Redis/hget", Module: "ioredis"})
+		builder.Add(Line{LogId: 8, Time: at(11), Pid: 18, Component: "segment", Message: adding("Datastore/operation/Redis/hget", "/", "c672")})
+		builder.Add(Line{LogId: 9, Time: at(20), Pid: 18, Component: "transaction", Message: "Finished setting transaction name from Uri", TransactionId: "c672", TransactionName: "WebTransaction/NormalizedUri/*"})
+		builder.Add(Line{LogId: 10, Time: at(21), Pid: 18, Component: "sampler", Message: "Recorded memory"})
+
+		transactions := builder.Transactions()
+		require.Equal(t, 1, len(transactions))
+		tx := transactions[0]
+		assert.Equal(t, "c672", tx.Id)
+		assert.Equal(t, "WebTransaction/NormalizedUri/*", tx.Name)
+		assert.Equal(t, "/verify", tx.URL)
+		assert.Equal(t, at(0), tx.Start)
+		assert.Equal(t, 20*time.Millisecond, tx.Duration())
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9}, tx.LogIds)
+		assert.Equal(t, 4, tx.SegmentCount())
+
+		require.Equal(t, 1, len(tx.Segments))
+		root := tx.Segments[0]
+		assert.Equal(t, "/", root.Name)
+		assert.Equal(t, 10*time.Millisecond, root.Duration())
+		require.Equal(t, 2, len(root.Children))
+		verify := root.Children[0]
+		assert.Equal(t, 4*time.Millisecond, verify.Duration())
+		require.Equal(t, 1, len(verify.Children))
+		assert.Equal(t, "mongodb", verify.Children[0].Module)
+		assert.Equal(t, []int{5, 6}, verify.Children[0].LogIds)
+		assert.Equal(t, "ioredis", root.Children[1].Module)
+		assert.Equal(t, []int{8, 7}, root.Children[1].LogIds)
+	})
+
+	t.Run("keeps interleaved transactions apart", func(t *testing.T) {
+		builder := New()
+		builder.Add(Line{LogId: 1, Time: at(0), Pid: 18, Message: adding("/a", "ROOT", "aaaa")})
+		builder.Add(Line{LogId: 2, Time: at(1), Pid: 18, Message: adding("/b", "ROOT", "bbbb")})
+		builder.Add(Line{LogId: 3, Time: at(2), Pid: 18, Message: adding("External/x", "/a", "aaaa")})
+		builder.Add(Line{LogId: 4, Time: at(3), Pid: 18, Message: adding("External/y", "/a", "bbbb")})
+
+		transactions := builder.Transactions()
+		require.Equal(t, 2, len(transactions))
+		assert.Equal(t, []int{1, 3}, transactions[0].LogIds)
+		assert.Equal(t, "External/x", transactions[0].Segments[0].Children[0].Name)
+		// The parent belongs to another transaction, so the segment is added
+		// to the root.
+		assert.Equal(t, 2, len(transactions[1].Segments))
+	})
+}
@@ -0,0 +1,153 @@
+// Package triage groups error lines so that recurring errors can be told
+// apart from one-off ones. Error lines carry the attributes of a Node.js
+// system error, e.g.:
+//
+//	{"msg":"Could not list packages in /lib/node_modules","errno":-2,"code":"ENOENT","syscall":"scandir","path":"/lib/node_modules","stack":"Error: ENOENT: ..."}
+//
+// Lines are grouped by their normalized message, their `code` and `syscall`
+// attributes, and the top frame of their stack. Lines logged at the error or
+// fatal level are grouped as well, even without those attributes.
+package triage
+
+import (
+	"cmp"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/newrelic/node-log-viewer/internal/templates"
+)
+
+// MaxPaths is the number of distinct paths kept as samples for each group.
+const MaxPaths = 5
+
+// matchPath matches tokens that hold a file path or a quoted value, e.g.
+// `/lib/node_modules` or `'/lib/node_modules'`, which vary between
+// occurrences of the same error.
+var matchPath = regexp.MustCompile(`^['"(]*([^\s'"]*[/\\][^\s'"]*|'[^']*'|"[^"]*")['")]*[.,;:]?$`)
+
+// Line is the part of a log line that is needed to triage errors.
+type Line struct {
+	LogId     int
+	Time      time.Time
+	Level     int
+	Component string
+	Message   string
+
+	// Code, Syscall, Path and Stack are the attributes of error lines.
+	Code    string
+	Syscall string
+	Path    string
+	Stack   string
+}
+
+// Key identifies a group of errors.
+type Key struct {
+	// Message is the message with its variable parts masked, see
+	// [Normalize].
+	Message string
+	Code    string
+	Syscall string
+	// Frame is the top frame of the stack, e.g.
+	// "at Object.readdirSync (node:fs:1507:26)".
+	Frame string
+}
+
+// Group is a set of lines that share a [Key].
+type Group struct {
+	Key
+	Count int
+	First time.Time
+	Last  time.Time
+	// Level is the highest level of the lines in the group.
+	Level      int
+	Components []string
+	// Paths are up to [MaxPaths] distinct paths of the lines in the group.
+	Paths  []string
+	LogIds []int
+}
+
+// Normalize masks the variable parts of the message, i.e. the tokens that
+// [templates.Tokenize] masks, along with paths and quoted values.
+func Normalize(message string) string {
+	tokens := templates.Tokenize(message)
+	for i, token := range tokens {
+		if matchPath.MatchString(token) {
+			tokens[i] = templates.Wildcard
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+// TopFrame returns the first frame of the stack, or an empty string when the
+// stack only holds the error's message.
+func TopFrame(stack string) string {
+	for _, line := range strings.Split(stack, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "at ") {
+			return line
+		}
+	}
+	return ""
+}
+
+// Grouper groups lines by their [Key].
+type Grouper struct {
+	groups map[Key]*Group
+}
+
+func New() *Grouper {
+	return &Grouper{groups: make(map[Key]*Group)}
+}
+
+// Add adds the line to its group.
+func (g *Grouper) Add(line Line) {
+	key := Key{
+		Message: Normalize(line.Message),
+		Code:    line.Code,
+		Syscall: line.Syscall,
+		Frame:   TopFrame(line.Stack),
+	}
+	group := g.groups[key]
+	if group == nil {
+		group = &Group{Key: key, First: line.Time, Last: line.Time}
+		g.groups[key] = group
+	}
+
+	group.Count++
+	group.LogIds = append(group.LogIds, line.LogId)
+	group.Level = max(group.Level, line.Level)
+	if line.Time.Before(group.First) {
+		group.First = line.Time
+	}
+	if line.Time.After(group.Last) {
+		group.Last = line.Time
+	}
+	if slices.Contains(group.Components, line.Component) == false {
+		group.Components = append(group.Components, line.Component)
+	}
+	if line.Path != "" && len(group.Paths) < MaxPaths && slices.Contains(group.Paths, line.Path) == false {
+		group.Paths = append(group.Paths, line.Path)
+	}
+}
+
+// Groups returns the groups, the most frequent first. Groups of the same
+// size are ordered by their first occurrence.
+func (g *Grouper) Groups() []Group {
+	result := make([]Group, 0, len(g.groups))
+	for group := range maps.Values(g.groups) {
+		group := *group
+		slices.Sort(group.Components)
+		result = append(result, group)
+	}
+	slices.SortFunc(result, func(a Group, b Group) int {
+		return cmp.Or(
+			cmp.Compare(b.Count, a.Count),
+			a.First.Compare(b.First),
+			cmp.Compare(a.Message, b.Message),
+		)
+	})
+	return result
+}
@@ -0,0 +1,61 @@
+package triage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Normalize(t *testing.T) {
+	assert.Equal(
+		t,
+		"Could not list packages in <*> (probably not an error)",
+		Normalize("Could not list packages in /lib/node_modules (probably not an error)"),
+	)
+	assert.Equal(t, "Could not read <*>", Normalize("Could not read /app/node_modules/a/package.json."))
+	assert.Equal(t, "scandir <*>", Normalize("scandir '/lib/node_modules'"))
+	assert.Equal(
+		t,
+		"Agent endpoint metric_data returned <*> status. Restarting.",
+		Normalize("Agent endpoint metric_data returned 409 status. Restarting."),
+	)
+}
+
+func Test_TopFrame(t *testing.T) {
+	assert.Equal(t, "", TopFrame("Error: ENOENT: no such file or directory, scandir '/lib/node_modules'"))
+	assert.Equal(
+		t,
+		"at Object.readdirSync (node:fs:1507:26)",
+		TopFrame("Error: ENOENT: no such file or directory\n    at Object.readdirSync (node:fs:1507:26)\n    at listPackages (environment.js:86:22)"),
+	)
+}
+
+func Test_Grouper(t *testing.T) {
+	start := time.Date(2025, 3, 6, 18, 8, 4, 0, time.UTC)
+	grouper := New()
+	grouper.Add(Line{LogId: 1, Time: start, Level: 10, Component: "environment", Message: "Could not list packages in /lib/node_modules", Code: "ENOENT", Syscall: "scandir", Path: "/lib/node_modules"})
+	grouper.Add(Line{LogId: 2, Time: start.Add(time.Second), Level: 50, Component: "collector_api", Message: "Agent endpoint metric_data returned 409 status. Restarting."})
+	grouper.Add(Line{LogId: 3, Time: start.Add(2 * time.Second), Level: 20, Component: "environment", Message: "Could not list packages in /home/.node_modules", Code: "ENOENT", Syscall: "scandir", Path: "/home/.node_modules"})
+	grouper.Add(Line{LogId: 4, Time: start.Add(3 * time.Second), Level: 10, Component: "utilization", Message: "Could not list packages in /lib/node_modules", Code: "ENOENT", Syscall: "scandir", Path: "/lib/node_modules"})
+	grouper.Add(Line{LogId: 5, Time: start.Add(4 * time.Second), Level: 10, Component: "environment", Message: "Could not list packages in /lib/node_modules", Code: "ENOTDIR", Syscall: "scandir", Path: "/lib/node_modules"})
+
+	groups := grouper.Groups()
+	require.Equal(t, 3, len(groups))
+
+	enoent := groups[0]
+	assert.Equal(t, Key{Message: "Could not list packages in <*>", Code: "ENOENT", Syscall: "scandir"}, enoent.Key)
+	assert.Equal(t, 3, enoent.Count)
+	assert.Equal(t, start, enoent.First)
+	assert.Equal(t, start.Add(3*time.Second), enoent.Last)
+	assert.Equal(t, 20, enoent.Level)
+	assert.Equal(t, []string{"environment", "utilization"}, enoent.Components)
+	assert.Equal(t, []string{"/lib/node_modules", "/home/.node_modules"}, enoent.Paths)
+	assert.Equal(t, []int{1, 3, 4}, enoent.LogIds)
+
+	// Groups of the same size are ordered by their first occurrence.
+	assert.Equal(t, "collector_api", groups[1].Components[0])
+	assert.Equal(t, 50, groups[1].Level)
+	assert.Equal(t, "ENOTDIR", groups[2].Code)
+}
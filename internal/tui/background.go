@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// rightStatusText is the default text of the right status indicator.
+const rightStatusText = "(s)earch | (e)xport | (h)elp"
+
+// spinnerFrames are shown, in order, in the status bar while a background
+// task is running.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval is how often the spinner, and the elapsed time, are
+// updated.
+const spinnerInterval = 100 * time.Millisecond
+
+// backgroundTask is work, e.g. a query, running outside of the tview event
+// loop so that the UI stays responsive.
+type backgroundTask struct {
+	label   string
+	started time.Time
+	cancel  context.CancelFunc
+	// exclusive tasks are not cancelled by the tasks started after them, see
+	// [TUI.runExclusively].
+	exclusive bool
+}
+
+// runInBackground runs the work in a new goroutine while a spinner, along
+// with the elapsed time, is shown in the status bar. Only one task runs at a
+// time; starting a task cancels the one in flight. The `esc` key also cancels
+// the task in flight, see [TUI.cancelBackgroundTask].
+//
+// The done callback is invoked on the tview event loop with the result of the
+// work. It is not invoked when the task is cancelled. The work must not
+// modify the UI, or read state that the UI may modify, as it does not run on
+// the event loop.
+func (t *TUI) runInBackground(label string, work func(ctx context.Context) error, done func(err error)) {
+	t.startBackgroundTask(label, false, work, done)
+}
+
+// runExclusively runs the work like [TUI.runInBackground], except that the
+// task is not cancelled by the tasks started after it. Those are refused
+// until it has finished, or has been cancelled with the `esc` key. It is
+// meant for work with side effects that must not be interrupted by
+// browsing, e.g. an export.
+func (t *TUI) runExclusively(label string, work func(ctx context.Context) error, done func(err error)) {
+	t.startBackgroundTask(label, true, work, done)
+}
+
+func (t *TUI) startBackgroundTask(
+	label string,
+	exclusive bool,
+	work func(ctx context.Context) error,
+	done func(err error),
+) {
+	if t.task != nil && t.task.exclusive == true {
+		t.showError(nil, "Still %s. Wait for it to finish, or press esc to cancel it.", t.task.label)
+		return
+	}
+	t.cancelBackgroundTask()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	task := &backgroundTask{
+		label:     label,
+		started:   time.Now(),
+		cancel:    cancel,
+		exclusive: exclusive,
+	}
+	t.task = task
+	t.logger.Debug("starting background task", "task", label)
+	t.renderSpinner(task, 0)
+
+	finished := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+		for frame := 1; ; frame++ {
+			select {
+			case <-finished:
+				return
+			case <-ticker.C:
+				t.App.QueueUpdateDraw(func() {
+					if t.task == task {
+						t.renderSpinner(task, frame)
+					}
+				})
+			}
+		}
+	}()
+
+	go func() {
+		err := work(ctx)
+		close(finished)
+
+		t.App.QueueUpdateDraw(func() {
+			cancelled := errors.Is(err, context.Canceled) || ctx.Err() != nil
+			t.logger.Debug(
+				"finished background task",
+				"task", label,
+				"elapsed", time.Since(task.started),
+				"cancelled", cancelled,
+				"error", err,
+			)
+
+			if t.task == task {
+				t.task = nil
+				t.rightStatus.SetText(rightStatusText)
+			}
+			cancel()
+			if cancelled == true {
+				return
+			}
+			done(err)
+		})
+	}()
+}
+
+// cancelBackgroundTask cancels the task in flight, if any. It returns true if
+// a task was cancelled.
+func (t *TUI) cancelBackgroundTask() bool {
+	if t.task == nil {
+		return false
+	}
+
+	t.logger.Debug("cancelling background task", "task", t.task.label)
+	t.task.cancel()
+	t.task = nil
+	t.rightStatus.SetText(rightStatusText)
+	return true
+}
+
+func (t *TUI) renderSpinner(task *backgroundTask, frame int) {
+	elapsed := time.Since(task.started).Truncate(100 * time.Millisecond)
+	t.rightStatus.SetText(fmt.Sprintf(
+		"%s %s %.1fs (esc to cancel)",
+		spinnerFrames[frame%len(spinnerFrames)],
+		task.label,
+		elapsed.Seconds(),
+	))
+}
@@ -0,0 +1,255 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/rivo/tview"
+)
+
+// bookmarksTable lists the bookmarked lines. It is repopulated every time the
+// page is shown so that it reflects bookmarks added from the lines table.
+var bookmarksTable *tview.Table
+
+// bookmarksList is the set of bookmarks currently rendered in bookmarksTable.
+var bookmarksList []database.Bookmark
+
+// bookmarkNoteForm holds a reference to the form used to attach a note to
+// the selected line.
+var bookmarkNoteForm *tview.Form
+
+// bookmarkNoteLogId is the line the note form is editing.
+var bookmarkNoteLogId int
+
+// loadBookmarks reads the bookmarks stored in the cache file so that bookmarked
+// lines can be marked in the lines table.
+func (t *TUI) loadBookmarks() {
+	t.bookmarks = make(map[int]database.Bookmark)
+	bookmarks, err := t.db.Bookmarks()
+	if err != nil {
+		t.logger.Error("could not load bookmarks", "error", err)
+		return
+	}
+	for _, bookmark := range bookmarks {
+		t.bookmarks[bookmark.LogId] = bookmark
+	}
+}
+
+func (t *TUI) initBookmarksView() {
+	table := dashboardTable(" Bookmarks (enter: go to line, a: edit note, x: remove, esc: back) ")
+	table.SetSelectedFunc(func(row int, _ int) {
+		t.bookmarkSelected(row)
+	})
+	table.SetInputCapture(t.bookmarksInputHandler)
+	bookmarksTable = table
+	t.pages.AddPage(PAGE_BOOKMARKS, table, true, false)
+}
+
+func (t *TUI) initBookmarkNoteModal() {
+	form := tview.NewForm()
+	form.SetBorder(true)
+	form.SetTitle(" Bookmark note ")
+	form.SetButtonsAlign(tview.AlignRight)
+
+	form.AddInputField("Note:", "", 0, nil, nil)
+	form.AddButton("Save", func() { t.handleBookmarkNote(form) })
+	form.AddButton("Cancel", func() { t.hideModal(PAGE_BOOKMARK_NOTE) })
+	bookmarkNoteForm = form
+
+	t.pages.AddPage(PAGE_BOOKMARK_NOTE, modal(form, 70, 7), true, false)
+}
+
+// toggleBookmark bookmarks, or removes the bookmark from, the line at the
+// given row of the lines table.
+func (t *TUI) toggleBookmark(row int) {
+	if t.linesLoaded() == false {
+		return
+	}
+
+	logId := t.query.LogId(row + 1)
+	if logId == 0 {
+		return
+	}
+
+	bookmarked, err := t.db.ToggleBookmark(logId)
+	if err != nil {
+		t.showError(err, "Could not toggle bookmark: %s", err.Error())
+		return
+	}
+	if bookmarked == true {
+		t.bookmarks[logId] = database.Bookmark{LogId: logId, Created: time.Now()}
+	} else {
+		delete(t.bookmarks, logId)
+	}
+}
+
+// showBookmarkNoteModal shows the form for attaching a note to the line with
+// the given id. Saving the note bookmarks the line.
+func (t *TUI) showBookmarkNoteModal(logId int) {
+	if logId == 0 {
+		return
+	}
+
+	bookmarkNoteLogId = logId
+	bookmarkNoteForm.SetTitle(fmt.Sprintf(" Bookmark note for line %d ", logId))
+	bookmarkNoteForm.GetFormItem(0).(*tview.InputField).SetText(t.bookmarks[logId].Note)
+	bookmarkNoteForm.SetFocus(0)
+	t.showModal(PAGE_BOOKMARK_NOTE)
+}
+
+func (t *TUI) handleBookmarkNote(form *tview.Form) {
+	note := form.GetFormItem(0).(*tview.InputField).GetText()
+	t.hideModal(PAGE_BOOKMARK_NOTE)
+
+	err := t.db.SetBookmarkNote(bookmarkNoteLogId, note)
+	if err != nil {
+		t.showError(err, "Could not save note: %s", err.Error())
+		return
+	}
+
+	bookmark, found := t.bookmarks[bookmarkNoteLogId]
+	if found == false {
+		bookmark = database.Bookmark{LogId: bookmarkNoteLogId, Created: time.Now()}
+	}
+	bookmark.Note = note
+	t.bookmarks[bookmarkNoteLogId] = bookmark
+
+	name, _ := t.pages.GetFrontPage()
+	if name == PAGE_BOOKMARKS {
+		t.renderBookmarks()
+	}
+}
+
+// jumpToBookmark selects the next, or previous, bookmarked line within the
+// current set of lines.
+func (t *TUI) jumpToBookmark(forward bool) {
+	if t.linesLoaded() == false {
+		return
+	}
+
+	row, _ := t.linesTable.GetSelection()
+	rowNumber := t.query.NextBookmark(row+1, forward)
+	if rowNumber == 0 {
+		t.logger.Trace("no further bookmarks in current lines", "row", row, "forward", forward)
+		return
+	}
+	t.linesTable.Select(rowNumber-1, 0)
+}
+
+func (t *TUI) showBookmarks() {
+	t.renderBookmarks()
+	t.showPage(PAGE_BOOKMARKS, fmt.Sprintf("bookmarks -- %d lines", len(bookmarksList)))
+	t.App.SetFocus(bookmarksTable)
+}
+
+func (t *TUI) renderBookmarks() {
+	bookmarks, err := t.db.Bookmarks()
+	if err != nil {
+		t.showError(err, "Could not load bookmarks: %s", err.Error())
+		return
+	}
+	bookmarksList = bookmarks
+
+	selected, _ := bookmarksTable.GetSelection()
+	bookmarksTable.Clear()
+	bookmarksTable.SetCell(0, 0, dashboardHeaderCell("Line").SetAlign(tview.AlignRight))
+	bookmarksTable.SetCell(0, 1, dashboardHeaderCell("Time"))
+	bookmarksTable.SetCell(0, 2, dashboardHeaderCell("Component"))
+	bookmarksTable.SetCell(0, 3, dashboardHeaderCell("Note"))
+	bookmarksTable.SetCell(0, 4, dashboardHeaderCell("Message"))
+	for i, bookmark := range bookmarks {
+		timestamp := ""
+		if bookmark.Time.IsZero() == false {
+			timestamp = bookmark.Time.In(time.Now().Location()).Format("2006-01-02 15:04:05.000")
+		}
+		bookmarksTable.SetCell(i+1, 0, tview.NewTableCell(strconv.Itoa(bookmark.LogId)).SetAlign(tview.AlignRight))
+		bookmarksTable.SetCell(i+1, 1, tview.NewTableCell(timestamp).SetTextColor(tcell.ColorYellow))
+		bookmarksTable.SetCell(i+1, 2, tview.NewTableCell(bookmark.Component))
+		bookmarksTable.SetCell(
+			i+1,
+			3,
+			tview.NewTableCell(tview.Escape(bookmark.Note)).
+				SetTextColor(tcell.GetColor("#73d4e9")).
+				SetMaxWidth(40),
+		)
+		bookmarksTable.SetCell(i+1, 4, tview.NewTableCell(tview.Escape(bookmark.Message)).SetExpansion(1))
+	}
+
+	bookmarksTable.Select(min(max(selected, 1), max(len(bookmarks), 1)), 0)
+}
+
+// selectedBookmark returns the bookmark at the given row of the bookmarks
+// table, or nil if the row is not a bookmark, e.g. the header.
+func selectedBookmark(row int) *database.Bookmark {
+	if row < 1 || row > len(bookmarksList) {
+		return nil
+	}
+	return &bookmarksList[row-1]
+}
+
+// bookmarkSelected shows the selected bookmark in the lines table. If the
+// line is not within the current set of lines, the unfiltered set of lines
+// is shown instead.
+func (t *TUI) bookmarkSelected(row int) {
+	bookmark := selectedBookmark(row)
+	if bookmark == nil {
+		return
+	}
+
+	t.showPage(PAGE_LINES_TABLE, "")
+	t.App.SetFocus(t.linesTable)
+
+	rowNumber := 0
+	if t.linesLoaded() == true {
+		rowNumber = t.query.RowNumberOf(bookmark.LogId)
+	}
+	if rowNumber == 0 {
+		t.showLogIdInAllLines(bookmark.LogId)
+		return
+	}
+	t.linesTable.Select(rowNumber-1, 0)
+}
+
+func (t *TUI) bookmarksInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	t.logger.Trace("received key event in bookmarks view", "key", event.Name(), "rune", event.Rune())
+
+	switch event.Key() {
+	case tcell.KeyEsc, tcell.KeyBackspace, tcell.KeyBackspace2:
+		t.showPage(PAGE_LINES_TABLE, t.prevPageStatus)
+		t.prevPageStatus = ""
+		t.App.SetFocus(t.linesTable)
+		return nil
+	}
+
+	row, _ := bookmarksTable.GetSelection()
+	switch event.Rune() {
+	case 'a':
+		if bookmark := selectedBookmark(row); bookmark != nil {
+			t.showBookmarkNoteModal(bookmark.LogId)
+		}
+		return nil
+
+	case 'x':
+		if bookmark := selectedBookmark(row); bookmark != nil {
+			_, err := t.db.ToggleBookmark(bookmark.LogId)
+			if err != nil {
+				t.showError(err, "Could not remove bookmark: %s", err.Error())
+				return nil
+			}
+			delete(t.bookmarks, bookmark.LogId)
+			t.renderBookmarks()
+			t.leftStatus.SetText(fmt.Sprintf("bookmarks -- %d lines", len(bookmarksList)))
+		}
+		return nil
+
+	case 'j':
+		return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+	case 'k':
+		return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+	}
+
+	return event
+}
@@ -0,0 +1,323 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/newrelic/node-log-viewer/internal/config"
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/rivo/tview"
+)
+
+// configView shows the effective configuration of each session as a tree of
+// settings, optionally reduced to the settings that differ from a base
+// session.
+type configView struct {
+	root     *tview.Flex
+	sessions *tview.Table
+	search   *tview.InputField
+	tree     *tview.TreeView
+
+	// configs is the set of configurations rendered in sessions.
+	configs []database.SessionConfig
+	// base is the index into configs of the session that the selected session
+	// is compared against, or -1 to show the full configuration.
+	base int
+}
+
+func (t *TUI) initConfigView() {
+	view := &configView{
+		root:     tview.NewFlex(),
+		sessions: dashboardTable(" Sessions (enter: lines, d: diff) "),
+		search:   tview.NewInputField(),
+		tree:     tview.NewTreeView(),
+		base:     -1,
+	}
+
+	view.sessions.SetSelectionChangedFunc(func(_ int, _ int) {
+		t.renderConfigTree()
+	})
+	view.sessions.SetSelectedFunc(func(row int, _ int) {
+		t.configSessionSelected(row)
+	})
+	view.sessions.SetInputCapture(t.configSessionsInputHandler)
+
+	view.search.
+		SetLabel("Search: ").
+		SetFieldBackgroundColor(tcell.ColorBlack).
+		SetChangedFunc(func(_ string) {
+			t.renderConfigTree()
+		})
+	view.search.SetBorder(true)
+
+	view.tree.SetBorder(true)
+	view.tree.SetSelectedFunc(func(node *tview.TreeNode) {
+		node.SetExpanded(!node.IsExpanded())
+	})
+
+	right := tview.NewFlex()
+	right.SetDirection(tview.FlexRow)
+	right.AddItem(view.search, 3, 0, false)
+	right.AddItem(view.tree, 0, 1, false)
+
+	view.root.AddItem(view.sessions, 40, 0, true)
+	view.root.AddItem(right, 0, 1, false)
+	view.root.SetInputCapture(t.configInputHandler)
+
+	t.config = view
+	t.pages.AddPage(PAGE_CONFIG, view.root, true, false)
+}
+
+func (t *TUI) showConfig() {
+	db := t.db
+	session := t.session
+	var configs []database.SessionConfig
+
+	t.runInBackground(
+		"reconstructing configurations",
+		func(ctx context.Context) error {
+			var err error
+			configs, err = db.Configs(ctx, session)
+			return err
+		},
+		func(err error) {
+			if err != nil {
+				t.showError(err, "Could not reconstruct configurations: %s", err.Error())
+				return
+			}
+			if len(configs) == 0 {
+				t.showError(
+					nil,
+					"No configuration was logged. The connect and agent_settings payloads are only logged at the trace level.",
+				)
+				return
+			}
+
+			view := t.config
+			view.configs = configs
+			view.base = -1
+
+			selected := 1
+			for i, c := range configs {
+				if c.Id == t.session {
+					selected = i + 1
+				}
+			}
+			t.renderConfigSessions()
+			view.sessions.Select(selected, 0)
+			t.renderConfigTree()
+			t.showPage(PAGE_CONFIG, fmt.Sprintf("config -- %d sessions", len(configs)))
+			t.App.SetFocus(view.sessions)
+		},
+	)
+}
+
+func (t *TUI) renderConfigSessions() {
+	view := t.config
+	table := view.sessions
+	table.Clear()
+	table.SetCell(0, 0, dashboardHeaderCell("Session").SetAlign(tview.AlignRight))
+	table.SetCell(0, 1, dashboardHeaderCell("Pid").SetAlign(tview.AlignRight))
+	table.SetCell(0, 2, dashboardHeaderCell("Settings").SetAlign(tview.AlignRight))
+
+	for i, c := range view.configs {
+		row := i + 1
+		label := strconv.Itoa(c.Id)
+		if i == view.base {
+			label = "base " + label
+		}
+		table.SetCell(row, 0, tview.NewTableCell(label).SetAlign(tview.AlignRight))
+		table.SetCell(row, 1, tview.NewTableCell(strconv.Itoa(c.Pid)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 2, tview.NewTableCell(strconv.Itoa(len(c.Config.Settings))).SetAlign(tview.AlignRight).SetExpansion(1))
+	}
+}
+
+// configSelected returns the index into configs of the selected session, or -1
+// if none is selected.
+func (v *configView) configSelected() int {
+	row, _ := v.sessions.GetSelection()
+	if row < 1 || row > len(v.configs) {
+		return -1
+	}
+	return row - 1
+}
+
+// renderConfigTree renders the settings of the selected session that match
+// the search. When a diff base is set, only the settings that differ from the
+// base are rendered.
+func (t *TUI) renderConfigTree() {
+	view := t.config
+	selected := view.configSelected()
+	if selected < 0 {
+		return
+	}
+	current := view.configs[selected]
+	search := strings.ToLower(view.search.GetText())
+
+	title := fmt.Sprintf(" Session %d configuration ", current.Id)
+	entries := make([]configEntry, 0)
+	if view.base >= 0 && view.base != selected {
+		base := view.configs[view.base]
+		title = fmt.Sprintf(" Session %d compared to session %d ", current.Id, base.Id)
+		for _, change := range config.Diff(base.Config, current.Config) {
+			entries = append(entries, configChangeEntry(change))
+		}
+	} else {
+		for _, key := range current.Config.Keys() {
+			entries = append(entries, configEntry{
+				key: key,
+				text: fmt.Sprintf(
+					"%s [gray](%s)[-]",
+					tview.Escape(config.FormatValue(current.Config.Settings[key])),
+					current.Config.Sources[key],
+				),
+				plain: config.FormatValue(current.Config.Settings[key]),
+			})
+		}
+	}
+
+	root := tview.NewTreeNode(".").SetSelectable(false)
+	nodes := map[string]*tview.TreeNode{"": root}
+	matches := 0
+	for _, entry := range entries {
+		if search != "" &&
+			strings.Contains(strings.ToLower(entry.key), search) == false &&
+			strings.Contains(strings.ToLower(entry.plain), search) == false {
+			continue
+		}
+		matches++
+
+		segments := strings.Split(entry.key, ".")
+		parent := root
+		for i, segment := range segments[:len(segments)-1] {
+			path := strings.Join(segments[:i+1], ".")
+			node := nodes[path]
+			if node == nil {
+				node = tview.NewTreeNode(tview.Escape(segment)).
+					SetColor(tcell.GetColor("#BB5FB9")).
+					SetReference(path)
+				nodes[path] = node
+				parent.AddChild(node)
+			}
+			parent = node
+		}
+		parent.AddChild(
+			tview.NewTreeNode(tview.Escape(segments[len(segments)-1]) + ": " + entry.text).
+				SetReference(entry.key),
+		)
+	}
+	if matches == 0 {
+		root.AddChild(tview.NewTreeNode("no matching settings").SetSelectable(false))
+	}
+
+	view.tree.SetTitle(title + fmt.Sprintf("(%d settings, tab: switch pane, esc: back) ", matches))
+	view.tree.SetRoot(root)
+	view.tree.SetTopLevel(1)
+	if children := root.GetChildren(); len(children) > 0 {
+		view.tree.SetCurrentNode(children[0])
+	}
+}
+
+// configEntry is a rendered setting in the configuration tree.
+type configEntry struct {
+	key string
+	// text is the rendered value, with color tags.
+	text string
+	// plain is the value as matched by the search.
+	plain string
+}
+
+func configChangeEntry(change config.Change) configEntry {
+	before := config.FormatValue(change.Before)
+	after := config.FormatValue(change.After)
+	entry := configEntry{key: change.Key, plain: before + " " + after}
+	switch change.Kind {
+	case config.ChangeAdded:
+		entry.text = "[#43A047]" + tview.Escape(after) + "[-] [gray](added)[-]"
+	case config.ChangeRemoved:
+		entry.text = "[#FF0000]" + tview.Escape(before) + "[-] [gray](removed)[-]"
+	default:
+		entry.text = fmt.Sprintf(
+			"[#FF0000]%s[-] → [#43A047]%s[-]",
+			tview.Escape(before),
+			tview.Escape(after),
+		)
+	}
+	return entry
+}
+
+// configInputHandler handles the keys shared by the panes of the page.
+func (t *TUI) configInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	t.logger.Trace("received key event in config view", "key", event.Name(), "rune", event.Rune())
+	view := t.config
+
+	switch event.Key() {
+	case tcell.KeyEsc:
+		if view.search.HasFocus() == true {
+			t.App.SetFocus(view.tree)
+			return nil
+		}
+		t.returnToLines()
+		return nil
+
+	case tcell.KeyTab:
+		switch {
+		case view.sessions.HasFocus():
+			t.App.SetFocus(view.search)
+		case view.search.HasFocus():
+			t.App.SetFocus(view.tree)
+		default:
+			t.App.SetFocus(view.sessions)
+		}
+		return nil
+	}
+
+	if view.search.HasFocus() == true {
+		return event
+	}
+
+	switch event.Rune() {
+	case '/':
+		t.App.SetFocus(view.search)
+		return nil
+	}
+
+	return remapVimKeys(event)
+}
+
+func (t *TUI) configSessionsInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	view := t.config
+
+	switch event.Rune() {
+	case 'd':
+		selected := view.configSelected()
+		if selected < 0 {
+			return nil
+		}
+		if view.base == selected {
+			view.base = -1
+		} else {
+			view.base = selected
+		}
+		t.renderConfigSessions()
+		t.renderConfigTree()
+		return nil
+	}
+
+	return event
+}
+
+// configSessionSelected shows the lines whose payloads hold the configuration
+// of the selected session.
+func (t *TUI) configSessionSelected(row int) {
+	view := t.config
+	if row < 1 || row > len(view.configs) {
+		return
+	}
+
+	filter := database.ConfigFilter(view.configs[row-1])
+	t.showFilteredLines(filter)
+}
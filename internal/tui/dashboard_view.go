@@ -0,0 +1,336 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/newrelic/node-log-viewer/internal/database"
+	v0 "github.com/newrelic/node-log-viewer/internal/v0"
+	"github.com/rivo/tview"
+)
+
+// dashboardFacets are the facets shown as count tables on the dashboard.
+var dashboardFacets = []database.Facet{
+	database.FacetComponent,
+	database.FacetLevel,
+	database.FacetPid,
+}
+
+// dashboardBucket is the size of the time buckets shown in the dashboard's
+// timeline.
+const dashboardBucket = time.Minute
+
+// dashboardBarWidth is the number of cells used for the longest bar in the
+// dashboard's timeline.
+const dashboardBarWidth = 50
+
+// dashboardView summarizes the lines of the current query: the number of
+// lines per facet value, and the number of lines per minute.
+type dashboardView struct {
+	root *tview.Grid
+
+	facetTables map[database.Facet]*tview.Table
+	facetRows   map[database.Facet][]database.AggregateRow
+	// sortByValue indicates, per facet, if the table is sorted by the facet
+	// value instead of by descending count.
+	sortByValue map[database.Facet]bool
+
+	timeline     *tview.Table
+	timelineRows []database.AggregateRow
+
+	// focusables is the order in which the tables receive focus when the tab
+	// key is pressed.
+	focusables []*tview.Table
+	focusIndex int
+}
+
+func (t *TUI) initDashboardView() {
+	dashboard := &dashboardView{
+		root:        tview.NewGrid(),
+		facetTables: make(map[database.Facet]*tview.Table),
+		facetRows:   make(map[database.Facet][]database.AggregateRow),
+		sortByValue: make(map[database.Facet]bool),
+	}
+
+	columns := make([]int, len(dashboardFacets))
+	dashboard.root.SetRows(0, 0).SetColumns(columns...)
+
+	for i, facet := range dashboardFacets {
+		table := dashboardTable(fmt.Sprintf(" Lines per %s ", facet))
+		table.SetSelectedFunc(func(row int, _ int) {
+			t.dashboardFacetSelected(facet, row)
+		})
+		dashboard.facetTables[facet] = table
+		dashboard.focusables = append(dashboard.focusables, table)
+		dashboard.root.AddItem(table, 0, i, 1, 1, 0, 0, i == 0)
+	}
+
+	timeline := dashboardTable(" Lines per minute ")
+	timeline.SetSelectedFunc(t.dashboardBucketSelected)
+	dashboard.timeline = timeline
+	dashboard.focusables = append(dashboard.focusables, timeline)
+	dashboard.root.AddItem(timeline, 1, 0, 1, len(dashboardFacets), 0, 0, false)
+
+	dashboard.root.SetInputCapture(t.dashboardInputHandler)
+	t.dashboard = dashboard
+	t.pages.AddPage(PAGE_DASHBOARD, dashboard.root, true, false)
+}
+
+func dashboardTable(title string) *tview.Table {
+	table := tview.NewTable()
+	table.SetBorder(true)
+	table.SetTitle(title)
+	table.SetFixed(1, 0)
+	table.SetSelectable(true, false)
+	table.SetSelectedStyle(
+		tcell.Style{}.
+			Background(tcell.GetColor("#40ea37")).
+			Foreground(tcell.ColorBlack),
+	)
+	return table
+}
+
+// showDashboard aggregates the lines of the current query in the background
+// and shows the dashboard page.
+func (t *TUI) showDashboard() {
+	if t.linesLoaded() == false {
+		return
+	}
+
+	query := t.query
+	facetRows := make(map[database.Facet][]database.AggregateRow)
+	var timelineRows []database.AggregateRow
+
+	t.runInBackground(
+		"aggregating lines",
+		func(ctx context.Context) error {
+			for _, facet := range dashboardFacets {
+				rows, err := query.Aggregate(ctx, database.Aggregation{GroupBy: facet})
+				if err != nil {
+					return fmt.Errorf("could not count lines per %s: %w", facet, err)
+				}
+				facetRows[facet] = rows
+			}
+
+			var err error
+			timelineRows, err = query.Aggregate(ctx, database.Aggregation{Bucket: dashboardBucket})
+			if err != nil {
+				return fmt.Errorf("could not count lines per minute: %w", err)
+			}
+			return nil
+		},
+		func(err error) {
+			if err != nil {
+				t.showError(err, "Could not aggregate lines: %s", err.Error())
+				return
+			}
+			t.renderDashboard(facetRows, timelineRows)
+		},
+	)
+}
+
+// renderDashboard renders the aggregated lines and shows the dashboard page.
+func (t *TUI) renderDashboard(facetRows map[database.Facet][]database.AggregateRow, timelineRows []database.AggregateRow) {
+	dashboard := t.dashboard
+	maps.Copy(dashboard.facetRows, facetRows)
+	dashboard.timelineRows = timelineRows
+
+	for _, facet := range dashboardFacets {
+		t.renderFacetTable(facet)
+	}
+	t.renderTimeline()
+
+	total := 0
+	for _, row := range dashboard.timelineRows {
+		total += row.Count
+	}
+
+	dashboard.focusIndex = 0
+	t.showPage(PAGE_DASHBOARD, fmt.Sprintf("dashboard -- %d lines", total))
+	t.App.SetFocus(dashboard.focusables[0])
+}
+
+func (t *TUI) renderFacetTable(facet database.Facet) {
+	dashboard := t.dashboard
+	table := dashboard.facetTables[facet]
+	rows := dashboard.facetRows[facet]
+
+	if dashboard.sortByValue[facet] == true {
+		slices.SortStableFunc(rows, func(a, b database.AggregateRow) int {
+			return compareFacetValues(a.Value, b.Value)
+		})
+	} else {
+		slices.SortStableFunc(rows, func(a, b database.AggregateRow) int {
+			return b.Count - a.Count
+		})
+	}
+
+	total := 0
+	for _, row := range rows {
+		total += row.Count
+	}
+
+	table.Clear()
+	table.SetCell(0, 0, dashboardHeaderCell(strings.ToUpper(string(facet)[:1])+string(facet)[1:]))
+	table.SetCell(0, 1, dashboardHeaderCell("Count").SetAlign(tview.AlignRight))
+	table.SetCell(0, 2, dashboardHeaderCell("%").SetAlign(tview.AlignRight))
+	for i, row := range rows {
+		percent := 0.0
+		if total > 0 {
+			percent = float64(row.Count) * 100 / float64(total)
+		}
+		table.SetCell(i+1, 0, tview.NewTableCell(facetValueLabel(facet, row.Value)).SetExpansion(1))
+		table.SetCell(i+1, 1, tview.NewTableCell(strconv.Itoa(row.Count)).SetAlign(tview.AlignRight))
+		table.SetCell(i+1, 2, tview.NewTableCell(fmt.Sprintf("%.1f", percent)).SetAlign(tview.AlignRight))
+	}
+	table.Select(1, 0)
+	table.ScrollToBeginning()
+}
+
+func (t *TUI) renderTimeline() {
+	dashboard := t.dashboard
+	table := dashboard.timeline
+
+	maxCount := 0
+	for _, row := range dashboard.timelineRows {
+		maxCount = max(maxCount, row.Count)
+	}
+
+	table.Clear()
+	table.SetCell(0, 0, dashboardHeaderCell("Minute"))
+	table.SetCell(0, 1, dashboardHeaderCell("Count").SetAlign(tview.AlignRight))
+	table.SetCell(0, 2, dashboardHeaderCell(""))
+	for i, row := range dashboard.timelineRows {
+		start := row.BucketStart.In(time.Now().Location()).Format("2006-01-02 15:04")
+		table.SetCell(i+1, 0, tview.NewTableCell(start).SetTextColor(tcell.ColorYellow))
+		table.SetCell(i+1, 1, tview.NewTableCell(strconv.Itoa(row.Count)).SetAlign(tview.AlignRight))
+		table.SetCell(
+			i+1,
+			2,
+			tview.NewTableCell(horizontalBar(row.Count, maxCount, dashboardBarWidth)).
+				SetTextColor(tcell.GetColor("#73d4e9")).
+				SetExpansion(1),
+		)
+	}
+	table.Select(1, 0)
+	table.ScrollToBeginning()
+}
+
+func dashboardHeaderCell(text string) *tview.TableCell {
+	return tview.NewTableCell(text).
+		SetTextColor(tcell.GetColor("#BB5FB9")).
+		SetAttributes(tcell.AttrBold).
+		SetSelectable(false)
+}
+
+// facetValueLabel renders numeric levels by name, e.g. "Info (30)".
+func facetValueLabel(facet database.Facet, value string) string {
+	switch {
+	case value == "":
+		return "<none>"
+	case facet == database.FacetLevel:
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return value
+		}
+		return fmt.Sprintf("%s (%d)", v0.LevelFromNumber(number).String(), number)
+	}
+	return value
+}
+
+// compareFacetValues orders numeric values, e.g. pids, numerically and all
+// other values lexically.
+func compareFacetValues(a string, b string) int {
+	aNum, aErr := strconv.Atoi(a)
+	bNum, bErr := strconv.Atoi(b)
+	if aErr == nil && bErr == nil {
+		return aNum - bNum
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// horizontalBar renders a bar whose length, relative to width, is
+// proportional to value relative to maxValue. Eighth block characters are
+// used to render fractional cells.
+func horizontalBar(value int, maxValue int, width int) string {
+	if value <= 0 || maxValue <= 0 {
+		return ""
+	}
+
+	eighths := value * width * 8 / maxValue
+	if eighths == 0 {
+		eighths = 1
+	}
+
+	partials := []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+	return strings.Repeat("█", eighths/8) + partials[eighths%8]
+}
+
+func (t *TUI) dashboardInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	t.logger.Trace("received key event in dashboard view", "key", event.Name(), "rune", event.Rune())
+	dashboard := t.dashboard
+
+	switch event.Key() {
+	case tcell.KeyEsc, tcell.KeyBackspace, tcell.KeyBackspace2:
+		t.showPage(PAGE_LINES_TABLE, t.prevPageStatus)
+		t.prevPageStatus = ""
+		t.App.SetFocus(t.linesTable)
+		return nil
+
+	case tcell.KeyTab, tcell.KeyBacktab:
+		step := 1
+		if event.Key() == tcell.KeyBacktab {
+			step = len(dashboard.focusables) - 1
+		}
+		dashboard.focusIndex = (dashboard.focusIndex + step) % len(dashboard.focusables)
+		t.App.SetFocus(dashboard.focusables[dashboard.focusIndex])
+		return nil
+	}
+
+	switch event.Rune() {
+	case 'o':
+		if dashboard.focusIndex < len(dashboardFacets) {
+			facet := dashboardFacets[dashboard.focusIndex]
+			dashboard.sortByValue[facet] = !dashboard.sortByValue[facet]
+			t.renderFacetTable(facet)
+		}
+		return nil
+	}
+
+	return event
+}
+
+// dashboardFacetSelected narrows the current filter to the lines with the
+// selected facet value, and returns to the lines table.
+func (t *TUI) dashboardFacetSelected(facet database.Facet, row int) {
+	rows := t.dashboard.facetRows[facet]
+	if row < 1 || row > len(rows) {
+		return
+	}
+
+	filter := t.filter.WithCondition(facet, rows[row-1].Value)
+	t.showPage(PAGE_LINES_TABLE, "")
+	t.App.SetFocus(t.linesTable)
+	t.applyFilter(filter)
+}
+
+// dashboardBucketSelected narrows the current filter to the lines logged
+// within the selected minute, and returns to the lines table.
+func (t *TUI) dashboardBucketSelected(row int, _ int) {
+	rows := t.dashboard.timelineRows
+	if row < 1 || row > len(rows) {
+		return
+	}
+
+	start := rows[row-1].BucketStart
+	filter := t.filter.WithTimeRange(start, start.Add(dashboardBucket))
+	t.showPage(PAGE_LINES_TABLE, "")
+	t.App.SetFocus(t.linesTable)
+	t.applyFilter(filter)
+}
@@ -0,0 +1,161 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/doctor"
+	"github.com/rivo/tview"
+)
+
+// findingsTable lists the findings of the built-in rules, and findingDetail
+// explains the selected finding.
+var findingsTable *tview.Table
+var findingDetail *tview.TextView
+
+// findingsList is the set of findings currently rendered in findingsTable.
+var findingsList []doctor.Finding
+
+func (t *TUI) initDoctorView() {
+	table := dashboardTable(" Findings (enter: show evidence, esc: back) ")
+	table.SetSelectionChangedFunc(func(row int, _ int) {
+		renderFindingDetail(row)
+	})
+	table.SetSelectedFunc(func(row int, _ int) {
+		t.findingSelected(row)
+	})
+	table.SetInputCapture(t.doctorInputHandler)
+	findingsTable = table
+
+	detail := tview.NewTextView()
+	detail.SetDynamicColors(true)
+	detail.SetWrap(true)
+	detail.SetWordWrap(true)
+	detail.SetBorder(true)
+	detail.SetTitle(" Finding ")
+	findingDetail = detail
+
+	root := tview.NewFlex()
+	root.SetDirection(tview.FlexRow)
+	root.AddItem(table, 0, 1, true)
+	root.AddItem(detail, 8, 0, false)
+	t.pages.AddPage(PAGE_DOCTOR, root, true, false)
+}
+
+func (t *TUI) showDoctor() {
+	db := t.db
+	session := t.session
+	var findings []doctor.Finding
+
+	t.runInBackground(
+		"diagnosing sessions",
+		func(ctx context.Context) error {
+			var err error
+			findings, err = db.Diagnose(ctx, session)
+			return err
+		},
+		func(err error) {
+			if err != nil {
+				t.showError(err, "Could not diagnose sessions: %s", err.Error())
+				return
+			}
+			if len(findings) == 0 {
+				t.showError(nil, "No problems found.")
+				return
+			}
+			findingsList = findings
+
+			renderFindings()
+			findingsTable.Select(1, 0)
+			findingsTable.ScrollToBeginning()
+			renderFindingDetail(1)
+			t.showPage(PAGE_DOCTOR, doctorStatus(findings))
+			t.App.SetFocus(findingsTable)
+		},
+	)
+}
+
+func doctorStatus(findings []doctor.Finding) string {
+	counts := make(map[doctor.Severity]int)
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+	return fmt.Sprintf(
+		"doctor -- %d errors, %d warnings, %d info",
+		counts[doctor.SeverityError],
+		counts[doctor.SeverityWarning],
+		counts[doctor.SeverityInfo],
+	)
+}
+
+func renderFindings() {
+	table := findingsTable
+	table.Clear()
+	table.SetCell(0, 0, dashboardHeaderCell("Severity"))
+	table.SetCell(0, 1, dashboardHeaderCell("Session").SetAlign(tview.AlignRight))
+	table.SetCell(0, 2, dashboardHeaderCell("Rule"))
+	table.SetCell(0, 3, dashboardHeaderCell("Finding"))
+
+	for i, f := range findingsList {
+		row := i + 1
+		session := "all"
+		if f.Session != 0 {
+			session = strconv.Itoa(f.Session)
+		}
+		table.SetCell(row, 0, tview.NewTableCell(f.Severity.String()).SetTextColor(severityColor(f.Severity)))
+		table.SetCell(row, 1, tview.NewTableCell(session).SetAlign(tview.AlignRight))
+		table.SetCell(row, 2, tview.NewTableCell(f.Rule).SetTextColor(tcell.GetColor("#BB5FB9")))
+		table.SetCell(row, 3, tview.NewTableCell(tview.Escape(f.Title)).SetExpansion(1))
+	}
+}
+
+func severityColor(severity doctor.Severity) tcell.Color {
+	switch severity {
+	case doctor.SeverityError:
+		return tcell.GetColor("#FF0000")
+	case doctor.SeverityWarning:
+		return tcell.GetColor("#F57F17")
+	}
+	return tcell.GetColor("#43A047")
+}
+
+// renderFindingDetail shows the explanation of the finding in the row.
+func renderFindingDetail(row int) {
+	findingDetail.Clear()
+	if row < 1 || row > len(findingsList) {
+		return
+	}
+	f := findingsList[row-1]
+
+	builder := new(strings.Builder)
+	fmt.Fprintf(builder, "%s\n\n", tview.Escape(f.Explanation))
+	fmt.Fprintf(builder, "[gray]%d evidence lines, press enter to show them[-]", len(f.LogIds))
+	findingDetail.SetText(builder.String())
+	findingDetail.ScrollToBeginning()
+}
+
+func (t *TUI) doctorInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	t.logger.Trace("received key event in doctor view", "key", event.Name(), "rune", event.Rune())
+
+	switch event.Key() {
+	case tcell.KeyEsc, tcell.KeyBackspace, tcell.KeyBackspace2:
+		t.returnToLines()
+		return nil
+	}
+
+	return remapVimKeys(event)
+}
+
+// findingSelected shows the evidence of the selected finding.
+func (t *TUI) findingSelected(row int) {
+	if row < 1 || row > len(findingsList) {
+		return
+	}
+
+	filter := database.FindingFilter(findingsList[row-1])
+	t.showFilteredLines(filter)
+}
@@ -1,6 +1,8 @@
 package tui
 
 import (
+	"fmt"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -37,6 +39,15 @@ func (t *TUI) setErrorText(text string) {
 	errorTextView.SetText(text)
 }
 
+// showError displays the formatted message in the error modal. The error is
+// only used for logging, and may be nil if there is no underlying error.
+func (t *TUI) showError(err error, msg string, a ...any) {
+	formattedMsg := fmt.Sprintf(msg, a...)
+	t.logger.Error("showing error to user", "errorMsg", formattedMsg, "error", err)
+	t.setErrorText(formattedMsg)
+	t.showModal(PAGE_ERROR_MODAL)
+}
+
 func (t *TUI) errorModalInputHandler(event *tcell.EventKey) *tcell.EventKey {
 	t.logger.Trace("received key event in line detail view", "key", event.Name(), "rune", event.Rune())
 
@@ -0,0 +1,171 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/triage"
+	v0 "github.com/newrelic/node-log-viewer/internal/v0"
+	"github.com/rivo/tview"
+)
+
+// errorsTable lists the groups of error lines, and errorDetail shows the
+// stack frame and sample paths of the selected group.
+var errorsTable *tview.Table
+var errorDetail *tview.TextView
+
+// errorsList is the set of groups currently rendered in errorsTable.
+var errorsList []triage.Group
+
+func (t *TUI) initErrorsView() {
+	table := dashboardTable(" Errors (enter: show occurrences, esc: back) ")
+	table.SetSelectionChangedFunc(func(row int, _ int) {
+		renderErrorDetail(row)
+	})
+	table.SetSelectedFunc(func(row int, _ int) {
+		t.errorGroupSelected(row)
+	})
+	table.SetInputCapture(t.errorsInputHandler)
+	errorsTable = table
+
+	detail := tview.NewTextView()
+	detail.SetDynamicColors(true)
+	detail.SetWrap(true)
+	detail.SetBorder(true)
+	detail.SetTitle(" Error ")
+	errorDetail = detail
+
+	root := tview.NewFlex()
+	root.SetDirection(tview.FlexRow)
+	root.AddItem(table, 0, 1, true)
+	root.AddItem(detail, 10, 0, false)
+	t.pages.AddPage(PAGE_ERRORS, root, true, false)
+}
+
+func (t *TUI) showErrors() {
+	db := t.db
+	session := t.session
+	var groups []triage.Group
+
+	t.runInBackground(
+		"grouping errors",
+		func(ctx context.Context) error {
+			var err error
+			groups, err = db.ErrorGroups(ctx, session)
+			return err
+		},
+		func(err error) {
+			if err != nil {
+				t.showError(err, "Could not group errors: %s", err.Error())
+				return
+			}
+			if len(groups) == 0 {
+				t.showError(nil, "No errors were logged.")
+				return
+			}
+			errorsList = groups
+
+			renderErrors()
+			errorsTable.Select(1, 0)
+			errorsTable.ScrollToBeginning()
+			renderErrorDetail(1)
+			t.showPage(PAGE_ERRORS, errorsStatus(groups))
+			t.App.SetFocus(errorsTable)
+		},
+	)
+}
+
+func errorsStatus(groups []triage.Group) string {
+	count := 0
+	for _, g := range groups {
+		count += g.Count
+	}
+	return fmt.Sprintf("errors -- %d errors in %d groups", count, len(groups))
+}
+
+func renderErrors() {
+	table := errorsTable
+	table.Clear()
+	table.SetCell(0, 0, dashboardHeaderCell("Count").SetAlign(tview.AlignRight))
+	table.SetCell(0, 1, dashboardHeaderCell("First"))
+	table.SetCell(0, 2, dashboardHeaderCell("Last"))
+	table.SetCell(0, 3, dashboardHeaderCell("Level"))
+	table.SetCell(0, 4, dashboardHeaderCell("Code"))
+	table.SetCell(0, 5, dashboardHeaderCell("Syscall"))
+	table.SetCell(0, 6, dashboardHeaderCell("Components"))
+	table.SetCell(0, 7, dashboardHeaderCell("Message"))
+
+	local := time.Now().Location()
+	for i, g := range errorsList {
+		row := i + 1
+		table.SetCell(row, 0, tview.NewTableCell(strconv.Itoa(g.Count)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 1, tview.NewTableCell(g.First.In(local).Format("2006-01-02 15:04:05.000")).SetTextColor(tcell.ColorYellow))
+		table.SetCell(row, 2, tview.NewTableCell(g.Last.In(local).Format("2006-01-02 15:04:05.000")).SetTextColor(tcell.ColorYellow))
+		table.SetCell(row, 3, tview.NewTableCell(v0.LevelFromNumber(g.Level).String()).SetTextColor(errorLevelColor(g.Level)))
+		table.SetCell(row, 4, tview.NewTableCell(tview.Escape(g.Code)).SetTextColor(tcell.GetColor("#FF0000")))
+		table.SetCell(row, 5, tview.NewTableCell(tview.Escape(g.Syscall)))
+		table.SetCell(row, 6, tview.NewTableCell(tview.Escape(strings.Join(g.Components, ", "))).SetTextColor(tcell.GetColor("#BB5FB9")))
+		table.SetCell(row, 7, tview.NewTableCell(tview.Escape(g.Message)).SetExpansion(1))
+	}
+}
+
+func errorLevelColor(level int) tcell.Color {
+	if level >= v0.ERROR {
+		return tcell.GetColor("#FF0000")
+	}
+	return tcell.ColorWhite
+}
+
+// renderErrorDetail shows the details of the group in the row that do not
+// fit the table.
+func renderErrorDetail(row int) {
+	errorDetail.Clear()
+	if row < 1 || row > len(errorsList) {
+		return
+	}
+	g := errorsList[row-1]
+
+	frame := g.Frame
+	if frame == "" {
+		frame = "<no stack frames>"
+	}
+	builder := new(strings.Builder)
+	fmt.Fprintf(builder, "[yellow]Message:[-] %s\n", tview.Escape(g.Message))
+	fmt.Fprintf(builder, "[yellow]Frame:[-] %s\n", tview.Escape(frame))
+	fmt.Fprintf(builder, "[yellow]Components:[-] %s\n", tview.Escape(strings.Join(g.Components, ", ")))
+	if len(g.Paths) > 0 {
+		fmt.Fprintf(builder, "[yellow]Paths:[-]\n")
+		for _, path := range g.Paths {
+			fmt.Fprintf(builder, "  %s\n", tview.Escape(path))
+		}
+	}
+	errorDetail.SetText(builder.String())
+	errorDetail.ScrollToBeginning()
+}
+
+func (t *TUI) errorsInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	t.logger.Trace("received key event in errors view", "key", event.Name(), "rune", event.Rune())
+
+	switch event.Key() {
+	case tcell.KeyEsc, tcell.KeyBackspace, tcell.KeyBackspace2:
+		t.returnToLines()
+		return nil
+	}
+
+	return remapVimKeys(event)
+}
+
+// errorGroupSelected shows the occurrences of the selected group.
+func (t *TUI) errorGroupSelected(row int) {
+	if row < 1 || row > len(errorsList) {
+		return
+	}
+
+	filter := database.ErrorGroupFilter(errorsList[row-1])
+	t.showFilteredLines(filter)
+}
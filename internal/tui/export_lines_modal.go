@@ -1,9 +1,14 @@
 package tui
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"maps"
 	"os"
 
+	"github.com/newrelic/node-log-viewer/internal/database"
+
 	"github.com/rivo/tview"
 )
 
@@ -13,64 +18,95 @@ func (t *TUI) initExportLinesModal() {
 	form.SetButtonsAlign(tview.AlignRight)
 
 	form.AddInputField("Export file:", "", 0, nil, nil)
+	form.AddCheckbox("Include bookmark notes:", false, nil)
 
 	form.AddButton("Export", func() { t.handleExport(form) })
 	form.AddButton("Cancel", func() { t.hideModal(PAGE_EXPORT_LINES) })
 
-	t.pages.AddPage(PAGE_EXPORT_LINES, modal(form, 50, 8), true, false)
+	t.pages.AddPage(PAGE_EXPORT_LINES, modal(form, 50, 10), true, false)
 }
 
 func (t *TUI) handleExport(form *tview.Form) {
-
-	// TODO: we can't update the buttons because the button handler blocks the
-	// main thread. We'd have to wrap that handler in a goroutine in order to
-	// perform the UI updates (along with wrapping the meat of this function).
-	// But we can't get the error pop-up to show when we do that. So, for now,
-	// we are leaving the unresponsive UI in place until we can devise a
-	// solution.
-	//
-	// exportBtn := form.GetButton(0)
-	// cancelBtn := form.GetButton(1)
-	// t.App.QueueUpdateDraw(func() {
-	// 	exportBtn.SetDisabled(true).SetLabel("Exporting ...")
-	// 	cancelBtn.SetDisabled(true)
-	// })
-	//
-	// resetButtons := func() {
-	// 	t.hideModal(PAGE_EXPORT_LINES)
-	// 	exportBtn.SetDisabled(false).SetTitle("Export")
-	// 	cancelBtn.SetDisabled(false)
-	// }
-
 	fileName := form.GetFormItem(0).(*tview.InputField).GetText()
+	includeNotes := form.GetFormItem(1).(*tview.Checkbox).IsChecked()
 	t.logger.Trace("attempting to export filtered lines", "fileName", fileName)
-
-	rows, err := t.query.AllResults()
-	if err != nil {
-		t.hideModal(PAGE_EXPORT_LINES)
-		t.logExportError(err, "Could not fetch filtered lines: %s", err.Error())
+	t.hideModal(PAGE_EXPORT_LINES)
+	if t.linesLoaded() == false {
 		return
 	}
 
-	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_RDWR, 0644)
+	// The export runs in the background, so it works with a copy of the
+	// bookmarks that may be modified while it runs.
+	bookmarks := make(map[int]database.Bookmark)
+	if includeNotes == true {
+		maps.Copy(bookmarks, t.bookmarks)
+	}
+
+	query := t.query
+	var exportErr error
+	var exportMsg string
+	t.runExclusively(
+		"exporting",
+		func(ctx context.Context) error {
+			exportMsg, exportErr = exportLines(ctx, query, fileName, bookmarks)
+			return exportErr
+		},
+		func(err error) {
+			if err != nil {
+				t.logExportError(err, "%s", exportMsg)
+				return
+			}
+			t.logger.Trace("successfully exported filtered lines", "fileName", fileName)
+		},
+	)
+}
+
+// exportLines writes the query's lines to the named file, replacing its
+// contents. Lines with a bookmark in the given set are annotated with the
+// bookmark's note. When an error occurs, or the export is cancelled, the
+// partially written file is removed and a message describing the failure is
+// returned with the error.
+func exportLines(
+	ctx context.Context,
+	query *database.Query,
+	fileName string,
+	bookmarks map[int]database.Bookmark,
+) (msg string, err error) {
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
-		t.hideModal(PAGE_EXPORT_LINES)
-		t.logExportError(err, "Could not open file for writing: %s", err.Error())
-		return
+		return fmt.Sprintf("Could not open file for writing: %s", err.Error()), err
 	}
-	defer file.Close()
+	defer func() {
+		file.Close()
+		if err != nil {
+			os.Remove(fileName)
+		}
+	}()
+
+	// Lines are written as they are read from the database so that exporting
+	// a large set of lines does not require holding all of them in memory.
+	writer := bufio.NewWriter(file)
+	for row, err := range query.Results(ctx) {
+		if err != nil {
+			return fmt.Sprintf("Could not fetch filtered lines: %s", err.Error()), err
+		}
 
-	for _, row := range rows {
-		_, err = file.WriteString(fmt.Sprintf("%s\n", row.Original))
+		line := row.Original
+		if bookmark, found := bookmarks[row.LogId]; found {
+			line = bookmark.Annotate(line)
+		}
+		_, err = writer.WriteString(line + "\n")
 		if err != nil {
-			t.hideModal(PAGE_EXPORT_LINES)
-			t.logExportError(err, "Could not write to file (%s): %s", fileName, err.Error())
-			return
+			return fmt.Sprintf("Could not write to file (%s): %s", fileName, err.Error()), err
 		}
 	}
 
-	t.logger.Trace("successfully exported filtered lines", "fileName", fileName)
-	t.hideModal(PAGE_EXPORT_LINES)
+	err = writer.Flush()
+	if err != nil {
+		return fmt.Sprintf("Could not write to file (%s): %s", fileName, err.Error()), err
+	}
+
+	return "", nil
 }
 
 func (t *TUI) logExportError(err error, msg string, a ...any) {
@@ -0,0 +1,146 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/rivo/tview"
+)
+
+// filterPickerList holds a reference to the list of saved filters. The list
+// is repopulated every time the picker is shown so that newly saved filters
+// are included.
+var filterPickerList *tview.List
+
+func (t *TUI) initFilterPickerModal() {
+	list := tview.NewList()
+	list.SetBorder(true)
+	list.SetTitle(" Saved filters ")
+	list.SetSecondaryTextColor(tcell.ColorGray)
+	list.SetInputCapture(t.filterPickerInputHandler)
+	filterPickerList = list
+
+	t.pages.AddPage(PAGE_FILTER_PICKER, modal(list, 75, 20), true, false)
+}
+
+func (t *TUI) showFilterPicker() {
+	if t.filterStore == nil {
+		t.showError(nil, "Saved filters are not available.")
+		return
+	}
+
+	savedFilters, err := t.filterStore.All()
+	if err != nil {
+		t.showError(err, "Could not load saved filters: %s", err.Error())
+		return
+	}
+
+	filterPickerList.Clear()
+	filterPickerList.AddItem("(none)", "Show all lines", 0, func() {
+		t.hideModal(PAGE_FILTER_PICKER)
+		t.applyFilter(database.Filter{})
+	})
+	for _, saved := range savedFilters {
+		filter := saved.Filter
+		filterPickerList.AddItem(
+			fmt.Sprintf("%s [gray](%s)", filter.Name, saved.Source),
+			filter.Description,
+			0,
+			func() {
+				t.hideModal(PAGE_FILTER_PICKER)
+				t.applyFilter(filter)
+			},
+		)
+	}
+
+	t.showModal(PAGE_FILTER_PICKER)
+}
+
+func (t *TUI) filterPickerInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	t.logger.Trace("received key event in filter picker", "key", event.Name(), "rune", event.Rune())
+
+	switch event.Key() {
+	case tcell.KeyEsc, tcell.KeyBackspace, tcell.KeyBackspace2:
+		t.hideModal(PAGE_FILTER_PICKER)
+		return nil
+	}
+
+	switch event.Rune() {
+	case 'j':
+		return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+	case 'k':
+		return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+	}
+	return event
+}
+
+// saveFilterForm holds a reference to the form used to name the current
+// filter when saving it.
+var saveFilterForm *tview.Form
+
+// saveFilterDestinations are the choices for where a filter is saved. Filters
+// saved to the cache file are available to anyone the cache file is shared
+// with.
+var saveFilterDestinations = []string{"Config file", "Cache file"}
+
+func (t *TUI) initSaveFilterModal() {
+	form := tview.NewForm()
+	form.SetBorder(true)
+	form.SetTitle(" Save current filter ")
+	form.SetButtonsAlign(tview.AlignRight)
+
+	form.AddInputField("Name:", "", 0, nil, nil)
+	form.AddInputField("Description:", "", 0, nil, nil)
+	form.AddDropDown("Save to:", saveFilterDestinations, 0, nil)
+
+	form.AddButton("Save", func() { t.handleSaveFilter(form) })
+	form.AddButton("Cancel", func() { t.hideModal(PAGE_SAVE_FILTER) })
+	saveFilterForm = form
+
+	t.pages.AddPage(PAGE_SAVE_FILTER, modal(form, 60, 11), true, false)
+}
+
+func (t *TUI) showSaveFilterModal() {
+	if t.filterStore == nil {
+		t.showError(nil, "Saved filters are not available.")
+		return
+	}
+	if t.filter.IsEmpty() {
+		t.showError(nil, "There is no filter applied to save. Perform a search first.")
+		return
+	}
+
+	saveFilterForm.GetFormItem(0).(*tview.InputField).SetText(t.filter.Name)
+	saveFilterForm.GetFormItem(1).(*tview.InputField).SetText(t.filter.Description)
+	saveFilterForm.SetFocus(0)
+	t.showModal(PAGE_SAVE_FILTER)
+}
+
+func (t *TUI) handleSaveFilter(form *tview.Form) {
+	filter := t.filter
+	filter.Name = form.GetFormItem(0).(*tview.InputField).GetText()
+	filter.Description = form.GetFormItem(1).(*tview.InputField).GetText()
+	destination, _ := form.GetFormItem(2).(*tview.DropDown).GetCurrentOption()
+
+	if filter.Name == "" {
+		t.hideModal(PAGE_SAVE_FILTER)
+		t.showError(nil, "A filter must have a name.")
+		return
+	}
+
+	var err error
+	switch saveFilterDestinations[destination] {
+	case "Cache file":
+		err = t.filterStore.SaveToCache(filter)
+	default:
+		err = t.filterStore.SaveToConfig(filter)
+	}
+
+	t.hideModal(PAGE_SAVE_FILTER)
+	if err != nil {
+		t.showError(err, "Could not save filter: %s", err.Error())
+		return
+	}
+	t.filter = filter
+}
@@ -0,0 +1,201 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/harvest"
+	"github.com/rivo/tview"
+)
+
+// harvestsBarWidth is the number of cells used for the longest duration bar
+// in the harvests table.
+const harvestsBarWidth = 30
+
+// harvestsTable lists the harvests stitched from the collector lines.
+var harvestsTable *tview.Table
+
+// harvestsList is the set of harvests currently rendered in harvestsTable.
+var harvestsList []harvest.Harvest
+
+// harvestsByDuration indicates if the harvests are sorted by descending
+// duration instead of by start time.
+var harvestsByDuration bool
+
+func (t *TUI) initHarvestsView() {
+	table := dashboardTable(" Harvests (enter: show lines, o: sort by time/duration, esc: back) ")
+	table.SetSelectedFunc(func(row int, _ int) {
+		t.harvestSelected(row)
+	})
+	table.SetInputCapture(t.harvestsInputHandler)
+	harvestsTable = table
+	t.pages.AddPage(PAGE_HARVESTS, table, true, false)
+}
+
+func (t *TUI) showHarvests() {
+	db := t.db
+	session := t.session
+	var harvests []harvest.Harvest
+
+	t.runInBackground(
+		"stitching harvests",
+		func(ctx context.Context) error {
+			var err error
+			harvests, err = db.Harvests(ctx, session)
+			return err
+		},
+		func(err error) {
+			if err != nil {
+				t.showError(err, "Could not stitch harvests: %s", err.Error())
+				return
+			}
+			harvestsList = harvests
+
+			t.renderHarvests()
+			harvestsTable.Select(1, 0)
+			harvestsTable.ScrollToBeginning()
+			t.showPage(PAGE_HARVESTS, harvestsStatus(harvests))
+			t.App.SetFocus(harvestsTable)
+		},
+	)
+}
+
+// harvestsStatus summarizes the harvests by outcome.
+func harvestsStatus(harvests []harvest.Harvest) string {
+	outcomes := make(map[harvest.Outcome]int)
+	for _, h := range harvests {
+		outcomes[h.Outcome]++
+	}
+	return fmt.Sprintf(
+		"harvests -- %d harvests, %d missing, %d failed",
+		len(harvests),
+		outcomes[harvest.OutcomeMissing],
+		outcomes[harvest.OutcomeFailed],
+	)
+}
+
+func (t *TUI) renderHarvests() {
+	if harvestsByDuration == true {
+		slices.SortStableFunc(harvestsList, func(a, b harvest.Harvest) int {
+			return int(b.Duration() - a.Duration())
+		})
+	} else {
+		slices.SortStableFunc(harvestsList, func(a, b harvest.Harvest) int {
+			return a.Start.Compare(b.Start)
+		})
+	}
+
+	var maxDuration time.Duration
+	for _, h := range harvestsList {
+		maxDuration = max(maxDuration, h.Duration())
+	}
+
+	table := harvestsTable
+	table.Clear()
+	table.SetCell(0, 0, dashboardHeaderCell("Start"))
+	table.SetCell(0, 1, dashboardHeaderCell("Pid").SetAlign(tview.AlignRight))
+	table.SetCell(0, 2, dashboardHeaderCell("Method"))
+	table.SetCell(0, 3, dashboardHeaderCell("Duration").SetAlign(tview.AlignRight))
+	table.SetCell(0, 4, dashboardHeaderCell(""))
+	table.SetCell(0, 5, dashboardHeaderCell("Payload").SetAlign(tview.AlignRight))
+	table.SetCell(0, 6, dashboardHeaderCell("Compressed"))
+	table.SetCell(0, 7, dashboardHeaderCell("Outcome"))
+
+	for i, h := range harvestsList {
+		row := i + 1
+		duration := ""
+		if h.End.IsZero() == false {
+			duration = h.Duration().String()
+		}
+		compressed := ""
+		if h.Compressed == true {
+			compressed = "yes"
+		}
+
+		table.SetCell(row, 0, tview.NewTableCell(templateTime(h.Start)).SetTextColor(tcell.ColorYellow))
+		table.SetCell(row, 1, tview.NewTableCell(strconv.Itoa(h.Pid)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 2, tview.NewTableCell(h.Method))
+		table.SetCell(row, 3, tview.NewTableCell(duration).SetAlign(tview.AlignRight))
+		table.SetCell(
+			row,
+			4,
+			tview.NewTableCell(horizontalBar(int(h.Duration()), int(maxDuration), harvestsBarWidth)).
+				SetTextColor(tcell.GetColor("#73d4e9")),
+		)
+		table.SetCell(row, 5, tview.NewTableCell(byteSize(h.PayloadBytes)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 6, tview.NewTableCell(compressed))
+		table.SetCell(row, 7, harvestOutcomeCell(h).SetExpansion(1))
+	}
+}
+
+func harvestOutcomeCell(h harvest.Harvest) *tview.TableCell {
+	// Overlapping harvests may have swapped their responses.
+	suffix := ""
+	if h.Ambiguous == true {
+		suffix = ", ambiguous"
+	}
+
+	switch h.Outcome {
+	case harvest.OutcomeFinished:
+		return tview.NewTableCell(h.Outcome.String() + suffix)
+	case harvest.OutcomeFailed:
+		return tview.NewTableCell(fmt.Sprintf("%s (%d status)%s", h.Outcome, h.Status, suffix)).
+			SetTextColor(tcell.GetColor("#FF0000"))
+	}
+	return tview.NewTableCell("no response" + suffix).SetTextColor(tcell.GetColor("#F57F17"))
+}
+
+// byteSize renders a number of bytes with a binary unit, e.g. "1.5KiB".
+func byteSize(bytes int) string {
+	if bytes <= 0 {
+		return ""
+	}
+	units := []string{"B", "KiB", "MiB", "GiB"}
+	size := float64(bytes)
+	unit := 0
+	for size >= 1024 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d%s", bytes, units[unit])
+	}
+	return fmt.Sprintf("%.1f%s", size, units[unit])
+}
+
+func (t *TUI) harvestsInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	t.logger.Trace("received key event in harvests view", "key", event.Name(), "rune", event.Rune())
+
+	switch event.Key() {
+	case tcell.KeyEsc, tcell.KeyBackspace, tcell.KeyBackspace2:
+		t.returnToLines()
+		return nil
+	}
+
+	switch event.Rune() {
+	case 'o':
+		harvestsByDuration = !harvestsByDuration
+		t.renderHarvests()
+		harvestsTable.Select(1, 0)
+		harvestsTable.ScrollToBeginning()
+		return nil
+	}
+
+	return remapVimKeys(event)
+}
+
+// harvestSelected shows the lines logged by the selected harvest's process
+// while the harvest was in flight.
+func (t *TUI) harvestSelected(row int) {
+	if row < 1 || row > len(harvestsList) {
+		return
+	}
+
+	filter := database.HarvestFilter(harvestsList[row-1])
+	t.showFilteredLines(filter)
+}
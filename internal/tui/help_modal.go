@@ -1,35 +1,128 @@
 package tui
 
 import (
+	"strings"
+
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
+// helpText lists the keys of each view. The keys listed under "Every view"
+// work wherever the view does not take text input.
 var helpText = heredoc.Doc(`
-<up arrow>, <j>: Move selection up
-<down arrow>, <k>: Move selection down
-<enter>: View detail of selection
-<s>: Open search box
-<e>: Export current result set
-<g>: Open go to line box
-<esc>, <backspace>: Return to previous view
-<q>, <ctrl+c>: Quit the application
+Every view
+  <h>: Show this help
+  <esc>: Cancel the running query, or return to the previous view
+  <backspace>: Return to the previous view
+  <up arrow>, <k>: Move selection up
+  <down arrow>, <j>: Move selection down
+  <q>, <ctrl+c>: Quit the application
+
+Lines
+  <enter>: View detail of selection
+  <s>: Open search box
+  <g>: Open go to line box
+  <f>: Pick a saved filter
+  <F>: Save the current search as a named filter
+  <e>: Export current result set
+  <u>: Show the selected filtered line within the unfiltered lines
+  <b>: Toggle a bookmark on the selected line
+  <a>: Attach a note to the selected line
+  <n>, <N>: Move selection to the next or previous bookmark
+  <B>: Open the list of bookmarks
+  <d>: Open the dashboard summarizing the current result set
+  <z>: Zoom out to the lines per time bucket
+  <t>: Open the list of message templates
+  <m>: Chart the memory and CPU samples over time
+  <r>: Open the timeline of harvests sent to the collector
+  <C>: Open the effective configuration of each session
+  <D>: Run the built-in health checks and list their findings
+  <E>: Open the error lines grouped by message, code and stack frame
+  <I>: Open the inventory of instrumented modules
+  <L>: Open the agent state changes of each session
+  <S>: Pick an agent session to scope every view to, or compare sessions
+  <T>: Open the segment tree of each traced transaction
+  <:>: Open the SQL console
+
+Bookmarks
+  <enter>: Go to the bookmarked line
+  <a>: Edit the note of the selected bookmark
+  <x>: Remove the selected bookmark
+
+Dashboard
+  <tab>, <shift+tab>: Move to the next or previous table
+  <enter>: Narrow the lines to the selected value or minute
+  <o>: Sort the selected table by value or by count
+
+Time buckets
+  <enter>: Zoom in to the lines of the selected bucket
+  <+>, <->: Grow or shrink the bucket size
+
+Templates
+  <enter>: Show the lines of the selected template
+  <x>: Hide, or show again, the lines of the selected template
+
+Samples
+  <left arrow>, <right arrow>: Move the cursor
+  <home>, <end>: Move the cursor to the first or last sample
+  <enter>: Show the line logged nearest to the cursor
+
+Harvests
+  <enter>: Show the lines of the selected harvest
+  <o>: Sort by time or by duration
+
+Configuration
+  <tab>: Move between sessions, search and settings
+  </>: Search the settings
+  <enter>: Show the lines of the selected session, or expand a setting
+  <d>: Diff the other sessions against the selected session
+
+Sessions
+  <enter>: Scope every view to the selected session
+  <a>: Scope every view to all sessions
+  <space>: Mark the selected session for comparison
+  <c>: Compare the marked sessions, or all sessions
+
+Transactions
+  <enter>: Show the lines of the selected transaction
+  <space>: Expand or collapse the selected segment
+
+Errors, findings, instrumentation and lifecycle
+  <enter>: Show the lines of the selection
+
+SQL console
+  <enter>: Run the statement
+  <tab>: Move between the statement and the results
+  <ctrl+o>: Open the rows of the results as lines
 `)
 
 func (t *TUI) initHelpModal() {
 	view := tview.NewTextView()
 	view.SetBorder(true)
+	view.SetTitle(" Help (arrows, page up/down: scroll, any other key: close) ")
 	view.SetText(helpText)
 	view.SetInputCapture(t.helpModalInputHandler)
 
-	t.pages.AddPage(PAGE_HELP_FORM, modal(view, 75, 16), true, false)
+	// The text is sized to fit, rather than a fixed height, and scrolls when
+	// the screen is too short to show all of it.
+	height := strings.Count(helpText, "\n") + 2
+	t.pages.AddPage(PAGE_HELP_FORM, fittedModal(view, 75, height), true, false)
 }
 
 func (t *TUI) helpModalInputHandler(event *tcell.EventKey) *tcell.EventKey {
-	t.logger.Trace("received key event in line detail view", "key", event.Name(), "rune", event.Rune())
-	// The help modal can be closed by pressing any key. If this becomes an
-	// issue, we can add a filter at a later date.
+	t.logger.Trace("received key event in help modal", "key", event.Name(), "rune", event.Rune())
+
+	switch event.Key() {
+	case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn, tcell.KeyHome, tcell.KeyEnd:
+		return event
+	}
+	switch event.Rune() {
+	case 'j', 'k':
+		return event
+	}
+
+	// Any other key closes the help modal.
 	t.hideModal(PAGE_HELP_FORM)
 	return event
 }
@@ -0,0 +1,140 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/instrumentation"
+	"github.com/rivo/tview"
+)
+
+// instrumentationTable lists the modules that the agent instrumented.
+var instrumentationTable *tview.Table
+
+// instrumentationList is the set of modules currently rendered in
+// instrumentationTable.
+var instrumentationList []instrumentation.Module
+
+func (t *TUI) initInstrumentationView() {
+	table := dashboardTable(" Instrumentation (enter: show module lines, esc: back) ")
+	table.SetSelectedFunc(func(row int, _ int) {
+		t.instrumentationSelected(row)
+	})
+	table.SetInputCapture(t.instrumentationInputHandler)
+	instrumentationTable = table
+	t.pages.AddPage(PAGE_INSTRUMENTS, table, true, false)
+}
+
+func (t *TUI) showInstrumentation() {
+	db := t.db
+	session := t.session
+	var modules []instrumentation.Module
+
+	t.runInBackground(
+		"building instrumentation inventory",
+		func(ctx context.Context) error {
+			var err error
+			modules, err = db.Instrumentation(ctx, session)
+			return err
+		},
+		func(err error) {
+			if err != nil {
+				t.showError(err, "Could not build instrumentation inventory: %s", err.Error())
+				return
+			}
+			if len(modules) == 0 {
+				t.showError(nil, "No instrumentation was logged. Shims only log the modules they wrap at the trace level.")
+				return
+			}
+			instrumentationList = modules
+
+			renderInstrumentation()
+			instrumentationTable.Select(1, 0)
+			instrumentationTable.ScrollToBeginning()
+			t.showPage(PAGE_INSTRUMENTS, instrumentationStatus(modules))
+			t.App.SetFocus(instrumentationTable)
+		},
+	)
+}
+
+func instrumentationStatus(modules []instrumentation.Module) string {
+	issues := 0
+	for _, m := range modules {
+		if m.Status() != instrumentation.StatusInstrumented {
+			issues++
+		}
+	}
+	return fmt.Sprintf("instrumentation -- %d modules, %d with issues", len(modules), issues)
+}
+
+func renderInstrumentation() {
+	table := instrumentationTable
+	table.Clear()
+	table.SetCell(0, 0, dashboardHeaderCell("Module"))
+	table.SetCell(0, 1, dashboardHeaderCell("Framework"))
+	table.SetCell(0, 2, dashboardHeaderCell("Shims"))
+	table.SetCell(0, 3, dashboardHeaderCell("Properties").SetAlign(tview.AlignRight))
+	table.SetCell(0, 4, dashboardHeaderCell("Wrapped").SetAlign(tview.AlignRight))
+	table.SetCell(0, 5, dashboardHeaderCell("Status"))
+	table.SetCell(0, 6, dashboardHeaderCell("Issues"))
+
+	for i, m := range instrumentationList {
+		row := i + 1
+		table.SetCell(row, 0, tview.NewTableCell(tview.Escape(m.Name)))
+		table.SetCell(row, 1, tview.NewTableCell(tview.Escape(m.Framework)))
+		table.SetCell(row, 2, tview.NewTableCell(strings.Join(m.Shims, ", ")))
+		table.SetCell(row, 3, tview.NewTableCell(strconv.Itoa(m.Properties)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 4, tview.NewTableCell(strconv.Itoa(len(m.Wrapped))).SetAlign(tview.AlignRight))
+		table.SetCell(row, 5, tview.NewTableCell(m.Status().String()).SetTextColor(instrumentationStatusColor(m.Status())))
+		table.SetCell(row, 6, tview.NewTableCell(tview.Escape(instrumentationIssues(m.Issues))).SetTextColor(tcell.GetColor("#FF0000")).SetExpansion(1))
+	}
+}
+
+// instrumentationIssues renders the latest issue, along with the number of
+// earlier ones.
+func instrumentationIssues(issues []instrumentation.Issue) string {
+	switch len(issues) {
+	case 0:
+		return ""
+	case 1:
+		return issues[0].Message
+	}
+	return fmt.Sprintf("%s (+%d more)", issues[len(issues)-1].Message, len(issues)-1)
+}
+
+func instrumentationStatusColor(status instrumentation.Status) tcell.Color {
+	switch status {
+	case instrumentation.StatusInstrumented:
+		return tcell.GetColor("#43A047")
+	case instrumentation.StatusPartial:
+		return tcell.GetColor("#F57F17")
+	}
+	return tcell.GetColor("#FF0000")
+}
+
+func (t *TUI) instrumentationInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	t.logger.Trace("received key event in instrumentation view", "key", event.Name(), "rune", event.Rune())
+
+	switch event.Key() {
+	case tcell.KeyEsc, tcell.KeyBackspace, tcell.KeyBackspace2:
+		t.returnToLines()
+		return nil
+	}
+
+	return remapVimKeys(event)
+}
+
+// instrumentationSelected shows the lines logged while instrumenting the
+// selected module.
+func (t *TUI) instrumentationSelected(row int) {
+	if row < 1 || row > len(instrumentationList) {
+		return
+	}
+
+	filter := database.InstrumentationFilter(instrumentationList[row-1])
+	t.showFilteredLines(filter)
+}
@@ -0,0 +1,157 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/lifecycle"
+	"github.com/rivo/tview"
+)
+
+// lifecycleTable lists the state machine of each session.
+var lifecycleTable *tview.Table
+
+// lifecycleList is the set of lifecycles currently rendered in
+// lifecycleTable.
+var lifecycleList []database.SessionLifecycle
+
+func (t *TUI) initLifecycleView() {
+	table := dashboardTable(" Lifecycle (enter: show state changes, esc: back) ")
+	table.SetSelectedFunc(func(row int, _ int) {
+		t.lifecycleSelected(row)
+	})
+	table.SetInputCapture(t.lifecycleInputHandler)
+	lifecycleTable = table
+	t.pages.AddPage(PAGE_LIFECYCLE, table, true, false)
+}
+
+func (t *TUI) showLifecycle() {
+	db := t.db
+	session := t.session
+	var lifecycles []database.SessionLifecycle
+
+	t.runInBackground(
+		"reconstructing lifecycles",
+		func(ctx context.Context) error {
+			var err error
+			lifecycles, err = db.Lifecycles(ctx, session)
+			return err
+		},
+		func(err error) {
+			if err != nil {
+				t.showError(err, "Could not reconstruct lifecycles: %s", err.Error())
+				return
+			}
+			lifecycleList = lifecycles
+
+			renderLifecycle()
+			lifecycleTable.Select(1, 0)
+			lifecycleTable.ScrollToBeginning()
+			t.showPage(PAGE_LIFECYCLE, lifecycleStatus(lifecycles))
+			t.App.SetFocus(lifecycleTable)
+		},
+	)
+}
+
+func lifecycleStatus(lifecycles []database.SessionLifecycle) string {
+	problems := 0
+	for _, l := range lifecycles {
+		if len(l.Lifecycle.Problems) > 0 {
+			problems++
+		}
+	}
+	return fmt.Sprintf("lifecycle -- %d sessions, %d with problems", len(lifecycles), problems)
+}
+
+func renderLifecycle() {
+	table := lifecycleTable
+	table.Clear()
+	table.SetCell(0, 0, dashboardHeaderCell("Session").SetAlign(tview.AlignRight))
+	table.SetCell(0, 1, dashboardHeaderCell("Pid").SetAlign(tview.AlignRight))
+	table.SetCell(0, 2, dashboardHeaderCell("Start"))
+	table.SetCell(0, 3, dashboardHeaderCell("State"))
+	table.SetCell(0, 4, dashboardHeaderCell("Problems"))
+	table.SetCell(0, 5, dashboardHeaderCell("Phases"))
+
+	for i, l := range lifecycleList {
+		row := i + 1
+		state := l.Lifecycle.State()
+		if state == "" {
+			state = "?"
+		}
+		table.SetCell(row, 0, tview.NewTableCell(strconv.Itoa(l.Id)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 1, tview.NewTableCell(strconv.Itoa(l.Pid)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 2, tview.NewTableCell(templateTime(l.Start)).SetTextColor(tcell.ColorYellow))
+		table.SetCell(row, 3, tview.NewTableCell(state).SetTextColor(lifecycleStateColor(state)))
+		table.SetCell(row, 4, tview.NewTableCell(lifecycleProblems(l.Lifecycle.Problems)).SetTextColor(tcell.GetColor("#FF0000")))
+		table.SetCell(row, 5, tview.NewTableCell(lifecyclePhases(l.Lifecycle.Phases)).SetExpansion(1))
+	}
+}
+
+// lifecyclePhases renders the phases as a compact timeline of states, each
+// followed by the time spent in it, e.g. "connecting 1.5s > connected 5ms".
+func lifecyclePhases(phases []lifecycle.Phase) string {
+	parts := make([]string, 0, len(phases))
+	for _, phase := range phases {
+		parts = append(
+			parts,
+			fmt.Sprintf(
+				"[%s]%s[-] %s",
+				lifecycleStateColor(phase.State).String(),
+				phase.State,
+				phase.Duration().Round(time.Millisecond),
+			),
+		)
+	}
+	return strings.Join(parts, " > ")
+}
+
+func lifecycleProblems(problems []lifecycle.Problem) string {
+	parts := make([]string, 0, len(problems))
+	for _, problem := range problems {
+		text := problem.Kind.String()
+		if problem.Detail != "" {
+			text += " (" + problem.Detail + ")"
+		}
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func lifecycleStateColor(state string) tcell.Color {
+	switch state {
+	case lifecycle.StateStarted:
+		return tcell.GetColor("#43A047")
+	case "disconnected", "errored", "stopping", "stopped":
+		return tcell.GetColor("#FF0000")
+	}
+	return tcell.GetColor("#F57F17")
+}
+
+func (t *TUI) lifecycleInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	t.logger.Trace("received key event in lifecycle view", "key", event.Name(), "rune", event.Rune())
+
+	switch event.Key() {
+	case tcell.KeyEsc, tcell.KeyBackspace, tcell.KeyBackspace2:
+		t.returnToLines()
+		return nil
+	}
+
+	return remapVimKeys(event)
+}
+
+// lifecycleSelected shows the state changes of the selected session, along
+// with the lines that show its problems.
+func (t *TUI) lifecycleSelected(row int) {
+	if row < 1 || row > len(lifecycleList) {
+		return
+	}
+
+	filter := database.LifecycleFilter(lifecycleList[row-1].Lifecycle)
+	t.showFilteredLines(filter)
+}
@@ -15,11 +15,16 @@ type LinesTableContent struct {
 	// requirements.
 	tview.TableContentReadOnly
 	query *database.Query
+
+	// bookmarks are the bookmarked lines keyed by log line id. The map is
+	// shared with the TUI so that toggled bookmarks are reflected immediately.
+	bookmarks map[int]database.Bookmark
 }
 
-func NewLinesTableContent(query *database.Query) *LinesTableContent {
+func NewLinesTableContent(query *database.Query, bookmarks map[int]database.Bookmark) *LinesTableContent {
 	return &LinesTableContent{
-		query: query,
+		query:     query,
+		bookmarks: bookmarks,
 	}
 }
 
@@ -27,12 +32,21 @@ func (t *LinesTableContent) GetCell(rowNumber int, columnNumber int) *tview.Tabl
 	// The sqlite windowing function `row_number()` starts numbering at 1.
 	// The tview widget starts numbering at 0.
 	// So we always need to increment the row number by 1.
-	envelope := t.query.GetRow(rowNumber + 1)
+	if t.query.IsSeparator(rowNumber + 1) {
+		return t.separatorCell(columnNumber)
+	}
+
+	envelope := t.query.GetSummary(rowNumber + 1)
 	if envelope == nil {
 		return nil
 	}
 
 	cell := tview.NewTableCell("")
+	if t.query.HasContext() && t.query.IsHit(rowNumber+1) {
+		// Distinguish the lines that matched the search from the lines that
+		// are only shown for context.
+		cell.SetBackgroundColor(tcell.GetColor("#3B3B1F"))
+	}
 	switch columnNumber {
 	case 0: // Timestamp
 		cell.SetMaxWidth(23).
@@ -41,13 +55,13 @@ func (t *LinesTableContent) GetCell(rowNumber int, columnNumber int) *tview.Tabl
 	case 1: // LogLevel
 		cell.SetMaxWidth(6).
 			SetText(envelope.Level().String()).
-			SetTextColor(t.levelColor(envelope.Level())).
+			SetTextColor(levelColor(envelope.Level())).
 			SetAlign(tview.AlignLeft)
 	case 2: // SourceComponent
 		cell.SetMaxWidth(0).SetText(envelope.Component())
-	case 3: // Expand indicator
+	case 3: // Bookmark and expand indicators
 		cell.SetMaxWidth(3).
-			SetText(t.expandIndicator(envelope)).
+			SetText(t.bookmarkIndicator(rowNumber+1) + t.expandIndicator(envelope)).
 			SetTextColor(tcell.GetColor("#BB5FB9"))
 	case 4: // Log message
 		cell.SetExpansion(1).SetText(envelope.Message())
@@ -65,12 +79,27 @@ func (t *LinesTableContent) GetColumnCount() int {
 	// 1. Timestamp (23 characters wide): e.g `2024-07-03 08:10:41.199`
 	// 2. LogLevel name (6 characters wide): e.g. `Trace `
 	// 3. SourceComponent name (variable width): e.g. `error_tracer  `
-	// 4. Expand indicator (3 characters wide): e.g. ` » `
+	// 4. Bookmark and expand indicators (3 characters wide): e.g. `●» `
 	// 5. Log message (remainder of available screen width)
 	return LINES_TABLE_COLUMN_COUNT
 }
 
-func (t *LinesTableContent) levelColor(level common.LogLevel) tcell.Color {
+// separatorCell renders a row that marks a gap between non-contiguous chunks
+// of lines, similar to the `--` lines written by `grep -C`.
+func (t *LinesTableContent) separatorCell(columnNumber int) *tview.TableCell {
+	cell := tview.NewTableCell("").
+		SetTextColor(tcell.ColorGray).
+		SetSelectable(false)
+	switch columnNumber {
+	case 0:
+		cell.SetText("--")
+	case 4:
+		cell.SetExpansion(1)
+	}
+	return cell
+}
+
+func levelColor(level common.LogLevel) tcell.Color {
 	var color tcell.Color
 	switch {
 	case level.IsTrace():
@@ -89,19 +118,18 @@ func (t *LinesTableContent) levelColor(level common.LogLevel) tcell.Color {
 	return color
 }
 
-func (t *LinesTableContent) expandIndicator(line common.Envelope) string {
-	indicator := " » "
-	result := "   "
-	lineKind := line.Kind()
-	switch {
-	case lineKind == common.TypeDataIncluded:
-		result = indicator
-	case lineKind == common.TypeEmbeddedDataIncluded:
-		result = indicator
-	case lineKind == common.TypeError:
-		result = indicator
-	case lineKind == common.TypeExtraAttributes:
-		result = indicator
+// bookmarkIndicator marks bookmarked lines. It occupies the leading space of
+// the expand indicator column.
+func (t *LinesTableContent) bookmarkIndicator(number int) string {
+	if _, found := t.bookmarks[t.query.LogId(number)]; found {
+		return "●"
+	}
+	return " "
+}
+
+func (t *LinesTableContent) expandIndicator(line *database.LineSummary) string {
+	if line.IsExpandable() == true {
+		return "» "
 	}
-	return result
+	return "  "
 }
@@ -3,9 +3,11 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
-	"github.com/newrelic/node-log-viewer/internal/common"
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/detail"
 	"github.com/rivo/tview"
 )
 
@@ -22,7 +24,8 @@ func (t *TUI) initLinesTableView() {
 	// need to stop using a virtual table, and need the hint in the future.
 	// table.SetEvaluateAllRows(true)
 
-	table.SetContent(NewLinesTableContent(t.query))
+	// The initial set of lines is loaded in the background once the
+	// application has started, see [NewTUI].
 	table.SetSelectable(true, false) // Select by rows only.
 	table.SetSelectedStyle(
 		tcell.Style{}.
@@ -41,31 +44,189 @@ func (t *TUI) linesTableInputHandler(event *tcell.EventKey) *tcell.EventKey {
 
 	// TODO: modals are retaining state between invocations, they shouldn't
 	switch event.Rune() {
+	case ':':
+		t.logger.Trace("showing sql console")
+		t.showSqlConsole()
+		return nil
+
+	case 'a':
+		if t.linesLoaded() == false {
+			return nil
+		}
+		row, _ := t.linesTable.GetSelection()
+		t.logger.Trace("showing bookmark note modal", "row", row)
+		t.showBookmarkNoteModal(t.query.LogId(row + 1))
+		return nil
+
+	case 'b':
+		row, _ := t.linesTable.GetSelection()
+		t.logger.Trace("toggling bookmark", "row", row)
+		t.toggleBookmark(row)
+		return nil
+
+	case 'B':
+		t.logger.Trace("showing bookmarks")
+		t.showBookmarks()
+		return nil
+
+	case 'm':
+		t.logger.Trace("showing sampler")
+		t.showSampler()
+		return nil
+
+	case 'n':
+		t.jumpToBookmark(true)
+		return nil
+
+	case 'N':
+		t.jumpToBookmark(false)
+		return nil
+
+	case 'd':
+		t.logger.Trace("showing dashboard")
+		t.showDashboard()
+		return nil
+
 	case 'e':
 		t.logger.Trace("showing export lines modal")
 		t.showModal(PAGE_EXPORT_LINES)
 		return nil
 
+	case 'f':
+		t.logger.Trace("showing filter picker modal")
+		t.showFilterPicker()
+		return nil
+
+	case 'F':
+		t.logger.Trace("showing save filter modal")
+		t.showSaveFilterModal()
+		return nil
+
 	case 'g':
 		t.logger.Trace("showing go to line modal")
 		t.showModal(PAGE_GOTO_LINE)
 		return nil
 
+	case 'C':
+		t.logger.Trace("showing config")
+		t.showConfig()
+		return nil
+
+	case 'D':
+		t.logger.Trace("showing doctor findings")
+		t.showDoctor()
+		return nil
+
+	case 'E':
+		t.logger.Trace("showing errors")
+		t.showErrors()
+		return nil
+
+	case 'I':
+		t.logger.Trace("showing instrumentation")
+		t.showInstrumentation()
+		return nil
+
+	case 'L':
+		t.logger.Trace("showing lifecycle")
+		t.showLifecycle()
+		return nil
+
+	case 'r':
+		t.logger.Trace("showing harvests")
+		t.showHarvests()
+		return nil
+
+	case 'S':
+		t.logger.Trace("showing sessions")
+		t.showSessions()
+		return nil
+
 	case 's':
 		t.logger.Trace("showing search modal")
-		t.showModal(PAGE_SEARCH_FORM)
+		t.showSearchModal()
+		return nil
+
+	case 't':
+		t.logger.Trace("showing templates")
+		t.showTemplates()
+		return nil
+
+	case 'z':
+		if t.linesLoaded() == false {
+			return nil
+		}
+		row, _ := t.linesTable.GetSelection()
+		t.logger.Trace("showing time buckets", "row", row)
+		var selectTime time.Time
+		if line := t.query.GetSummary(row + 1); line != nil {
+			selectTime = line.Time()
+		}
+		t.showTimeBuckets(selectTime)
+		return nil
+
+	case 'T':
+		t.logger.Trace("showing transactions")
+		t.showTransactions()
+		return nil
+
+	case 'u':
+		row, _ := t.linesTable.GetSelection()
+		t.logger.Trace("jumping to line in unfiltered logs", "row", row)
+		t.showInUnfilteredLines(row)
 		return nil
 	}
 
 	return event
 }
 
+// showInUnfilteredLines replaces the current filtered set of lines with the
+// full set of lines, and selects the line that was highlighted at the given
+// row of the filtered set.
+func (t *TUI) showInUnfilteredLines(row int) {
+	if t.linesLoaded() == false || t.query.IsFiltered() == false {
+		return
+	}
+
+	logId := t.query.LogId(row + 1)
+	if logId == 0 {
+		// Separator rows do not represent a log line.
+		return
+	}
+
+	t.showLogIdInAllLines(logId)
+}
+
+// showLogIdInAllLines replaces the current set of lines with the full set of
+// lines, and selects the line with the given id.
+func (t *TUI) showLogIdInAllLines(logId int) {
+	query := database.SelectAllQuery(t.db, t.logger)
+	if t.session != 0 {
+		query = t.sessionFilter(database.Filter{}).Query(t.db, t.logger)
+	}
+	t.loadQuery(database.Filter{}, query, "loading lines", func() {
+		rowNumber := query.RowNumberOf(logId)
+		if rowNumber == 0 {
+			// The line is not part of the set, e.g. because it belongs to
+			// another session than the one the views are scoped to.
+			t.linesScrollStatus(0, 0)
+			return
+		}
+		t.linesTable.Select(rowNumber-1, 0)
+		t.linesScrollStatus(rowNumber-1, 0)
+	})
+}
+
 // linesScrollStatus is a callback invoked by the log lines table to indicate
 // which line has been highlighted. We use this to update the status bar to
 // show which line, out of the total, is currently highlighted.
 func (t *TUI) linesScrollStatus(row int, _ int) {
 	totalRows := t.linesTable.GetRowCount()
-	t.leftStatus.SetText(fmt.Sprintf("%d / %d", row+1, totalRows))
+	status := fmt.Sprintf("%d / %d", row+1, totalRows)
+	if t.session != 0 {
+		status += fmt.Sprintf(" -- session %d", t.session)
+	}
+	t.leftStatus.SetText(status)
 }
 
 // lineSelected is a callback invoked by the [tview.Table] when a row has been
@@ -73,43 +234,25 @@ func (t *TUI) linesScrollStatus(row int, _ int) {
 // highlighted. This handler will determine the kind of the log line, prepare
 // the line for detailed view, and switch to the detail view.
 func (t *TUI) lineSelected(row int, _ int) {
+	if t.linesLoaded() == false {
+		return
+	}
+
 	// The UI references rows starting from 0.
 	// The database references rows starting from 1.
 	line := t.query.GetRow(row + 1)
-	lines := strings.Split(line.Message(), "\n")
-
-	switch line.Kind() {
-	case common.TypeDataIncluded:
-		preparedLines, err := prepareDataIncludedLines(line)
-		if err != nil {
-			lines = []string{"Error preparing selected line:", "\t" + err.Error()}
-		} else {
-			lines = append(lines, preparedLines...)
-		}
-
-	case common.TypeEmbeddedDataIncluded:
-		preparedLines, err := prepareEmbeddedDataLines(line)
-		if err != nil {
-			t.logger.Error("failed to prepare embedded data line", "error", err)
-			lines = []string{"Error preparing selected line:", "\t" + err.Error()}
-		} else {
-			lines = append(lines, preparedLines...)
-		}
-
-	case common.TypeError:
-		lines = append(lines, prepareErrorLines(line)...)
-
-	case common.TypeExtraAttributes:
-		preparedLines, err := prepareExtraAttrsLines(line)
-		if err != nil {
-			t.logger.Error("failed to prepare extra attributes line for viewing", "error", err)
-			lines = []string{"Error preparing selected line:", "\t" + err.Error()}
-		} else {
-			lines = append(lines, preparedLines...)
-		}
+	if line == nil {
+		// Separator rows in a context view do not have any detail to show.
+		return
+	}
+	lines, err := detail.Lines(line)
+	if err != nil {
+		t.logger.Error("failed to prepare selected line for viewing", "error", err)
+		lines = []string{"Error preparing selected line:", "\t" + err.Error()}
+	}
 
-	case common.TypeMessage:
-		// Nothing to do.
+	if bookmark, found := t.bookmarks[t.query.LogId(row+1)]; found && bookmark.Note != "" {
+		lines = append([]string{"Note: " + bookmark.Note, ""}, lines...)
 	}
 
 	t.lineDetailView.SetText(strings.Join(lines, "\n"))
@@ -1,8 +1,11 @@
 package tui
 
 import (
+	"github.com/gdamore/tcell/v2"
 	"github.com/newrelic/node-log-viewer/internal/common"
 	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/filters"
+	"github.com/newrelic/node-log-viewer/internal/history"
 	"github.com/newrelic/node-log-viewer/internal/log"
 	"github.com/rivo/tview"
 )
@@ -17,6 +20,30 @@ type TUI struct {
 	// `select *` query.
 	query *database.Query
 
+	// filter is the set of criteria that produced the current query. It is
+	// retained so that the current view can be saved as a named filter.
+	filter database.Filter
+
+	// session is the id of the agent session that the lines, and every view
+	// derived from them, are scoped to. It is zero when the lines of all
+	// sessions are shown. See [database.LogsDatabase.Sessions].
+	session int
+
+	// searchHistory is the set of previously executed searches. It may be nil,
+	// in which case searches are not recorded.
+	searchHistory *history.History
+
+	// searchCompletion provides recall and autocomplete for the search modal.
+	searchCompletion *searchCompletion
+
+	// filterStore provides access to the saved named filters. It may be nil,
+	// in which case saved filters are not available.
+	filterStore *filters.Store
+
+	// task is the background task in flight, e.g. a search. It is nil when no
+	// task is running.
+	task *backgroundTask
+
 	// prevQueries is used to keep track of queries as the views are changed.
 	// TODO: might not be necessary? ~ 2026-01-08
 	prevQueries *common.Stack[*database.Query]
@@ -39,6 +66,22 @@ type TUI struct {
 	// filter is applied). It is named PAGE_LINES_TABLE in the pages set.
 	linesTable *tview.Table
 
+	// dashboard summarizes the lines of the current query. It is named
+	// PAGE_DASHBOARD in the pages set.
+	dashboard *dashboardView
+
+	// sqlConsole runs ad-hoc SQL statements against the cache. It is named
+	// PAGE_SQL_CONSOLE in the pages set.
+	sqlConsole *sqlConsoleView
+
+	// config shows the effective configuration of each session. It is named
+	// PAGE_CONFIG in the pages set.
+	config *configView
+
+	// bookmarks are the bookmarked lines keyed by log line id. They are
+	// marked in the lines table.
+	bookmarks map[int]database.Bookmark
+
 	// lineDetailView is used to display the details of a selected log line.
 	// It is named PAGE_LINE_DETAIL in the pages set.
 	lineDetailView *tview.TextView
@@ -57,7 +100,32 @@ type TUI struct {
 	prevPageStatus string
 }
 
-func NewTUI(db *database.LogsDatabase, logger *log.Logger) TUI {
+type Option func(*TUI)
+
+// WithFilterStore provides the store of saved filters that may be picked
+// from while viewing the lines table.
+func WithFilterStore(store *filters.Store) Option {
+	return func(t *TUI) {
+		t.filterStore = store
+	}
+}
+
+// WithSearchHistory provides the history that executed searches are recorded
+// in, and recalled from.
+func WithSearchHistory(searchHistory *history.History) Option {
+	return func(t *TUI) {
+		t.searchHistory = searchHistory
+	}
+}
+
+// WithFilter applies the given filter to the initial set of lines.
+func WithFilter(filter database.Filter) Option {
+	return func(t *TUI) {
+		t.filter = filter
+	}
+}
+
+func NewTUI(db *database.LogsDatabase, logger *log.Logger, opts ...Option) TUI {
 	tui := TUI{
 		App:                tview.NewApplication(),
 		db:                 db,
@@ -66,28 +134,67 @@ func NewTUI(db *database.LogsDatabase, logger *log.Logger) TUI {
 		captureGlobalInput: true,
 	}
 
+	for _, opt := range opts {
+		opt(&tui)
+	}
+
 	stack := common.NewStack[*database.Query]()
 	tui.prevQueries = &stack
-	query := database.SelectAllQuery(db, logger)
-	tui.query = query
+	tui.loadBookmarks()
 
 	tui.initLineDetailView()
 	tui.initLinesTableView()
+	tui.initDashboardView()
+	tui.initSqlConsoleView()
+	tui.initBookmarksView()
+	tui.initTemplatesView()
+	tui.initTimeBucketsView()
+	tui.initHarvestsView()
+	tui.initSessionsView()
+	tui.initLifecycleView()
+	tui.initConfigView()
+	tui.initSamplerView()
+	tui.initTransactionsView()
+	tui.initInstrumentationView()
+	tui.initErrorsView()
+	tui.initDoctorView()
 	tui.initGotoLineModal()
 	tui.initSearchModal()
 	tui.initHelpModal()
 	tui.initExportLinesModal()
 	tui.initErrorModal()
+	tui.initFilterPickerModal()
+	tui.initSaveFilterModal()
+	tui.initBookmarkNoteModal()
 	tui.initStatusBarView()
 	tui.initRootView()
 
 	tui.linesScrollStatus(0, 0) // Initialize the status bar.
+	// The current query is only assigned once it has been materialized, so
+	// that the views do not block on it while the lines are loading.
+	tui.loadQuery(tui.filter, tui.filter.Query(db, logger), "loading lines", func() {
+		tui.linesScrollStatus(0, 0)
+	})
 
 	tui.App.SetRoot(tui.root, true)
 
 	return tui
 }
 
+// linesLoaded indicates if the initial set of lines has been loaded. Until
+// then there is no current query, and the views that read it do nothing.
+func (t *TUI) linesLoaded() bool {
+	return t.query != nil
+}
+
+// Run runs the application until it is stopped. Work in flight is stopped
+// before returning, so that the database can be closed afterward.
+func (t *TUI) Run() error {
+	defer t.stopCompletions()
+	defer t.cancelBackgroundTask()
+	return t.App.Run()
+}
+
 func (t *TUI) hidePage(name string) {
 	t.pages.HidePage(name)
 	t.captureGlobalInput = !t.captureGlobalInput
@@ -109,6 +216,35 @@ func (t *TUI) showPage(name string, status string) {
 	t.leftStatus.SetText(status)
 }
 
+// returnToLines shows the lines table again, along with the status it showed
+// before the current page was shown.
+func (t *TUI) returnToLines() {
+	t.showPage(PAGE_LINES_TABLE, t.prevPageStatus)
+	t.prevPageStatus = ""
+	t.App.SetFocus(t.linesTable)
+}
+
+// showFilteredLines returns to the lines table and replaces its lines with
+// those matching the filter.
+func (t *TUI) showFilteredLines(filter database.Filter) {
+	t.showPage(PAGE_LINES_TABLE, "")
+	t.prevPageStatus = ""
+	t.App.SetFocus(t.linesTable)
+	t.applyFilter(filter)
+}
+
+// remapVimKeys translates the `j` and `k` keys to the down and up keys, so
+// that the tables of the other pages can be navigated like the lines table.
+func remapVimKeys(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Rune() {
+	case 'j':
+		return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+	case 'k':
+		return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+	}
+	return event
+}
+
 func (t *TUI) hideModal(name string) {
 	t.pages.HidePage(name)
 	t.captureGlobalInput = !t.captureGlobalInput
@@ -1,6 +1,9 @@
 package tui
 
-import "github.com/rivo/tview"
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
 
 func modal(primitive tview.Primitive, width int, height int) tview.Primitive {
 	return tview.NewFlex().
@@ -11,3 +14,22 @@ func modal(primitive tview.Primitive, width int, height int) tview.Primitive {
 			AddItem(nil, 0, 1, false), width, 1, true).
 		AddItem(nil, 0, 1, false)
 }
+
+// fittedModal is a [modal] whose height is reduced to the height of the
+// screen, less a line above and below, when the screen is not tall enough to
+// show all of it.
+func fittedModal(primitive tview.Primitive, width int, height int) tview.Primitive {
+	column := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(nil, 0, 1, false).
+		AddItem(primitive, height, 1, true).
+		AddItem(nil, 0, 1, false)
+	column.SetDrawFunc(func(_ tcell.Screen, x int, y int, width int, available int) (int, int, int, int) {
+		column.ResizeItem(primitive, max(min(height, available-2), 0), 1)
+		return x, y, width, available
+	})
+
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(column, width, 1, true).
+		AddItem(nil, 0, 1, false)
+}
@@ -1,13 +1,31 @@
 package tui
 
 const (
-	PAGE_GOTO_LINE    string = "goto_line_modal"
-	PAGE_LINES_TABLE         = "lines_table"
-	PAGE_LINE_DETAIL         = "line_detail"
-	PAGE_SEARCH_FORM         = "search_form"
-	PAGE_HELP_FORM           = "help_form"
-	PAGE_EXPORT_LINES        = "export_lines"
-	PAGE_ERROR_MODAL         = "error_modal"
+	PAGE_GOTO_LINE     string = "goto_line_modal"
+	PAGE_LINES_TABLE          = "lines_table"
+	PAGE_LINE_DETAIL          = "line_detail"
+	PAGE_SEARCH_FORM          = "search_form"
+	PAGE_HELP_FORM            = "help_form"
+	PAGE_EXPORT_LINES         = "export_lines"
+	PAGE_ERROR_MODAL          = "error_modal"
+	PAGE_FILTER_PICKER        = "filter_picker"
+	PAGE_SAVE_FILTER          = "save_filter"
+	PAGE_DASHBOARD            = "dashboard"
+	PAGE_SQL_CONSOLE          = "sql_console"
+	PAGE_BOOKMARKS            = "bookmarks"
+	PAGE_BOOKMARK_NOTE        = "bookmark_note"
+	PAGE_TEMPLATES            = "templates"
+	PAGE_TIME_BUCKETS         = "time_buckets"
+	PAGE_HARVESTS             = "harvests"
+	PAGE_SESSIONS             = "sessions"
+	PAGE_SESSION_DIFF         = "session_diff"
+	PAGE_LIFECYCLE            = "lifecycle"
+	PAGE_CONFIG               = "config"
+	PAGE_SAMPLER              = "sampler"
+	PAGE_TRANSACTIONS         = "transactions"
+	PAGE_INSTRUMENTS          = "instrumentation"
+	PAGE_ERRORS               = "errors"
+	PAGE_DOCTOR               = "doctor"
 )
 
 func (t *TUI) pageShouldCaptureGlobalInput(pageName string) bool {
@@ -26,6 +44,42 @@ func (t *TUI) pageShouldCaptureGlobalInput(pageName string) bool {
 		return false
 	case PAGE_ERROR_MODAL:
 		return false
+	case PAGE_FILTER_PICKER:
+		return false
+	case PAGE_SAVE_FILTER:
+		return false
+	case PAGE_DASHBOARD:
+		return true
+	case PAGE_SQL_CONSOLE:
+		return false
+	case PAGE_BOOKMARKS:
+		return true
+	case PAGE_BOOKMARK_NOTE:
+		return false
+	case PAGE_TEMPLATES:
+		return true
+	case PAGE_TIME_BUCKETS:
+		return true
+	case PAGE_HARVESTS:
+		return true
+	case PAGE_SESSIONS:
+		return true
+	case PAGE_SESSION_DIFF:
+		return true
+	case PAGE_LIFECYCLE:
+		return true
+	case PAGE_CONFIG:
+		return true
+	case PAGE_SAMPLER:
+		return true
+	case PAGE_TRANSACTIONS:
+		return true
+	case PAGE_INSTRUMENTS:
+		return true
+	case PAGE_ERRORS:
+		return true
+	case PAGE_DOCTOR:
+		return true
 	}
 	return false
 }
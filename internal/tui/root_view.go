@@ -19,6 +19,12 @@ func (t *TUI) initRootView() {
 //
 // The majority of event handlers should be located on primitives.
 func (t *TUI) rootInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	// While a query, or other long running task, is in flight, `esc` cancels it
+	// regardless of the view that is showing.
+	if event.Key() == tcell.KeyEsc && t.cancelBackgroundTask() == true {
+		return nil
+	}
+
 	if t.captureGlobalInput == false {
 		// When captureGlobalInput is false, the app should be showing a view that
 		// requires full input control, i.e. one that should not recognize global
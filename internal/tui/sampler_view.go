@@ -0,0 +1,309 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/newrelic/node-log-viewer/internal/sampler"
+	"github.com/rivo/tview"
+)
+
+// samplerChartRows is the height of each chart, in rows of braille cells.
+const samplerChartRows = 3
+
+// samplerCharts is the page that charts the sampler's time series.
+var samplerCharts *samplerChartsView
+
+// samplerChartsView renders each series as a braille line chart, all sharing
+// the same time axis. A cursor marks a point in time across the charts.
+type samplerChartsView struct {
+	*tview.Box
+
+	series []sampler.Series
+	start  time.Time
+	end    time.Time
+
+	// cursor is the column of the time cursor, and top is the index of the
+	// first series shown.
+	cursor int
+	top    int
+	// columns is the width of the charts when they were last drawn.
+	columns int
+}
+
+func (t *TUI) initSamplerView() {
+	view := &samplerChartsView{Box: tview.NewBox()}
+	view.SetBorder(true)
+	view.SetTitle(" Samples (left/right: move cursor, enter: show nearest line, esc: back) ")
+	view.SetInputCapture(t.samplerInputHandler)
+	samplerCharts = view
+	t.pages.AddPage(PAGE_SAMPLER, view, true, false)
+}
+
+func (t *TUI) showSampler() {
+	db := t.db
+	session := t.session
+	var series []sampler.Series
+
+	t.runInBackground(
+		"extracting samples",
+		func(ctx context.Context) error {
+			var err error
+			series, err = db.Samples(ctx, session)
+			return err
+		},
+		func(err error) {
+			if err != nil {
+				t.showError(err, "Could not extract samples: %s", err.Error())
+				return
+			}
+			if len(series) == 0 {
+				t.showError(nil, "No samples were logged. The sampler only logs its samples at the trace level.")
+				return
+			}
+
+			view := samplerCharts
+			view.series = series
+			view.start, view.end = series[0].Points[0].Time, series[0].Points[0].Time
+			for _, s := range series {
+				view.start = minTime(view.start, s.Points[0].Time)
+				view.end = maxTime(view.end, s.Points[len(s.Points)-1].Time)
+			}
+			view.cursor = 0
+			view.top = 0
+
+			t.showPage(PAGE_SAMPLER, view.status())
+			t.App.SetFocus(view)
+		},
+	)
+}
+
+func minTime(a time.Time, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a time.Time, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// cursorTime is the time at the middle of the cursor's column.
+func (v *samplerChartsView) cursorTime() time.Time {
+	columns := max(v.columns, 1)
+	span := v.end.Sub(v.start)
+	return v.start.Add(time.Duration((float64(v.cursor) + 0.5) / float64(columns) * float64(span)))
+}
+
+func (v *samplerChartsView) status() string {
+	return fmt.Sprintf(
+		"samples -- %d series, cursor at %s",
+		len(v.series),
+		v.cursorTime().In(time.Now().Location()).Format("15:04:05.000"),
+	)
+}
+
+func (v *samplerChartsView) Draw(screen tcell.Screen) {
+	v.DrawForSubclass(screen, v)
+	x, y, width, height := v.GetInnerRect()
+	if width <= 0 || height <= 1 {
+		return
+	}
+	v.columns = width
+	v.cursor = min(max(v.cursor, 0), width-1)
+	at := v.cursorTime()
+
+	lineStyle := tcell.StyleDefault.Foreground(tcell.GetColor("#40ea37"))
+	row := y
+	// The last row holds the time axis.
+	bottom := y + height - 1
+	for _, series := range v.series[min(v.top, len(v.series)):] {
+		if row+1+samplerChartRows > bottom {
+			break
+		}
+
+		low, high := series.Range()
+		tview.Print(
+			screen,
+			fmt.Sprintf(
+				"[#BB5FB9]%s[-] %s [gray](min %s, max %s)[-]",
+				tview.Escape(series.Name),
+				series.Unit.Format(series.At(at)),
+				series.Unit.Format(low),
+				series.Unit.Format(high),
+			),
+			x, row, width, tview.AlignLeft, tcell.ColorWhite,
+		)
+		row++
+
+		cells := brailleChart(series.Resample(v.start, v.end, width*2), width, samplerChartRows, low, high)
+		for r, cellRow := range cells {
+			for c, cell := range cellRow {
+				style := lineStyle
+				if c == v.cursor {
+					style = style.Background(tcell.GetColor("#444444"))
+				}
+				screen.SetContent(x+c, row+r, cell, nil, style)
+			}
+		}
+		row += samplerChartRows
+	}
+
+	local := time.Now().Location()
+	tview.Print(screen, "[yellow]"+v.start.In(local).Format("15:04:05")+"[-]", x, bottom, width, tview.AlignLeft, tcell.ColorWhite)
+	tview.Print(screen, "[yellow]"+v.end.In(local).Format("15:04:05")+"[-]", x, bottom, width, tview.AlignRight, tcell.ColorWhite)
+	tview.Print(screen, "^", x+v.cursor, bottom, 1, tview.AlignLeft, tcell.ColorWhite)
+}
+
+// brailleChart renders the values as a line chart of braille characters, two
+// values per character. Values are scaled between low and high, and NaN values
+// are left blank. Consecutive values are connected with vertical strokes.
+func brailleChart(values []float64, columns int, rows int, low float64, high float64) [][]rune {
+	// Bits of the dots in a braille cell, by column and then by row.
+	dots := [2][4]rune{{0x01, 0x02, 0x04, 0x40}, {0x08, 0x10, 0x20, 0x80}}
+	levels := rows * 4
+
+	cells := make([][]rune, rows)
+	for r := range cells {
+		cells[r] = make([]rune, columns)
+		for c := range cells[r] {
+			cells[r][c] = 0x2800
+		}
+	}
+
+	previous := -1
+	for i, value := range values {
+		if i >= columns*2 {
+			break
+		}
+		if math.IsNaN(value) {
+			previous = -1
+			continue
+		}
+		level := 0
+		if high > low {
+			level = int(math.Round((value - low) / (high - low) * float64(levels-1)))
+		}
+		from, to := level, level
+		if previous >= 0 {
+			from, to = min(previous, level), max(previous, level)
+		}
+		for l := from; l <= to; l++ {
+			dotRow := levels - 1 - l
+			cells[dotRow/4][i/2] |= dots[i%2][dotRow%4]
+		}
+		previous = level
+	}
+	return cells
+}
+
+func (t *TUI) samplerInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	t.logger.Trace("received key event in sampler view", "key", event.Name(), "rune", event.Rune())
+	view := samplerCharts
+	cursor := view.cursor
+
+	switch event.Key() {
+	case tcell.KeyEsc, tcell.KeyBackspace, tcell.KeyBackspace2:
+		t.returnToLines()
+		return nil
+	case tcell.KeyEnter:
+		t.samplerCursorSelected()
+		return nil
+	case tcell.KeyLeft:
+		view.cursor = max(view.cursor-1, 0)
+	case tcell.KeyRight:
+		view.cursor = min(view.cursor+1, max(view.columns-1, 0))
+	case tcell.KeyHome:
+		view.cursor = 0
+	case tcell.KeyEnd:
+		view.cursor = max(view.columns-1, 0)
+	case tcell.KeyDown:
+		view.top = min(view.top+1, max(len(view.series)-1, 0))
+	case tcell.KeyUp:
+		view.top = max(view.top-1, 0)
+	}
+
+	switch event.Rune() {
+	case 'j':
+		view.top = min(view.top+1, max(len(view.series)-1, 0))
+	case 'k':
+		view.top = max(view.top-1, 0)
+	}
+
+	t.leftStatus.SetText(view.status())
+	if view.cursor != cursor {
+		t.syncSamplerCursor()
+	}
+	return nil
+}
+
+// samplerCursorSelected shows the line logged closest to the cursor within
+// the unfiltered lines.
+func (t *TUI) samplerCursorSelected() {
+	t.findNearestLine(func(logId int) {
+		t.showPage(PAGE_LINES_TABLE, "")
+		t.prevPageStatus = ""
+		t.App.SetFocus(t.linesTable)
+		t.showLogIdInAllLines(logId)
+	})
+}
+
+// syncSamplerCursor highlights the line logged closest to the cursor in the
+// lines table, so that it is highlighted when returning to the lines. Nothing
+// is highlighted when the line is not part of the current set of lines.
+func (t *TUI) syncSamplerCursor() {
+	if t.linesLoaded() == false {
+		return
+	}
+	if t.task != nil && t.task.exclusive == true {
+		// Moving the cursor must not interrupt an export.
+		return
+	}
+	t.findNearestLine(func(logId int) {
+		row := t.query.RowNumberOf(logId)
+		if row == 0 {
+			return
+		}
+		// Selecting a row shows its position in the status bar, which is
+		// restored when returning to the lines.
+		t.linesTable.Select(row-1, 0)
+		t.prevPageStatus = t.leftStatus.GetText(false)
+		t.leftStatus.SetText(samplerCharts.status())
+	})
+}
+
+// findNearestLine looks up the line logged closest to the cursor in the
+// background, and passes its id to found. found is not invoked when there are
+// no lines.
+func (t *TUI) findNearestLine(found func(logId int)) {
+	db := t.db
+	session := t.session
+	at := samplerCharts.cursorTime()
+	var logId int
+
+	t.runInBackground(
+		"finding nearest line",
+		func(ctx context.Context) error {
+			var err error
+			logId, err = db.NearestLogId(ctx, at, session)
+			return err
+		},
+		func(err error) {
+			if err != nil {
+				t.showError(err, "Could not find the nearest line: %s", err.Error())
+				return
+			}
+			if logId == 0 {
+				return
+			}
+			found(logId)
+		},
+	)
+}
@@ -0,0 +1,262 @@
+package tui
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/history"
+	"github.com/rivo/tview"
+)
+
+// maxSearchSuggestions limits the number of entries in the search term
+// autocomplete drop-down.
+const maxSearchSuggestions = 10
+
+// searchCompletion provides recall of previous searches, and autocomplete of
+// search terms, for the search modal's term input field.
+type searchCompletion struct {
+	history *history.History
+
+	// components and fields are the distinct values available in the cache.
+	// They are loaded in the background the first time the search modal is
+	// shown, and are empty until then.
+	loading    bool
+	components []string
+	fields     []string
+
+	// cancel stops loading the values, and loaded is closed once the loading
+	// has stopped, see [TUI.stopCompletions].
+	cancel context.CancelFunc
+	loaded chan struct{}
+
+	// recallIndex is the position, within the recent history entries, of the
+	// entry currently shown in the input field. A value of -1 indicates the
+	// user's own input, i.e. draft, is shown.
+	recallIndex int
+	draft       string
+
+	// suggesting is true while the autocomplete drop-down is showing. The
+	// up and down keys navigate the drop-down, instead of the history, while
+	// it is showing.
+	suggesting bool
+}
+
+// ftsBareword matches the strings that may be used in an fts5 query without
+// being quoted.
+var ftsBareword = regexp.MustCompile(`^[\p{L}\p{N}_]+$`)
+
+// showSearchModal resets the history recall position, starts loading the
+// autocomplete values if needed, and shows the search modal.
+func (t *TUI) showSearchModal() {
+	completion := t.searchCompletion
+	completion.recallIndex = -1
+	completion.suggesting = false
+
+	if completion.loading == false {
+		completion.loading = true
+		t.loadCompletions()
+	}
+
+	t.showModal(PAGE_SEARCH_FORM)
+}
+
+// loadCompletions reads the component and field names for autocomplete. Reading
+// the field names decodes a sample of the lines, so they are read in a
+// goroutine of their own, rather than as a background task, to keep the search
+// modal usable, and other tasks from being cancelled, while they load.
+func (t *TUI) loadCompletions() {
+	db := t.db
+	completion := t.searchCompletion
+	ctx, cancel := context.WithCancel(context.Background())
+	completion.cancel = cancel
+	completion.loaded = make(chan struct{})
+
+	go func() {
+		defer close(completion.loaded)
+		components, err := db.Components(ctx)
+		if err != nil && ctx.Err() == nil {
+			t.logger.Error("could not load component names for autocomplete", "error", err)
+		}
+		fields, err := db.FieldNames(ctx)
+		if err != nil && ctx.Err() == nil {
+			t.logger.Error("could not load field names for autocomplete", "error", err)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		t.App.QueueUpdate(func() {
+			completion.components = components
+			completion.fields = fields
+		})
+	}()
+}
+
+// stopCompletions stops loading the autocomplete values, and waits for the
+// loading to stop, so that the database can be closed.
+func (t *TUI) stopCompletions() {
+	completion := t.searchCompletion
+	if completion.cancel == nil {
+		return
+	}
+	completion.cancel()
+	<-completion.loaded
+}
+
+// searchTermInputHandler intercepts the up and down keys, when the
+// autocomplete drop-down is not showing, in order to recall previously
+// executed searches.
+func (t *TUI) searchTermInputHandler(form *tview.Form) func(event *tcell.EventKey) *tcell.EventKey {
+	return func(event *tcell.EventKey) *tcell.EventKey {
+		completion := t.searchCompletion
+		switch event.Key() {
+		case tcell.KeyUp:
+			if completion.suggesting == true {
+				return event
+			}
+			t.recallSearch(form, 1)
+			return nil
+
+		case tcell.KeyDown:
+			if completion.suggesting == true {
+				return event
+			}
+			t.recallSearch(form, -1)
+			return nil
+
+		case tcell.KeyEscape, tcell.KeyEnter, tcell.KeyTab, tcell.KeyBacktab:
+			completion.suggesting = false
+		}
+		return event
+	}
+}
+
+// recallSearch replaces the search term, and match mode, with an older
+// (direction 1) or newer (direction -1) entry from the search history.
+func (t *TUI) recallSearch(form *tview.Form, direction int) {
+	completion := t.searchCompletion
+	if completion.history == nil {
+		return
+	}
+
+	input := form.GetFormItem(0).(*tview.InputField)
+	recent := completion.history.Recent()
+	next := completion.recallIndex + direction
+	switch {
+	case next >= len(recent):
+		return
+	case next < 0:
+		completion.recallIndex = -1
+		input.SetText(completion.draft)
+		return
+	}
+
+	if completion.recallIndex == -1 {
+		completion.draft = input.GetText()
+	}
+	completion.recallIndex = next
+
+	entry := recent[next]
+	input.SetText(entry.Term)
+	for i, mode := range database.SearchModes {
+		if mode == entry.Mode {
+			form.GetFormItem(1).(*tview.DropDown).SetCurrentOption(i)
+		}
+	}
+}
+
+// autocomplete provides the entries for the search term autocomplete
+// drop-down.
+func (c *searchCompletion) autocomplete(currentText string) []string {
+	if currentText == "" {
+		c.suggesting = false
+		return nil
+	}
+
+	entries := c.suggest(currentText)
+	c.suggesting = len(entries) > 0
+	return entries
+}
+
+// suggest builds the completions for the given input. Previously executed
+// searches that fuzzily match the whole input are listed first, followed by
+// completions of the last word of the input from the fts5 column names,
+// the component names, and the field names present in the cache.
+func (c *searchCompletion) suggest(input string) []string {
+	result := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(entry string) {
+		if entry == input || seen[entry] == true || len(result) == maxSearchSuggestions {
+			return
+		}
+		seen[entry] = true
+		result = append(result, entry)
+	}
+
+	if c.history != nil {
+		for _, term := range c.history.Suggest(input, maxSearchSuggestions/2) {
+			add(term)
+		}
+	}
+
+	prefix, token := splitLastToken(input)
+	if token == "" {
+		return result
+	}
+
+	column, value, isColumnFilter := strings.Cut(token, ":")
+	if isColumnFilter == true {
+		if strings.EqualFold(column, "component") {
+			for _, component := range c.components {
+				if hasPrefixFold(component, value) {
+					add(prefix + column + ":" + quoteIfNeeded(component))
+				}
+			}
+		}
+		return result
+	}
+
+	for _, column := range database.SearchColumns() {
+		if hasPrefixFold(column, token) {
+			add(prefix + column + ":")
+		}
+	}
+	for _, component := range c.components {
+		if hasPrefixFold(component, token) {
+			add(prefix + quoteIfNeeded(component))
+		}
+	}
+	for _, field := range c.fields {
+		if hasPrefixFold(field, token) {
+			add(prefix + quoteIfNeeded(field))
+		}
+	}
+
+	return result
+}
+
+// splitLastToken splits the input into everything up to, and including, the
+// final space, and the final word.
+func splitLastToken(input string) (string, string) {
+	i := strings.LastIndex(input, " ")
+	if i == -1 {
+		return "", input
+	}
+	return input[:i+1], input[i+1:]
+}
+
+func hasPrefixFold(s string, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// quoteIfNeeded wraps values that are not valid fts5 barewords, e.g.
+// `custom-event-aggregator`, in double quotes.
+func quoteIfNeeded(value string) string {
+	if ftsBareword.MatchString(value) {
+		return value
+	}
+	return `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+}
@@ -1,6 +1,9 @@
 package tui
 
 import (
+	"context"
+	"strconv"
+
 	"github.com/newrelic/node-log-viewer/internal/database"
 	"github.com/rivo/tview"
 )
@@ -12,34 +15,116 @@ func (t *TUI) initSearchModal() {
 
 	// TODO: create a robust form that allows for searching by component or message. Maybe
 	// tailor it to FTS5 specific queries 🤷‍♂️.
+	t.searchCompletion = &searchCompletion{
+		history:     t.searchHistory,
+		recallIndex: -1,
+	}
+	termInput := tview.NewInputField().SetLabel("Search term(s):")
+	termInput.SetAutocompleteFunc(t.searchCompletion.autocomplete)
+	termInput.SetInputCapture(t.searchTermInputHandler(form))
+	form.AddFormItem(termInput)
+
+	// "Words" matches whole tokens and supports fts5 query syntax. "Substring"
+	// matches partial words, e.g. `aggreg` will match `base_aggregator`.
+	modeOptions := make([]string, 0, len(database.SearchModes))
+	for _, mode := range database.SearchModes {
+		modeOptions = append(modeOptions, mode.String())
+	}
+	form.AddDropDown("Match:", modeOptions, 0, nil)
+
+	// Similar to `grep -C`, show a number of surrounding lines with each
+	// matching line.
 	form.AddInputField(
-		"Search term(s):",
-		"",
-		0,
-		nil,
+		"Context lines:",
+		"0",
+		5,
+		func(textToCheck string, _ rune) bool {
+			// An empty field is the same as no context lines, and must be
+			// accepted so that the default can be erased.
+			if textToCheck == "" {
+				return true
+			}
+			_, err := strconv.ParseUint(textToCheck, 10, 0)
+			return err == nil
+		},
 		nil,
 	)
 
 	form.AddButton("Search", func() { t.handleSearch(form) })
 	form.AddButton("Cancel", func() { t.hideModal(PAGE_SEARCH_FORM) })
 
-	t.pages.AddPage(PAGE_SEARCH_FORM, modal(form, 50, 8), true, false)
+	t.pages.AddPage(PAGE_SEARCH_FORM, modal(form, 50, 11), true, false)
 }
 
 func (t *TUI) handleSearch(form *tview.Form) {
 	searchTerm := form.GetFormItem(0).(*tview.InputField).GetText()
+	modeIndex, _ := form.GetFormItem(1).(*tview.DropDown).GetCurrentOption()
+	mode := database.SearchModeWords
+	if modeIndex >= 0 {
+		mode = database.SearchModes[modeIndex]
+	}
 
-	var query *database.Query
-	if searchTerm == "" {
-		query = database.SelectAllQuery(t.db, t.logger)
-	} else {
-		query = database.SearchQuery(searchTerm, t.db, t.logger)
+	contextLines, _ := strconv.Atoi(form.GetFormItem(2).(*tview.InputField).GetText())
+
+	if searchTerm != "" && t.searchHistory != nil {
+		err := t.searchHistory.Add(searchTerm, mode)
+		if err != nil {
+			t.logger.Error("could not record search history", "error", err)
+		}
 	}
-	content := NewLinesTableContent(query)
 
-	t.query = query
-	t.linesTable.SetContent(content)
 	t.hideModal(PAGE_SEARCH_FORM)
-	t.linesScrollStatus(0, 0)
-	t.linesTable.Select(0, 0)
+	t.applyFilter(database.Filter{
+		Search:       searchTerm,
+		Mode:         mode,
+		ContextLines: contextLines,
+	})
+}
+
+// applyFilter replaces the current set of lines with the lines matching the
+// given filter. The lines are queried in the background; the current set of
+// lines remains in place until the query has finished.
+func (t *TUI) applyFilter(filter database.Filter) {
+	t.logger.Trace("applying filter", "filter", filter)
+	query := t.sessionFilter(filter).Query(t.db, t.logger)
+	t.loadQuery(filter, query, "searching", func() {
+		t.linesScrollStatus(0, 0)
+		t.linesTable.Select(0, 0)
+	})
+}
+
+// loadQuery materializes the query in the background and then replaces the
+// current set of lines with the query's result set. The ready callback is
+// invoked, on the event loop, once the lines have been replaced. Templates
+// are mined first when the filter uses them.
+func (t *TUI) loadQuery(filter database.Filter, query *database.Query, label string, ready func()) {
+	db := t.db
+	t.runInBackground(
+		label,
+		func(ctx context.Context) error {
+			if filter.UsesTemplates() {
+				err := db.MineTemplates(ctx)
+				if err != nil {
+					return err
+				}
+			}
+			return query.Materialize(ctx)
+		},
+		func(err error) {
+			if err != nil {
+				query.Close()
+				t.showError(err, "Could not query lines: %s", err.Error())
+				return
+			}
+
+			previous := t.query
+			t.filter = filter
+			t.query = query
+			t.linesTable.SetContent(NewLinesTableContent(query, t.bookmarks))
+			if previous != nil && previous != query {
+				previous.Close()
+			}
+			ready()
+		},
+	)
 }
@@ -0,0 +1,348 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/session"
+	"github.com/rivo/tview"
+)
+
+// sessionComparisonComponents is the number of components compared across
+// sessions.
+const sessionComparisonComponents = 10
+
+// sessionsTable lists the agent sessions of the log.
+var sessionsTable *tview.Table
+
+// sessionsList is the set of sessions currently rendered in sessionsTable.
+var sessionsList []session.Session
+
+// sessionsMarked holds the ids of the sessions marked for comparison.
+var sessionsMarked = make(map[int]bool)
+
+// sessionComparisonTable compares the marked sessions side by side.
+var sessionComparisonTable *tview.Table
+
+// sessionsPrevStatus is the status of the page the sessions page was opened
+// from. It is restored when returning from the comparison page.
+var sessionsPrevStatus string
+
+func (t *TUI) initSessionsView() {
+	table := dashboardTable(" Sessions (enter: scope to session, a: all sessions, space: mark, c: compare, esc: back) ")
+	table.SetSelectedFunc(func(row int, _ int) {
+		t.sessionSelected(row)
+	})
+	table.SetInputCapture(t.sessionsInputHandler)
+	sessionsTable = table
+	t.pages.AddPage(PAGE_SESSIONS, table, true, false)
+
+	comparison := dashboardTable(" Session comparison (esc: back) ")
+	comparison.SetFixed(1, 1)
+	comparison.SetInputCapture(t.sessionComparisonInputHandler)
+	sessionComparisonTable = comparison
+	t.pages.AddPage(PAGE_SESSION_DIFF, comparison, true, false)
+}
+
+// sessionFilter limits the filter to the lines of the session that the views
+// are scoped to, if any.
+func (t *TUI) sessionFilter(filter database.Filter) database.Filter {
+	if t.session == 0 {
+		return filter
+	}
+	return filter.WithCondition(database.FacetSession, strconv.Itoa(t.session))
+}
+
+func (t *TUI) showSessions() {
+	db := t.db
+	var sessions []session.Session
+
+	t.runInBackground(
+		"reading sessions",
+		func(_ context.Context) error {
+			var err error
+			sessions, err = db.Sessions()
+			return err
+		},
+		func(err error) {
+			if err != nil {
+				t.showError(err, "Could not read sessions: %s", err.Error())
+				return
+			}
+			sessionsList = sessions
+
+			t.renderSessions()
+			selected := 1
+			for i, s := range sessions {
+				if s.Id == t.session {
+					selected = i + 1
+				}
+			}
+			sessionsTable.Select(selected, 0)
+			t.showPage(PAGE_SESSIONS, t.sessionsStatus())
+			t.App.SetFocus(sessionsTable)
+		},
+	)
+}
+
+func (t *TUI) sessionsStatus() string {
+	scope := "all sessions"
+	if t.session != 0 {
+		scope = fmt.Sprintf("scoped to session %d", t.session)
+	}
+	return fmt.Sprintf("sessions -- %d sessions, %s", len(sessionsList), scope)
+}
+
+func (t *TUI) renderSessions() {
+	maxLines := 0
+	for _, s := range sessionsList {
+		maxLines = max(maxLines, s.Lines)
+	}
+
+	table := sessionsTable
+	table.Clear()
+	table.SetCell(0, 0, dashboardHeaderCell(""))
+	table.SetCell(0, 1, dashboardHeaderCell("Id").SetAlign(tview.AlignRight))
+	table.SetCell(0, 2, dashboardHeaderCell("Pid").SetAlign(tview.AlignRight))
+	table.SetCell(0, 3, dashboardHeaderCell("Host"))
+	table.SetCell(0, 4, dashboardHeaderCell("Agent"))
+	table.SetCell(0, 5, dashboardHeaderCell("Node"))
+	table.SetCell(0, 6, dashboardHeaderCell("Start"))
+	table.SetCell(0, 7, dashboardHeaderCell("End"))
+	table.SetCell(0, 8, dashboardHeaderCell("Duration").SetAlign(tview.AlignRight))
+	table.SetCell(0, 9, dashboardHeaderCell("Lines").SetAlign(tview.AlignRight))
+	table.SetCell(0, 10, dashboardHeaderCell(""))
+
+	for i, s := range sessionsList {
+		row := i + 1
+		marker := ""
+		if s.Id == t.session {
+			marker += "*"
+		}
+		if sessionsMarked[s.Id] == true {
+			marker += "+"
+		}
+
+		table.SetCell(row, 0, tview.NewTableCell(marker).SetTextColor(tcell.ColorGreen))
+		table.SetCell(row, 1, tview.NewTableCell(strconv.Itoa(s.Id)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 2, tview.NewTableCell(strconv.Itoa(s.Pid)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 3, tview.NewTableCell(s.Hostname))
+		table.SetCell(row, 4, tview.NewTableCell(sessionVersion(s.AgentVersion)))
+		table.SetCell(row, 5, tview.NewTableCell(sessionVersion(s.NodeVersion)))
+		table.SetCell(row, 6, tview.NewTableCell(templateTime(s.Start)).SetTextColor(tcell.ColorYellow))
+		table.SetCell(row, 7, tview.NewTableCell(templateTime(s.End)).SetTextColor(tcell.ColorYellow))
+		table.SetCell(row, 8, tview.NewTableCell(sessionDuration(s)).SetAlign(tview.AlignRight))
+		table.SetCell(row, 9, tview.NewTableCell(strconv.Itoa(s.Lines)).SetAlign(tview.AlignRight))
+		table.SetCell(
+			row,
+			10,
+			tview.NewTableCell(horizontalBar(s.Lines, maxLines, 20)).
+				SetTextColor(tcell.GetColor("#73d4e9")).
+				SetExpansion(1),
+		)
+	}
+}
+
+// sessionVersion renders versions that were not logged, because the session's
+// startup banner is not part of the log, as a question mark.
+func sessionVersion(version string) string {
+	if version == "" {
+		return "?"
+	}
+	return version
+}
+
+func sessionDuration(s session.Session) string {
+	return s.Duration().Round(time.Millisecond).String()
+}
+
+func (t *TUI) sessionsInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	t.logger.Trace("received key event in sessions view", "key", event.Name(), "rune", event.Rune())
+
+	switch event.Key() {
+	case tcell.KeyEsc, tcell.KeyBackspace, tcell.KeyBackspace2:
+		t.returnToLines()
+		return nil
+	}
+
+	switch event.Rune() {
+	case ' ':
+		row, _ := sessionsTable.GetSelection()
+		if row < 1 || row > len(sessionsList) {
+			return nil
+		}
+		id := sessionsList[row-1].Id
+		if sessionsMarked[id] == true {
+			delete(sessionsMarked, id)
+		} else {
+			sessionsMarked[id] = true
+		}
+		t.renderSessions()
+		if row < len(sessionsList) {
+			sessionsTable.Select(row+1, 0)
+		}
+		return nil
+
+	case 'a':
+		t.scopeToSession(0)
+		return nil
+
+	case 'c':
+		t.showSessionComparison()
+		return nil
+	}
+
+	return remapVimKeys(event)
+}
+
+// sessionSelected scopes every view to the selected session.
+func (t *TUI) sessionSelected(row int) {
+	if row < 1 || row > len(sessionsList) {
+		return
+	}
+	t.scopeToSession(sessionsList[row-1].Id)
+}
+
+// scopeToSession limits the lines, and every view derived from them, to the
+// lines of the session with the given id. Zero removes the limit. The current
+// filter is kept.
+func (t *TUI) scopeToSession(id int) {
+	t.session = id
+	t.showFilteredLines(t.filter)
+}
+
+// showSessionComparison compares the marked sessions, or all sessions when
+// fewer than two are marked.
+func (t *TUI) showSessionComparison() {
+	ids := make([]int, 0, len(sessionsList))
+	for _, s := range sessionsList {
+		if len(sessionsMarked) < 2 || sessionsMarked[s.Id] == true {
+			ids = append(ids, s.Id)
+		}
+	}
+
+	db := t.db
+	var summaries []database.SessionSummary
+	t.runInBackground(
+		"comparing sessions",
+		func(ctx context.Context) error {
+			var err error
+			summaries, err = db.SummarizeSessions(ctx, ids)
+			return err
+		},
+		func(err error) {
+			if err != nil {
+				t.showError(err, "Could not compare sessions: %s", err.Error())
+				return
+			}
+
+			renderSessionComparison(summaries)
+			sessionComparisonTable.Select(1, 0)
+			sessionComparisonTable.ScrollToBeginning()
+			sessionsPrevStatus = t.prevPageStatus
+			t.showPage(PAGE_SESSION_DIFF, fmt.Sprintf("sessions -- comparing %d sessions", len(summaries)))
+			t.App.SetFocus(sessionComparisonTable)
+		},
+	)
+}
+
+// renderSessionComparison renders one column per session and one row per
+// attribute. Attributes that differ from those of the first session are
+// highlighted.
+func renderSessionComparison(summaries []database.SessionSummary) {
+	table := sessionComparisonTable
+	table.Clear()
+	table.SetCell(0, 0, dashboardHeaderCell(""))
+	for i, summary := range summaries {
+		table.SetCell(0, i+1, dashboardHeaderCell(fmt.Sprintf("Session %d", summary.Id)).SetAlign(tview.AlignRight))
+	}
+
+	row := 1
+	addRow := func(label string, highlight bool, value func(database.SessionSummary) string) {
+		table.SetCell(row, 0, tview.NewTableCell(label).SetTextColor(tcell.GetColor("#BB5FB9")))
+		for i, summary := range summaries {
+			text := value(summary)
+			cell := tview.NewTableCell(text).SetAlign(tview.AlignRight)
+			if highlight == true && i > 0 && text != value(summaries[0]) {
+				cell.SetTextColor(tcell.GetColor("#F57F17"))
+			}
+			table.SetCell(row, i+1, cell)
+		}
+		row++
+	}
+	addSection := func(label string) {
+		table.SetCell(row, 0, dashboardHeaderCell(label))
+		row++
+	}
+
+	addRow("Pid", false, func(s database.SessionSummary) string { return strconv.Itoa(s.Pid) })
+	addRow("Host", true, func(s database.SessionSummary) string { return s.Hostname })
+	addRow("Agent version", true, func(s database.SessionSummary) string { return sessionVersion(s.AgentVersion) })
+	addRow("Node version", true, func(s database.SessionSummary) string { return sessionVersion(s.NodeVersion) })
+	addRow("Start", false, func(s database.SessionSummary) string { return templateTime(s.Start) })
+	addRow("End", false, func(s database.SessionSummary) string { return templateTime(s.End) })
+	addRow("Duration", false, func(s database.SessionSummary) string { return sessionDuration(s.Session) })
+	addRow("Lines", false, func(s database.SessionSummary) string { return strconv.Itoa(s.Lines) })
+
+	addSection("Levels")
+	for _, level := range timeBucketLevels {
+		addRow(facetValueLabel(database.FacetLevel, strconv.Itoa(level)), true, func(s database.SessionSummary) string {
+			return strconv.Itoa(s.Levels[level])
+		})
+	}
+
+	addSection("Components")
+	for _, component := range comparedComponents(summaries) {
+		addRow(facetValueLabel(database.FacetComponent, component), false, func(s database.SessionSummary) string {
+			for _, row := range s.Components {
+				if row.Value == component {
+					return strconv.Itoa(row.Count)
+				}
+			}
+			return "0"
+		})
+	}
+}
+
+// comparedComponents are the components with the most lines across all of
+// the sessions.
+func comparedComponents(summaries []database.SessionSummary) []string {
+	totals := make(map[string]int)
+	for _, summary := range summaries {
+		for _, row := range summary.Components {
+			totals[row.Value] += row.Count
+		}
+	}
+
+	components := make([]string, 0, len(totals))
+	for component := range totals {
+		components = append(components, component)
+	}
+	slices.SortFunc(components, func(a, b string) int {
+		if totals[a] != totals[b] {
+			return totals[b] - totals[a]
+		}
+		return compareFacetValues(a, b)
+	})
+	if len(components) > sessionComparisonComponents {
+		components = components[:sessionComparisonComponents]
+	}
+	return components
+}
+
+func (t *TUI) sessionComparisonInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyEsc, tcell.KeyBackspace, tcell.KeyBackspace2:
+		t.showPage(PAGE_SESSIONS, t.sessionsStatus())
+		t.prevPageStatus = sessionsPrevStatus
+		t.App.SetFocus(sessionsTable)
+		return nil
+	}
+
+	return remapVimKeys(event)
+}
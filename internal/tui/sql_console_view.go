@@ -0,0 +1,178 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/rivo/tview"
+)
+
+// sqlConsoleMaxRows limits the number of rows rendered in the results table.
+// Larger result sets are better viewed in the lines view, or with the `sql`
+// command.
+const sqlConsoleMaxRows = 1_000
+
+// sqlConsoleView runs ad-hoc SQL statements against the cache and renders
+// their results.
+type sqlConsoleView struct {
+	root      *tview.Flex
+	statement *tview.InputField
+	results   *tview.Table
+
+	// lastStatement and lastResult are the most recently executed statement
+	// and its result set. They are used to open log line results in the lines
+	// view.
+	lastStatement string
+	lastResult    *database.SQLResult
+}
+
+func (t *TUI) initSqlConsoleView() {
+	console := &sqlConsoleView{
+		root:      tview.NewFlex(),
+		statement: tview.NewInputField(),
+		results:   tview.NewTable(),
+	}
+
+	console.statement.
+		SetLabel("SQL> ").
+		SetPlaceholder("select rowid, component, message from logs where level >= 40").
+		SetFieldBackgroundColor(tcell.ColorBlack)
+	console.statement.SetBorder(true)
+	console.statement.SetTitle(" SQL (enter: run, tab: results, ctrl+o: open as lines, esc: back) ")
+	console.statement.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			t.runSqlStatement()
+		}
+	})
+
+	console.results.SetBorder(true)
+	console.results.SetTitle(" Results ")
+	console.results.SetFixed(1, 0)
+	console.results.SetSelectable(true, false)
+	console.results.SetSelectedStyle(
+		tcell.Style{}.
+			Background(tcell.GetColor("#40ea37")).
+			Foreground(tcell.ColorBlack),
+	)
+
+	console.root.SetDirection(tview.FlexRow)
+	console.root.AddItem(console.statement, 3, 0, true)
+	console.root.AddItem(console.results, 0, 1, false)
+	console.root.SetInputCapture(t.sqlConsoleInputHandler)
+
+	t.sqlConsole = console
+	t.pages.AddPage(PAGE_SQL_CONSOLE, console.root, true, false)
+}
+
+func (t *TUI) showSqlConsole() {
+	t.showPage(PAGE_SQL_CONSOLE, "sql console")
+	t.App.SetFocus(t.sqlConsole.statement)
+}
+
+func (t *TUI) sqlConsoleInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	t.logger.Trace("received key event in sql console view", "key", event.Name(), "rune", event.Rune())
+	console := t.sqlConsole
+
+	switch event.Key() {
+	case tcell.KeyEsc:
+		t.showPage(PAGE_LINES_TABLE, t.prevPageStatus)
+		t.prevPageStatus = ""
+		t.App.SetFocus(t.linesTable)
+		return nil
+
+	case tcell.KeyTab, tcell.KeyBacktab:
+		if console.statement.HasFocus() {
+			t.App.SetFocus(console.results)
+		} else {
+			t.App.SetFocus(console.statement)
+		}
+		return nil
+
+	case tcell.KeyCtrlO:
+		t.openSqlResultAsLines()
+		return nil
+	}
+
+	return event
+}
+
+// runSqlStatement executes the entered statement in the background and
+// renders its result set.
+func (t *TUI) runSqlStatement() {
+	console := t.sqlConsole
+	statement := strings.TrimSpace(console.statement.GetText())
+	if statement == "" {
+		return
+	}
+
+	t.logger.Debug("running sql console statement", "statement", statement)
+	db := t.db
+	var result *database.SQLResult
+	t.runInBackground(
+		"running statement",
+		func(ctx context.Context) error {
+			var err error
+			result, err = db.RunSQL(ctx, statement, sqlConsoleMaxRows)
+			return err
+		},
+		func(err error) {
+			if err != nil {
+				t.showError(err, "Could not run statement: %s", err.Error())
+				return
+			}
+			console.lastStatement = statement
+			console.lastResult = result
+			t.renderSqlResult(result)
+		},
+	)
+}
+
+// renderSqlResult shows the result set of a statement in the console.
+func (t *TUI) renderSqlResult(result *database.SQLResult) {
+	console := t.sqlConsole
+
+	table := console.results
+	table.Clear()
+	for i, column := range result.Columns {
+		table.SetCell(0, i, dashboardHeaderCell(column))
+	}
+	for i, row := range result.Rows {
+		for j, value := range row {
+			cell := tview.NewTableCell(tview.Escape(strings.ReplaceAll(value, "\n", `\n`))).
+				SetMaxWidth(80)
+			if value == "NULL" {
+				cell.SetTextColor(tcell.ColorGray)
+			}
+			table.SetCell(i+1, j, cell)
+		}
+	}
+	table.Select(1, 0)
+	table.ScrollToBeginning()
+
+	status := fmt.Sprintf("sql console -- %d rows", len(result.Rows))
+	if result.Truncated == true {
+		status = fmt.Sprintf("sql console -- first %d rows", len(result.Rows))
+	}
+	if result.IsLogLines() == true {
+		status += " (ctrl+o: open as lines)"
+	}
+	t.leftStatus.SetText(status)
+}
+
+// openSqlResultAsLines shows the lines identified by the last result set in
+// the lines view.
+func (t *TUI) openSqlResultAsLines() {
+	console := t.sqlConsole
+	filter, err := database.LogLinesFilter(console.lastStatement, console.lastResult)
+	if err != nil {
+		t.showError(err, "Could not open result as lines: %s", err.Error())
+		return
+	}
+
+	t.showPage(PAGE_LINES_TABLE, "")
+	t.App.SetFocus(t.linesTable)
+	t.applyFilter(filter)
+}
@@ -16,7 +16,7 @@ func (t *TUI) initStatusBarView() {
 
 	rightStatus := tview.NewTextView().
 		SetTextAlign(tview.AlignRight).
-		SetText("(s)earch | (e)xport | (h)elp").
+		SetText(rightStatusText).
 		SetTextColor(tcell.ColorBlack)
 	rightStatus.SetBackgroundColor(tcell.GetColor("#73d4e9"))
 
@@ -0,0 +1,196 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/rivo/tview"
+)
+
+// templatesSparklineWidth is the number of time buckets, and thus cells, in
+// each template's sparkline.
+const templatesSparklineWidth = 20
+
+// templatesTable lists the message templates mined from the cached lines.
+var templatesTable *tview.Table
+
+// templatesList is the set of templates currently rendered in templatesTable.
+var templatesList []database.Template
+
+func (t *TUI) initTemplatesView() {
+	table := dashboardTable(" Message templates (enter: show lines, x: hide/show lines, esc: back) ")
+	table.SetSelectedFunc(func(row int, _ int) {
+		t.templateSelected(row)
+	})
+	table.SetInputCapture(t.templatesInputHandler)
+	templatesTable = table
+	t.pages.AddPage(PAGE_TEMPLATES, table, true, false)
+}
+
+func (t *TUI) showTemplates() {
+	db := t.db
+	session := t.session
+	var templates []database.Template
+
+	t.runInBackground(
+		"mining templates",
+		func(ctx context.Context) error {
+			var err error
+			templates, err = db.Templates(ctx, templatesSparklineWidth, session)
+			return err
+		},
+		func(err error) {
+			if err != nil {
+				t.showError(err, "Could not mine message templates: %s", err.Error())
+				return
+			}
+			templatesList = templates
+
+			t.renderTemplates()
+			templatesTable.Select(1, 0)
+			templatesTable.ScrollToBeginning()
+			t.showPage(PAGE_TEMPLATES, fmt.Sprintf("templates -- %d templates", len(templates)))
+			t.App.SetFocus(templatesTable)
+		},
+	)
+}
+
+func (t *TUI) renderTemplates() {
+	table := templatesTable
+	table.Clear()
+	table.SetCell(0, 0, dashboardHeaderCell(""))
+	table.SetCell(0, 1, dashboardHeaderCell("Count").SetAlign(tview.AlignRight))
+	table.SetCell(0, 2, dashboardHeaderCell("First"))
+	table.SetCell(0, 3, dashboardHeaderCell("Last"))
+	table.SetCell(0, 4, dashboardHeaderCell("Trend"))
+	table.SetCell(0, 5, dashboardHeaderCell("Template"))
+
+	for i, template := range templatesList {
+		hidden := slices.Contains(t.filter.HiddenTemplates, template.Pattern)
+		marker := " "
+		textColor := tcell.ColorWhite
+		if hidden == true {
+			marker = "x"
+			textColor = tcell.ColorGray
+		}
+
+		table.SetCell(i+1, 0, tview.NewTableCell(marker).SetTextColor(tcell.ColorGray))
+		table.SetCell(
+			i+1,
+			1,
+			tview.NewTableCell(strconv.Itoa(template.Count)).
+				SetAlign(tview.AlignRight).
+				SetTextColor(textColor),
+		)
+		table.SetCell(i+1, 2, tview.NewTableCell(templateTime(template.First)).SetTextColor(tcell.ColorYellow))
+		table.SetCell(i+1, 3, tview.NewTableCell(templateTime(template.Last)).SetTextColor(tcell.ColorYellow))
+		table.SetCell(
+			i+1,
+			4,
+			tview.NewTableCell(sparkline(template.Histogram)).SetTextColor(tcell.GetColor("#73d4e9")),
+		)
+		table.SetCell(
+			i+1,
+			5,
+			tview.NewTableCell(tview.Escape(strings.ReplaceAll(template.Pattern, "\n", " "))).
+				SetTextColor(textColor).
+				SetExpansion(1),
+		)
+	}
+}
+
+func templateTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(time.Now().Location()).Format("2006-01-02 15:04:05")
+}
+
+// sparkline renders the values as a row of block characters whose heights are
+// proportional to the values. Non-zero values always render as at least the
+// shortest block.
+func sparkline(values []int) string {
+	blocks := []rune("▁▂▃▄▅▆▇█")
+	maxValue := slices.Max(append([]int{0}, values...))
+
+	builder := strings.Builder{}
+	for _, value := range values {
+		switch {
+		case value <= 0 || maxValue <= 0:
+			builder.WriteRune(' ')
+		default:
+			builder.WriteRune(blocks[(value*(len(blocks)-1)+maxValue-1)/maxValue])
+		}
+	}
+	return builder.String()
+}
+
+// selectedTemplate returns the template at the given row of the templates
+// table, or nil if the row is not a template, e.g. the header.
+func selectedTemplate(row int) *database.Template {
+	if row < 1 || row > len(templatesList) {
+		return nil
+	}
+	return &templatesList[row-1]
+}
+
+// templateSelected narrows the current filter to the lines with the selected
+// template, and returns to the lines table.
+func (t *TUI) templateSelected(row int) {
+	template := selectedTemplate(row)
+	if template == nil {
+		return
+	}
+
+	filter := t.filter.WithTemplate(template.Pattern)
+	t.showPage(PAGE_LINES_TABLE, "")
+	t.App.SetFocus(t.linesTable)
+	t.applyFilter(filter)
+}
+
+func (t *TUI) templatesInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	t.logger.Trace("received key event in templates view", "key", event.Name(), "rune", event.Rune())
+
+	switch event.Key() {
+	case tcell.KeyEsc, tcell.KeyBackspace, tcell.KeyBackspace2:
+		t.showPage(PAGE_LINES_TABLE, t.prevPageStatus)
+		t.prevPageStatus = ""
+		t.App.SetFocus(t.linesTable)
+		return nil
+	}
+
+	switch event.Rune() {
+	case 'x':
+		row, _ := templatesTable.GetSelection()
+		if template := selectedTemplate(row); template != nil {
+			filter := t.filter.ToggleHiddenTemplate(template.Pattern)
+			// Limiting the lines to a single template, and then hiding that
+			// template, would leave no lines to show.
+			if slices.Contains(filter.HiddenTemplates, template.Pattern) {
+				filter.Templates = slices.DeleteFunc(slices.Clone(filter.Templates), func(pattern string) bool {
+					return pattern == template.Pattern
+				})
+			}
+			t.applyFilter(filter)
+			t.renderTemplates()
+			t.prevPageStatus = t.leftStatus.GetText(false)
+			t.leftStatus.SetText(
+				fmt.Sprintf("templates -- %d templates, %d hidden", len(templatesList), len(filter.HiddenTemplates)),
+			)
+		}
+		return nil
+
+	case 'j':
+		return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+	case 'k':
+		return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+	}
+
+	return event
+}
@@ -0,0 +1,217 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/newrelic/node-log-viewer/internal/database"
+	v0 "github.com/newrelic/node-log-viewer/internal/v0"
+	"github.com/rivo/tview"
+)
+
+// timeBucketSizes are the bucket sizes the time buckets view steps through
+// with the `+` and `-` keys.
+var timeBucketSizes = []time.Duration{
+	time.Second,
+	10 * time.Second,
+	time.Minute,
+	10 * time.Minute,
+}
+
+// timeBucketLevels are the levels that get a count column in the time buckets
+// view.
+var timeBucketLevels = []int{10, 20, 30, 40, 50, 60}
+
+// timeBucketComponents is the number of components listed per bucket.
+const timeBucketComponents = 3
+
+// timeBucketsTable lists the current query's lines grouped by time bucket.
+var timeBucketsTable *tview.Table
+
+// timeBucketsList is the set of buckets currently rendered in
+// timeBucketsTable.
+var timeBucketsList []database.TimeBucket
+
+// timeBucketSizeIndex is the index, within timeBucketSizes, of the bucket size
+// in use. It is kept between invocations of the view.
+var timeBucketSizeIndex = 2
+
+func (t *TUI) initTimeBucketsView() {
+	table := dashboardTable(" Time buckets (enter: zoom in, +/-: bucket size, esc: back) ")
+	table.SetSelectedFunc(func(row int, _ int) {
+		t.timeBucketSelected(row)
+	})
+	table.SetInputCapture(t.timeBucketsInputHandler)
+	timeBucketsTable = table
+	t.pages.AddPage(PAGE_TIME_BUCKETS, table, true, false)
+}
+
+// showTimeBuckets groups the lines of the current query into time buckets,
+// and shows the time buckets page. The bucket containing selectTime is
+// selected, when it is not zero.
+func (t *TUI) showTimeBuckets(selectTime time.Time) {
+	if t.linesLoaded() == false {
+		return
+	}
+
+	query := t.query
+	size := timeBucketSizes[timeBucketSizeIndex]
+	var buckets []database.TimeBucket
+
+	t.runInBackground(
+		"bucketing lines",
+		func(ctx context.Context) error {
+			var err error
+			buckets, err = query.TimeBuckets(ctx, size, timeBucketComponents)
+			return err
+		},
+		func(err error) {
+			if err != nil {
+				t.showError(err, "Could not group lines into time buckets: %s", err.Error())
+				return
+			}
+			timeBucketsList = buckets
+
+			t.renderTimeBuckets(size)
+			timeBucketsTable.Select(timeBucketRow(buckets, size, selectTime), 0)
+			status := fmt.Sprintf("time buckets -- %d buckets of %s", len(buckets), timeBucketLabel(size))
+			// Changing the bucket size re-renders the page in place, which
+			// must keep the lines table status to return to.
+			if name, _ := t.pages.GetFrontPage(); name == PAGE_TIME_BUCKETS {
+				t.leftStatus.SetText(status)
+				return
+			}
+			t.showPage(PAGE_TIME_BUCKETS, status)
+			t.App.SetFocus(timeBucketsTable)
+		},
+	)
+}
+
+// timeBucketRow finds the table row of the bucket that contains the given
+// time. The first row is returned when no bucket contains it.
+func timeBucketRow(buckets []database.TimeBucket, size time.Duration, target time.Time) int {
+	for i, bucket := range buckets {
+		if target.Before(bucket.Start) == false && target.Before(bucket.Start.Add(size)) {
+			return i + 1
+		}
+	}
+	return 1
+}
+
+func (t *TUI) renderTimeBuckets(size time.Duration) {
+	table := timeBucketsTable
+	table.Clear()
+	table.ScrollToBeginning()
+
+	table.SetCell(0, 0, dashboardHeaderCell(timeBucketLabel(size)+" bucket"))
+	table.SetCell(0, 1, dashboardHeaderCell("Lines").SetAlign(tview.AlignRight))
+	for i, level := range timeBucketLevels {
+		name := v0.LevelFromNumber(level).String()
+		table.SetCell(0, i+2, dashboardHeaderCell(name).SetAlign(tview.AlignRight))
+	}
+	column := len(timeBucketLevels) + 2
+	table.SetCell(0, column, dashboardHeaderCell("Top components"))
+	table.SetCell(0, column+1, dashboardHeaderCell("First error"))
+
+	for i, bucket := range timeBucketsList {
+		row := i + 1
+		table.SetCell(row, 0, tview.NewTableCell(templateTime(bucket.Start)).SetTextColor(tcell.ColorYellow))
+		table.SetCell(row, 1, tview.NewTableCell(strconv.Itoa(bucket.Count)).SetAlign(tview.AlignRight))
+
+		for j, level := range timeBucketLevels {
+			count := ""
+			if bucket.Levels[level] > 0 {
+				count = strconv.Itoa(bucket.Levels[level])
+			}
+			table.SetCell(
+				row,
+				j+2,
+				tview.NewTableCell(count).
+					SetAlign(tview.AlignRight).
+					SetTextColor(levelColor(v0.LevelFromNumber(level))),
+			)
+		}
+
+		components := make([]string, 0, len(bucket.Components))
+		for _, component := range bucket.Components {
+			components = append(components, fmt.Sprintf("%s (%d)", facetValueLabel(database.FacetComponent, component.Value), component.Count))
+		}
+		table.SetCell(row, column, tview.NewTableCell(tview.Escape(strings.Join(components, ", "))))
+		table.SetCell(
+			row,
+			column+1,
+			tview.NewTableCell(tview.Escape(strings.ReplaceAll(bucket.FirstError, "\n", " "))).
+				SetTextColor(levelColor(v0.LevelFromNumber(50))).
+				SetExpansion(1),
+		)
+	}
+}
+
+// timeBucketLabel renders bucket sizes compactly, e.g. "10s" or "1m".
+func timeBucketLabel(size time.Duration) string {
+	label := size.String()
+	if strings.HasSuffix(label, "m0s") {
+		label = strings.TrimSuffix(label, "0s")
+	}
+	if strings.HasSuffix(label, "h0m") {
+		label = strings.TrimSuffix(label, "0m")
+	}
+	return label
+}
+
+// selectedTimeBucket returns the bucket at the given row of the time buckets
+// table, if there is one.
+func selectedTimeBucket(row int) (database.TimeBucket, bool) {
+	if row < 1 || row > len(timeBucketsList) {
+		return database.TimeBucket{}, false
+	}
+	return timeBucketsList[row-1], true
+}
+
+func (t *TUI) timeBucketsInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	t.logger.Trace("received key event in time buckets view", "key", event.Name(), "rune", event.Rune())
+
+	switch event.Key() {
+	case tcell.KeyEsc, tcell.KeyBackspace, tcell.KeyBackspace2:
+		t.returnToLines()
+		return nil
+	}
+
+	step := 0
+	switch event.Rune() {
+	case '+', '=':
+		step = 1
+	case '-':
+		step = -1
+	default:
+		return remapVimKeys(event)
+	}
+
+	index := timeBucketSizeIndex + step
+	if index < 0 || index >= len(timeBucketSizes) {
+		return nil
+	}
+	timeBucketSizeIndex = index
+
+	row, _ := timeBucketsTable.GetSelection()
+	bucket, _ := selectedTimeBucket(row)
+	t.showTimeBuckets(bucket.Start)
+	return nil
+}
+
+// timeBucketSelected narrows the current filter to the lines logged within the
+// selected bucket, and returns to the lines table.
+func (t *TUI) timeBucketSelected(row int) {
+	bucket, found := selectedTimeBucket(row)
+	if found == false {
+		return
+	}
+
+	size := timeBucketSizes[timeBucketSizeIndex]
+	filter := t.filter.WithTimeRange(bucket.Start, bucket.Start.Add(size))
+	t.showFilteredLines(filter)
+}
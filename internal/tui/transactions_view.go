@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/transaction"
+	"github.com/rivo/tview"
+)
+
+// transactionsTree shows the segment tree of each transaction.
+var transactionsTree *tview.TreeView
+
+// transactionsList is the set of transactions currently rendered in
+// transactionsTree. Each node references the index of its transaction.
+var transactionsList []transaction.Transaction
+
+func (t *TUI) initTransactionsView() {
+	tree := tview.NewTreeView()
+	tree.SetBorder(true)
+	tree.SetTitle(" Transactions (enter: show transaction lines, space: expand/collapse, esc: back) ")
+	tree.SetGraphicsColor(tcell.ColorGray)
+	tree.SetSelectedFunc(func(node *tview.TreeNode) {
+		t.transactionSelected(node)
+	})
+	tree.SetInputCapture(t.transactionsInputHandler)
+	transactionsTree = tree
+	t.pages.AddPage(PAGE_TRANSACTIONS, tree, true, false)
+}
+
+func (t *TUI) showTransactions() {
+	db := t.db
+	session := t.session
+	var transactions []transaction.Transaction
+
+	t.runInBackground(
+		"reconstructing transactions",
+		func(ctx context.Context) error {
+			var err error
+			transactions, err = db.Transactions(ctx, session)
+			return err
+		},
+		func(err error) {
+			if err != nil {
+				t.showError(err, "Could not reconstruct transactions: %s", err.Error())
+				return
+			}
+			if len(transactions) == 0 {
+				t.showError(nil, "No transactions were traced. Segments are only logged at the trace level.")
+				return
+			}
+			transactionsList = transactions
+
+			renderTransactions()
+			t.showPage(PAGE_TRANSACTIONS, fmt.Sprintf("transactions -- %d transactions", len(transactions)))
+			t.App.SetFocus(transactionsTree)
+		},
+	)
+}
+
+func renderTransactions() {
+	root := tview.NewTreeNode("transactions").SetSelectable(false)
+	for i, tx := range transactionsList {
+		name := tx.Name
+		if name == "" {
+			name = "<unnamed>"
+		}
+		text := fmt.Sprintf(
+			"[yellow]%s[-] %s %s [gray]%s, %d segments, pid %d[-]",
+			tx.Start.In(time.Now().Location()).Format("15:04:05.000"),
+			tx.Id,
+			tview.Escape(name),
+			tx.Duration().Round(time.Microsecond),
+			tx.SegmentCount(),
+			tx.Pid,
+		)
+		if tx.URL != "" {
+			text += " [gray]" + tview.Escape(tx.URL) + "[-]"
+		}
+		node := tview.NewTreeNode(text).SetReference(i).SetExpanded(false)
+		addSegmentNodes(node, tx.Segments, tx.Start, i)
+		root.AddChild(node)
+	}
+
+	transactionsTree.SetRoot(root)
+	transactionsTree.SetTopLevel(1)
+	transactionsTree.SetCurrentNode(root.GetChildren()[0])
+}
+
+// addSegmentNodes adds the segments to the node, each labeled with its offset
+// from the start of the transaction and its duration.
+func addSegmentNodes(node *tview.TreeNode, segments []*transaction.Segment, start time.Time, index int) {
+	for _, segment := range segments {
+		text := fmt.Sprintf(
+			"%s [gray]+%s %s[-]",
+			tview.Escape(segment.Name),
+			segment.Start.Sub(start).Round(time.Microsecond),
+			segment.Duration().Round(time.Microsecond),
+		)
+		if segment.Module != "" {
+			text += " [#BB5FB9]" + tview.Escape(segment.Module) + "[-]"
+		}
+		child := tview.NewTreeNode(text).SetReference(index)
+		addSegmentNodes(child, segment.Children, start, index)
+		node.AddChild(child)
+	}
+}
+
+func (t *TUI) transactionsInputHandler(event *tcell.EventKey) *tcell.EventKey {
+	t.logger.Trace("received key event in transactions view", "key", event.Name(), "rune", event.Rune())
+
+	switch event.Key() {
+	case tcell.KeyEsc, tcell.KeyBackspace, tcell.KeyBackspace2:
+		t.returnToLines()
+		return nil
+	}
+
+	switch event.Rune() {
+	case ' ':
+		if node := transactionsTree.GetCurrentNode(); node != nil {
+			node.SetExpanded(!node.IsExpanded())
+		}
+		return nil
+	}
+
+	return remapVimKeys(event)
+}
+
+// transactionSelected shows the lines logged while the transaction of the
+// selected node was in flight, see [database.TransactionFilter].
+func (t *TUI) transactionSelected(node *tview.TreeNode) {
+	index, ok := node.GetReference().(int)
+	if ok == false || index >= len(transactionsList) {
+		return
+	}
+
+	filter := database.TransactionFilter(transactionsList[index])
+	t.showFilteredLines(filter)
+}
@@ -63,6 +63,21 @@ const (
 	FATAL = 60
 )
 
+// LevelFromNumber creates a [Level] from its numeric representation, e.g.
+// 30 for "info".
+func LevelFromNumber(number int) *Level {
+	return &Level{number: number}
+}
+
+// Number returns the numeric representation of the level. A nil level, i.e.
+// a line without a level, is represented as 0.
+func (l *Level) Number() int {
+	if l == nil {
+		return 0
+	}
+	return l.number
+}
+
 func (l *Level) IsDebug() bool {
 	return l.number == DEBUG
 }
@@ -250,10 +265,15 @@ func LooksLikeDataIncludedLine(envelope any) bool {
 	return l.OtherFields["data"] != nil
 }
 
+// matchEmbeddedDataStart and matchEmbeddedDataEnd match the quotes around
+// serialized JSON inlined in a message.
+var matchEmbeddedDataStart = regexp.MustCompile(`"[{\[]`)
+var matchEmbeddedDataEnd = regexp.MustCompile(`[}\]]"`)
+
 func LooksLikeEmbeddedDataIncludedLine(envelope any) bool {
 	l := envelopeToLineEnvelope(envelope)
-	hasStart, _ := regexp.MatchString(`"[{\[]`, l.LogMessage)
-	hasEnd, _ := regexp.MatchString(`[}\]]"`, l.LogMessage)
+	hasStart := matchEmbeddedDataStart.MatchString(l.LogMessage)
+	hasEnd := matchEmbeddedDataEnd.MatchString(l.LogMessage)
 	return hasStart == true && hasEnd == true
 }
 
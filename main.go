@@ -1,7 +1,7 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,15 +9,20 @@ import (
 	"log/slog"
 	"os"
 	"path"
-	"regexp"
 	"runtime/pprof"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 
+	"github.com/newrelic/node-log-viewer/internal/console"
 	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/doctor"
+	"github.com/newrelic/node-log-viewer/internal/filters"
+	"github.com/newrelic/node-log-viewer/internal/history"
+	"github.com/newrelic/node-log-viewer/internal/ingest"
 	log "github.com/newrelic/node-log-viewer/internal/log"
 	"github.com/newrelic/node-log-viewer/internal/misc"
 	"github.com/newrelic/node-log-viewer/internal/tui"
-	v0 "github.com/newrelic/node-log-viewer/internal/v0"
 	"github.com/spf13/afero"
 	flag "github.com/spf13/pflag"
 )
@@ -68,6 +73,10 @@ func run(args []string) error {
 	logger.Debug("app info", "flags", flags.String(), "pid", os.Getpid())
 
 	db, err = initializeDatabase(logger)
+	if err != nil {
+		logger.Error("could not open cache file", "error", err)
+		return err
+	}
 	logger.Info("cache file created", "cache-file", db.DatabaseFile)
 	defer shutdownDatabase(db, logger)
 
@@ -80,13 +89,16 @@ func run(args []string) error {
 	if hasCachedLogs == false {
 		logger.Trace("no cached logs found")
 		var inputFile io.ReadCloser
+		var inputFilePath string
 		switch {
 		case flags.InputFile != "":
 			logger.Debug("attempting to parse log file (-f)", "log-file", flags.InputFile)
+			inputFilePath = flags.InputFile
 			inputFile, err = openLogFile(flags.InputFile, logger)
 
 		case len(flags.PositionalArgs) == 1:
 			logger.Debug("attempting to parse log file (positional)", "log-file", flags.PositionalArgs[0])
+			inputFilePath = flags.PositionalArgs[0]
 			inputFile, err = openLogFile(flags.PositionalArgs[0], logger)
 		}
 
@@ -96,6 +108,15 @@ func run(args []string) error {
 		}
 		defer inputFile.Close()
 
+		if flags.IndexOnly == true {
+			logger.Debug("indexing log file without storing lines", "log-file", inputFilePath)
+			err = db.SetSourceFile(inputFilePath)
+			if err != nil {
+				logger.Error("could not index log file", "error", err)
+				return err
+			}
+		}
+
 		// We need to know the size of the file to be parsed so that we can
 		// display a progress indicator while reading the file. Given that we
 		// define `inputFile` as an `io.ReadCloser`, we don't know if we are
@@ -118,18 +139,43 @@ func run(args []string) error {
 		}
 	}
 
-	if flags.DumpRemotePayloads == true {
-		logger.Debug("dumping remote payloads")
-		err = dumpRemotePayloads(db, logger, os.Stdout)
+	if flags.Command == "harvests" {
+		logger.Debug("printing harvests")
+		return printHarvests(db, os.Stdout)
+	}
+
+	if flags.Command == "instrumentation" {
+		logger.Debug("printing instrumentation inventory")
+		return printInstrumentation(db, os.Stdout)
+	}
+
+	if flags.Command == "doctor" {
+		logger.Debug("diagnosing sessions")
+		return printDoctor(db, flags.Format, os.Stdout)
+	}
+
+	if flags.Command == "sql" {
+		logger.Debug("starting sql console")
+		return runConsole(db, logger)
+	}
+
+	filterStore := newFilterStore(db, logger)
+	tuiOptions := []tui.Option{
+		tui.WithFilterStore(filterStore),
+		tui.WithSearchHistory(newSearchHistory(logger)),
+	}
+	if flags.Filter != "" {
+		saved, err := filterStore.Find(flags.Filter)
 		if err != nil {
-			logger.Error("error dumping remote payloads", "error", err)
+			logger.Error("could not load requested filter", "filter", flags.Filter, "error", err)
+			return err
 		}
-		return nil
+		tuiOptions = append(tuiOptions, tui.WithFilter(saved.Filter))
 	}
 
 	logger.Debug("starting tui")
-	ui := tui.NewTUI(db, logger)
-	err = ui.App.Run()
+	ui := tui.NewTUI(db, logger, tuiOptions...)
+	err = ui.Run()
 	if err != nil {
 		logger.Error("tui application error", "error", err)
 		return err
@@ -167,6 +213,63 @@ func initializeDatabase(logger *log.Logger) (*database.LogsDatabase, error) {
 	return d, nil
 }
 
+// newFilterStore creates a store for the saved filters available in the
+// user's config file, the cache file, and the defaults shipped with the
+// application.
+func newFilterStore(db *database.LogsDatabase, logger *log.Logger) *filters.Store {
+	configFile, err := filters.DefaultConfigFile()
+	if err != nil {
+		logger.Warn("saved filters config file is not available", "error", err)
+	}
+
+	return filters.NewStore(filters.StoreParams{
+		Fs:         fs,
+		ConfigFile: configFile,
+		Database:   db,
+		Logger:     logger,
+	})
+}
+
+// runConsole reads SQL statements from stdin and writes their results to
+// stdout. Prompts are only shown when stdin is a terminal so that statements
+// may also be piped in.
+func runConsole(db *database.LogsDatabase, logger *log.Logger) error {
+	interactive := false
+	if info, err := os.Stdin.Stat(); err == nil {
+		interactive = info.Mode()&os.ModeCharDevice != 0
+	}
+
+	sqlConsole := console.New(console.Params{
+		Database:    db,
+		Input:       os.Stdin,
+		Output:      os.Stdout,
+		Interactive: interactive,
+		Logger:      logger,
+	})
+	return sqlConsole.Run()
+}
+
+// newSearchHistory loads the user's history of executed searches. If the
+// history file is not available, searches are only retained for the current
+// session.
+func newSearchHistory(logger *log.Logger) *history.History {
+	historyFile, err := history.DefaultFile()
+	if err != nil {
+		logger.Warn("search history file is not available", "error", err)
+	}
+
+	searchHistory := history.New(history.Params{
+		Fs:     fs,
+		File:   historyFile,
+		Logger: logger,
+	})
+	err = searchHistory.Load()
+	if err != nil {
+		logger.Warn("could not load search history", "error", err)
+	}
+	return searchHistory
+}
+
 func shutdownDatabase(db *database.LogsDatabase, logger *log.Logger) {
 	db.Close()
 	if flags.KeepCacheFile == true {
@@ -188,8 +291,6 @@ func openLogFile(filePath string, logger *log.Logger) (io.ReadCloser, error) {
 	return fs.Open(filePath)
 }
 
-var matchLeadingK8sTimestamp = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}\s\d{2}:\d{2}:\d{2}\.\d{3,}\s+?`)
-
 // progressReader wraps an io.Reader and displays progress as bytes are read.
 type progressReader struct {
 	reader      io.Reader
@@ -234,101 +335,170 @@ func parseLogFile(logFile io.Reader, fileSize int64, db *database.LogsDatabase,
 		}
 	}
 
-	scanBuffer := make([]byte, 0, 64*1_024)
-	scanner := bufio.NewScanner(reader)
-	scanner.Buffer(scanBuffer, 1_024*1_024) // Scan up to 1MB.
+	return ingest.Read(reader, db, logger)
+}
 
-	bufferLimit := 1_000
-	parsedLinesBuffer := make([]database.InsertTuple, 0)
+// printHarvests writes the harvests stitched from the cached lines as a table.
+// Times are in UTC, and durations are in milliseconds, so that the output is
+// easy to process further.
+func printHarvests(db *database.LogsDatabase, writer io.Writer) error {
+	harvests, err := db.Harvests(context.Background(), 0)
+	if err != nil {
+		return fmt.Errorf("failed to stitch harvests: %w", err)
+	}
 
-	// TODO: the scanner only scans up to a maximum number of bytes before it
-	// gives up looking for the token (`\n` in our case) and generates a
-	// `bufio.ErrTooLong` error. We should instead use `bufio.Reader.ReadString`
-	// to parse line by line. But that will require a bit of refactoring of this
-	// loop. For now, 2025-05-28, it's simpler to increase the maximum byte limit.
-	// See:
-	// + https://stackoverflow.com/a/37455465
-	// + https://stackoverflow.com/a/6143530
-	for scanner.Scan() {
-		err := scanner.Err()
-		if err != nil {
-			logger.Error("failed to scan input", "error", err)
-			return err
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "START\tPID\tMETHOD\tDURATION_MS\tPAYLOAD_BYTES\tCOMPRESSED\tOUTCOME\tSTATUS\tAMBIGUOUS")
+	for _, h := range harvests {
+		duration := "-"
+		if h.End.IsZero() == false {
+			duration = strconv.FormatInt(h.Duration().Milliseconds(), 10)
+		}
+		status := "-"
+		if h.Status != 0 {
+			status = strconv.Itoa(h.Status)
 		}
+		fmt.Fprintf(
+			table,
+			"%s\t%d\t%s\t%s\t%d\t%t\t%s\t%s\t%t\n",
+			h.Start.UTC().Format("2006-01-02T15:04:05.000Z"),
+			h.Pid,
+			h.Method,
+			duration,
+			h.PayloadBytes,
+			h.Compressed,
+			h.Outcome,
+			status,
+			h.Ambiguous,
+		)
+	}
+	return table.Flush()
+}
 
-		// TODO: when we have a v1 line type, we need to do some text inspection
-		// to determine the type of log line to unmarshal. This may mean we need
-		// different viewers, as well.
-		var envelope *v0.LineEnvelope
-		sourceBytes := scanner.Bytes()
-		sourceString := string(sourceBytes)
+// printInstrumentation writes the inventory of instrumented modules as a
+// table, followed by the issues logged for each module. Modules that are
+// missing from the inventory were never loaded after the agent.
+func printInstrumentation(db *database.LogsDatabase, writer io.Writer) error {
+	modules, err := db.Instrumentation(context.Background(), 0)
+	if err != nil {
+		return fmt.Errorf("failed to build instrumentation inventory: %w", err)
+	}
 
-		if len(sourceString) == 0 {
-			// Skip empty lines in the source file.
-			continue
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "MODULE\tFRAMEWORK\tSHIMS\tPROPERTIES\tWRAPPED\tISSUES\tSTATUS")
+	for _, m := range modules {
+		framework := "-"
+		if m.Framework != "" {
+			framework = m.Framework
 		}
-		if matchLeadingK8sTimestamp.MatchString(sourceString) == true {
-			// Looks like the line starts with a k8s style timestamp. So we
-			// trim it off.
-			idx := strings.Index(sourceString, "{")
-			if idx == -1 {
-				logger.Warn("line with leading timestamp does not seem to be a log line", "line", sourceString)
-				continue
-			}
-			logger.Debug("trimming leading timestamp", "line", sourceString)
-			sourceString = sourceString[idx:]
-		}
-		if sourceString[0:1] != "{" || sourceString[len(sourceString)-1:] != "}" {
-			// Skip lines that do not look like NDJSON.
-			logger.Warn("skipping parsing of malformed line", "line", sourceString)
-			continue
+		shims := "-"
+		if len(m.Shims) > 0 {
+			shims = strings.Join(m.Shims, ",")
 		}
+		fmt.Fprintf(
+			table,
+			"%s\t%s\t%s\t%d\t%d\t%d\t%s\n",
+			m.Name,
+			framework,
+			shims,
+			m.Properties,
+			len(m.Wrapped),
+			len(m.Issues),
+			m.Status(),
+		)
+	}
+	err = table.Flush()
+	if err != nil {
+		return err
+	}
 
-		err = json.Unmarshal([]byte(sourceString), &envelope)
-		if err != nil {
-			logger.Warn("failed to parse line", "error", err, "line", sourceString)
+	for _, m := range modules {
+		if len(m.Issues) == 0 {
 			continue
 		}
-
-		if len(parsedLinesBuffer) < bufferLimit {
-			parsedLinesBuffer = append(parsedLinesBuffer, database.InsertTuple{
-				ParsedLog: envelope,
-				Source:    sourceString,
-			})
-		} else {
-			err = db.BatchInsert(parsedLinesBuffer)
-			if err != nil {
-				return err
-			}
-			parsedLinesBuffer = []database.InsertTuple{{
-				ParsedLog: envelope,
-				Source:    sourceString,
-			}}
+		fmt.Fprintf(writer, "\n%s issues:\n", m.Name)
+		for _, issue := range m.Issues {
+			fmt.Fprintf(writer, "  %s  %s\n", issue.Time.UTC().Format("2006-01-02T15:04:05.000Z"), issue.Message)
 		}
 	}
+	return nil
+}
 
-	if len(parsedLinesBuffer) > 0 {
-		err := db.BatchInsert(parsedLinesBuffer)
-		if err != nil {
-			return err
+// maxEvidence is the number of evidence rows listed for each finding in the
+// text and Markdown reports. The JSON report lists all of them.
+const maxEvidence = 20
+
+// printDoctor runs the built-in rules and writes their findings in the
+// format, one of [doctorFormats].
+func printDoctor(db *database.LogsDatabase, format string, writer io.Writer) error {
+	findings, err := db.Diagnose(context.Background(), 0)
+	if err != nil {
+		return fmt.Errorf("failed to diagnose sessions: %w", err)
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(findings)
+
+	case "markdown":
+		fmt.Fprintln(writer, "# Agent Log Health Report")
+		fmt.Fprintln(writer)
+		fmt.Fprintln(writer, doctorSummary(findings))
+		for _, f := range findings {
+			fmt.Fprintf(writer, "\n## %s: %s\n\n", strings.ToUpper(f.Severity.String()), f.Title)
+			fmt.Fprintf(writer, "- Rule: `%s`\n", f.Rule)
+			fmt.Fprintf(writer, "- Scope: %s\n", doctorSession(f.Session))
+			fmt.Fprintf(writer, "- Evidence: rows %s\n\n", doctorEvidence(f.LogIds, "`"))
+			fmt.Fprintln(writer, f.Explanation)
 		}
+		return nil
 	}
 
-	logger.Debug("finished reading log lines from input")
+	for _, f := range findings {
+		fmt.Fprintf(writer, "[%s] %s (%s, %s)\n", f.Severity, f.Title, doctorSession(f.Session), f.Rule)
+		fmt.Fprintf(writer, "  %s\n", f.Explanation)
+		fmt.Fprintf(writer, "  Evidence: rows %s\n\n", doctorEvidence(f.LogIds, ""))
+	}
+	fmt.Fprintln(writer, doctorSummary(findings))
 	return nil
 }
 
-func dumpRemotePayloads(db *database.LogsDatabase, logger *log.Logger, writer io.Writer) error {
-	// TODO: if we implement a search by "component", utilize that here instead
-	query := database.SearchQuery("remote_method", db, logger)
-	rows, err := query.AllResults()
-	if err != nil {
-		return fmt.Errorf("failed to search logs for remote payloads: %w", err)
+func doctorSummary(findings []doctor.Finding) string {
+	if len(findings) == 0 {
+		return "No problems found."
 	}
+	counts := make(map[doctor.Severity]int)
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+	return fmt.Sprintf(
+		"%d findings: %d errors, %d warnings, %d info.",
+		len(findings),
+		counts[doctor.SeverityError],
+		counts[doctor.SeverityWarning],
+		counts[doctor.SeverityInfo],
+	)
+}
 
-	for _, row := range rows {
-		io.WriteString(writer, row.Original+"\n")
+func doctorSession(id int) string {
+	if id == 0 {
+		return "all sessions"
 	}
+	return "session " + strconv.Itoa(id)
+}
 
-	return nil
+// doctorEvidence lists the row ids, i.e. the `rowid` of the lines in the
+// cache, each wrapped in quote.
+func doctorEvidence(logIds []int, quote string) string {
+	ids := make([]string, 0, min(len(logIds), maxEvidence))
+	for _, id := range logIds[:min(len(logIds), maxEvidence)] {
+		ids = append(ids, quote+strconv.Itoa(id)+quote)
+	}
+	result := strings.Join(ids, ", ")
+	if len(logIds) > maxEvidence {
+		result += fmt.Sprintf(" and %d more", len(logIds)-maxEvidence)
+	}
+	return result
 }
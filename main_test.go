@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 
@@ -167,7 +168,7 @@ func Test_parseLogFile(t *testing.T) {
 		assert.Equal(t, 2, len(results))
 	})
 
-	t.Run("dumps remote_method logs to stdout", func(t *testing.T) {
+	t.Run("prints harvests to stdout", func(t *testing.T) {
 		testDb, err := database.New(database.DbParams{
 			DatabaseFilePath: "file::memory:",
 			DoMigration:      true,
@@ -187,9 +188,93 @@ func Test_parseLogFile(t *testing.T) {
 		require.Nil(t, err)
 
 		writer := &strings.Builder{}
-		err = dumpRemotePayloads(testDb, nullLogger, writer)
+		err = printHarvests(testDb, writer)
 		require.Nil(t, err)
-		logs := strings.Split(writer.String(), "\n")
-		assert.Equal(t, 384, len(logs))
+		lines := strings.Split(strings.TrimSpace(writer.String()), "\n")
+		require.Equal(t, 98, len(lines))
+		assert.Equal(
+			t,
+			[]string{"START", "PID", "METHOD", "DURATION_MS", "PAYLOAD_BYTES", "COMPRESSED", "OUTCOME", "STATUS", "AMBIGUOUS"},
+			strings.Fields(lines[0]),
+		)
+		assert.Equal(
+			t,
+			[]string{"2025-03-06T18:08:04.184Z", "99445", "preconnect", "554", "25", "false", "finished", "-", "false"},
+			strings.Fields(lines[2]),
+		)
+	})
+
+	t.Run("prints the instrumentation inventory to stdout", func(t *testing.T) {
+		testDb, err := database.New(database.DbParams{
+			DatabaseFilePath: "file::memory:",
+			DoMigration:      true,
+			Logger:           nullLogger,
+		})
+		require.Nil(t, err)
+
+		reader, err := fs.Open("testdata/v0/http-server.log")
+		require.Nil(t, err)
+
+		err = parseLogFile(reader, 0, testDb, nullLogger)
+		require.Nil(t, err)
+
+		writer := &strings.Builder{}
+		err = printInstrumentation(testDb, writer)
+		require.Nil(t, err)
+		lines := strings.Split(writer.String(), "\n")
+		assert.Equal(
+			t,
+			[]string{"MODULE", "FRAMEWORK", "SHIMS", "PROPERTIES", "WRAPPED", "ISSUES", "STATUS"},
+			strings.Fields(lines[0]),
+		)
+		assert.Equal(t, []string{"fs", "-", "Shim", "35", "35", "0", "instrumented"}, strings.Fields(lines[4]))
+		assert.Equal(t, []string{"http", "-", "-", "0", "5", "6", "partial"}, strings.Fields(lines[6]))
+		assert.Equal(t, "http issues:", lines[12])
+		assert.Equal(
+			t,
+			"  2025-03-06T18:08:04.116Z  Can't wrap Response.prototype.end from nonexistent object.",
+			lines[18],
+		)
+	})
+
+	t.Run("prints the doctor report to stdout", func(t *testing.T) {
+		testDb, err := database.New(database.DbParams{
+			DatabaseFilePath: "file::memory:",
+			DoMigration:      true,
+			Logger:           nullLogger,
+		})
+		require.Nil(t, err)
+
+		reader, err := fs.Open("testdata/v0/http-server.log")
+		require.Nil(t, err)
+
+		err = parseLogFile(reader, 0, testDb, nullLogger)
+		require.Nil(t, err)
+
+		writer := &strings.Builder{}
+		err = printDoctor(testDb, "text", writer)
+		require.Nil(t, err)
+		lines := strings.Split(strings.TrimSpace(writer.String()), "\n")
+		assert.Equal(t, "[error] Agent never started (session 2, agent-lifecycle)", lines[0])
+		assert.Equal(t, "  Evidence: rows 16, 18", lines[2])
+		assert.Equal(t, "11 findings: 2 errors, 2 warnings, 7 info.", lines[len(lines)-1])
+
+		writer.Reset()
+		err = printDoctor(testDb, "markdown", writer)
+		require.Nil(t, err)
+		lines = strings.Split(writer.String(), "\n")
+		assert.Equal(t, "# Agent Log Health Report", lines[0])
+		assert.Equal(t, "## ERROR: Agent never started", lines[4])
+		assert.Equal(t, "- Evidence: rows `16`, `18`", lines[8])
+
+		writer.Reset()
+		err = printDoctor(testDb, "json", writer)
+		require.Nil(t, err)
+		var findings []map[string]any
+		err = json.Unmarshal([]byte(writer.String()), &findings)
+		require.Nil(t, err)
+		require.Equal(t, 11, len(findings))
+		assert.Equal(t, "error", findings[0]["severity"])
+		assert.Equal(t, []any{16.0, 18.0}, findings[0]["evidence"])
 	})
 }
@@ -0,0 +1,26 @@
+// Package nrlv parses and queries New Relic Node.js agent logs. It is the
+// library form of the `nrlv` log viewer, meant for tools that need to inspect
+// agent logs without the terminal UI, e.g. bots that comment on support
+// tickets.
+//
+// A log file is read into a cache database with [Open], or [Read], and an
+// existing cache file, e.g. one created with `nrlv --keep-cache`, is opened
+// with [OpenCache]. The lines of the log are then iterated with
+// [Logs.Lines], optionally narrowed down by a [Filter]:
+//
+//	logs, err := nrlv.Open("newrelic_agent.log")
+//	if err != nil {
+//		return err
+//	}
+//	defer logs.Close()
+//
+//	for line, err := range logs.Lines(ctx, nrlv.Filter{MinLevel: nrlv.LevelWarn}) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(line.Time, line.Component, line.Message)
+//	}
+//
+// The types of this package are independent of the viewer's internals, so
+// they remain stable as the viewer changes.
+package nrlv
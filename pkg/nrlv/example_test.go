@@ -0,0 +1,68 @@
+package nrlv_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/newrelic/node-log-viewer/pkg/nrlv"
+)
+
+func Example() {
+	logs, err := nrlv.Open("../../testdata/v0/http-server.log")
+	if err != nil {
+		panic(err)
+	}
+	defer logs.Close()
+
+	filter := nrlv.Filter{MinLevel: nrlv.LevelError}
+	for line, err := range logs.Lines(context.Background(), filter) {
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(line.Level, line.Component, line.Message)
+	}
+	// Output:
+	// Error collector_api Agent endpoint metric_data returned 409 status. Restarting.
+}
+
+func ExampleLogs_Lines() {
+	logs, err := nrlv.Open("../../testdata/v0/http-server.log")
+	if err != nil {
+		panic(err)
+	}
+	defer logs.Close()
+
+	filter := nrlv.Filter{
+		Search:     "compressed = false",
+		Components: []string{"remote_method"},
+	}
+	count := 0
+	for _, err := range logs.Lines(context.Background(), filter) {
+		if err != nil {
+			panic(err)
+		}
+		count += 1
+	}
+	fmt.Println(count)
+	// Output:
+	// 96
+}
+
+func ExampleLine_Field() {
+	logs, err := nrlv.Open("../../testdata/v0/http-server.log")
+	if err != nil {
+		panic(err)
+	}
+	defer logs.Close()
+
+	filter := nrlv.Filter{Search: `data[0].utilization.hostname = "localhost"`}
+	for line, err := range logs.Lines(context.Background(), filter) {
+		if err != nil {
+			panic(err)
+		}
+		memory, _ := line.Field("data[0].utilization.total_ram_mib")
+		fmt.Println(line.Message, memory)
+	}
+	// Output:
+	// Calling connect on collector API 65536
+}
@@ -0,0 +1,70 @@
+package nrlv
+
+import (
+	"time"
+
+	"github.com/newrelic/node-log-viewer/internal/database"
+)
+
+// SearchMode indicates how the search term of a [Filter] is matched against
+// the lines.
+type SearchMode int
+
+const (
+	// SearchWords matches whole words. The term may use the SQLite FTS5 query
+	// syntax, e.g. `remote_method AND error`, and may include field
+	// predicates, e.g. `data[0].high_security = false`.
+	SearchWords SearchMode = iota
+
+	// SearchSubstring matches the term anywhere within a line, e.g. `aggreg`
+	// matches lines from the `base_aggregator` component.
+	SearchSubstring
+)
+
+func (m SearchMode) String() string {
+	return m.toDatabaseMode().String()
+}
+
+func (m SearchMode) toDatabaseMode() database.SearchMode {
+	if m == SearchSubstring {
+		return database.SearchModeSubstring
+	}
+	return database.SearchModeWords
+}
+
+// Filter narrows the lines down to the lines of interest. All of the criteria
+// that are set must be met by a line. The zero Filter matches all lines.
+type Filter struct {
+	// Search is the search term(s) a line must match, according to Mode.
+	Search string
+	Mode   SearchMode
+
+	// Components limits the lines to those logged by any of the listed
+	// components, e.g. `collector_api`.
+	Components []string
+
+	// MinLevel limits the lines to those logged at, or above, the level.
+	MinLevel Level
+
+	// Since and Until limit the lines to those logged within the time range.
+	// Since is inclusive while Until is exclusive. A zero time leaves that end
+	// of the range open.
+	Since time.Time
+	Until time.Time
+
+	// ContextLines is the number of surrounding lines to include with each
+	// matching line, similar to `grep -C`.
+	ContextLines int
+}
+
+func (f Filter) toDatabaseFilter() database.Filter {
+	return database.Filter{
+		Search:       f.Search,
+		Mode:         f.Mode.toDatabaseMode(),
+		Components:   f.Components,
+		MinLevel:     int(f.MinLevel),
+		Since:        f.Since,
+		Until:        f.Until,
+		ContextLines: f.ContextLines,
+	}
+}
@@ -0,0 +1,141 @@
+package nrlv
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/newrelic/node-log-viewer/internal/common"
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/detail"
+	v0 "github.com/newrelic/node-log-viewer/internal/v0"
+)
+
+// Level is the numeric level a line was logged at.
+type Level int
+
+const (
+	LevelTrace Level = v0.TRACE
+	LevelDebug Level = v0.DEBUG
+	LevelInfo  Level = v0.INFO
+	LevelWarn  Level = v0.WARN
+	LevelError Level = v0.ERROR
+	LevelFatal Level = v0.FATAL
+)
+
+// String returns the name of the level, e.g. "Warn". Unknown levels have an
+// empty name.
+func (l Level) String() string {
+	return v0.LevelFromNumber(int(l)).String()
+}
+
+// Kind indicates what, beyond the message, a line holds.
+type Kind int
+
+const (
+	// KindMessage lines consist solely of a message.
+	KindMessage Kind = Kind(common.TypeMessage)
+
+	// KindData lines have a `data` attribute that holds serialized JSON, e.g.
+	// the payload of a collector request.
+	KindData Kind = Kind(common.TypeDataIncluded)
+
+	// KindEmbeddedData lines have serialized JSON inlined in the message.
+	KindEmbeddedData Kind = Kind(common.TypeEmbeddedDataIncluded)
+
+	// KindError lines have attributes that describe an error.
+	KindError Kind = Kind(common.TypeError)
+
+	// KindAttributes lines have attributes in addition to the message.
+	KindAttributes Kind = Kind(common.TypeExtraAttributes)
+)
+
+// String returns a short name for the kind, e.g. "error".
+func (k Kind) String() string {
+	return common.TypeName(common.Type(k))
+}
+
+// Line is a single agent log line.
+type Line struct {
+	// Id identifies the line within its cache. Ids increase in the order the
+	// lines were read from the log.
+	Id int
+
+	Time      time.Time
+	Level     Level
+	Component string
+	Message   string
+	Kind      Kind
+	Pid       int
+	Hostname  string
+
+	// Attributes holds the attributes of the line beyond the common ones
+	// above, e.g. the `data` attribute of a collector request.
+	Attributes map[string]any
+
+	// Original is the line as it was read from the log.
+	Original string
+
+	// IsContext indicates that the line does not match the filter, and is
+	// included because it surrounds a line that does.
+	IsContext bool
+
+	envelope *v0.LineEnvelope
+}
+
+func newLine(row database.DbRow) (Line, error) {
+	line := Line{
+		Id:        row.LogId,
+		Time:      row.Time.Time,
+		Component: row.Component,
+		Message:   row.Message,
+		Original:  row.Original,
+		IsContext: row.IsHit == false,
+	}
+
+	var envelope v0.LineEnvelope
+	err := json.Unmarshal([]byte(row.Original), &envelope)
+	if err != nil {
+		return line, fmt.Errorf("could not decode line %d: %w", row.LogId, err)
+	}
+
+	line.envelope = &envelope
+	line.Kind = Kind(envelope.Kind())
+	line.Pid = envelope.Pid
+	line.Hostname = envelope.Hostname
+	line.Attributes = envelope.OtherFields
+	if envelope.LogLevel != nil {
+		line.Level = Level(envelope.LogLevel.Number())
+	}
+
+	return line, nil
+}
+
+// Field returns the value of the line's attribute at the given path, e.g.
+// `data.utilization.hostname` or `app_name[0]`. Attributes holding serialized
+// JSON are decoded as the path is followed. Values are as decoded by
+// [encoding/json], e.g. numbers are float64.
+func (l Line) Field(path string) (any, bool) {
+	var document any
+	err := json.Unmarshal([]byte(l.Original), &document)
+	if err != nil {
+		return nil, false
+	}
+	return common.LookupField(document, path)
+}
+
+// Details renders the line the way the viewer's line detail view does: the
+// message followed by a readable representation of the data attached to the
+// line, e.g. the decoded payload of a collector request.
+func (l Line) Details() (string, error) {
+	if l.envelope == nil {
+		return "", fmt.Errorf("line was not read from a log")
+	}
+
+	lines, err := detail.Lines(l.envelope)
+	if err != nil {
+		return "", fmt.Errorf("could not render line %d: %w", l.Id, err)
+	}
+	return strings.Join(lines, "\n"), nil
+}
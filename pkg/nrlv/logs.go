@@ -0,0 +1,267 @@
+package nrlv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"os"
+
+	"github.com/newrelic/node-log-viewer/internal/database"
+	"github.com/newrelic/node-log-viewer/internal/ingest"
+	"github.com/newrelic/node-log-viewer/internal/log"
+)
+
+// ErrNoLogs is returned by [OpenCache] when the cache file does not hold any
+// log lines.
+var ErrNoLogs = errors.New("cache file does not hold any log lines")
+
+// ErrCacheOutdated is returned by [OpenCache] when the cache file was created
+// by a previous version of the log viewer. Opening it with the log viewer, or
+// with [Open] and [WithCacheFile], upgrades it.
+var ErrCacheOutdated = database.ErrMigrationsPending
+
+// Logs is a set of agent log lines stored in a cache database. A Logs is safe
+// for concurrent use.
+type Logs struct {
+	db     *database.LogsDatabase
+	logger *log.Logger
+
+	// removeCache indicates that the cache file was created by the package,
+	// and should be removed when the logs are closed.
+	removeCache bool
+}
+
+type config struct {
+	cacheFile string
+	indexOnly bool
+	logger    *log.Logger
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{logger: log.NewDiscardLogger()}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type Option func(*config)
+
+// WithCacheFile stores the parsed lines in the given cache file, instead of a
+// temporary file, so that the cache outlives the [Logs]. If the cache file
+// already holds log lines, they are used as is instead of reading the log
+// again.
+func WithCacheFile(cacheFile string) Option {
+	return func(c *config) {
+		c.cacheFile = cacheFile
+	}
+}
+
+// WithIndexOnly stores only the location of each line within the log file,
+// instead of a copy of each line, in the cache. This greatly reduces the size
+// of the cache for large logs, but the log file must be kept, unchanged, for
+// as long as the cache is used; opening the cache fails with
+// [ErrSourceChanged] otherwise. It is only supported by [Open].
+func WithIndexOnly() Option {
+	return func(c *config) {
+		c.indexOnly = true
+	}
+}
+
+// WithLogger writes the package's internal logs to the given logger. By
+// default, they are discarded.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = &log.Logger{Logger: logger}
+	}
+}
+
+// ErrSourceChanged is returned when the log file of a cache created with
+// [WithIndexOnly] is missing, or has changed since the cache was created.
+var ErrSourceChanged = database.ErrSourceChanged
+
+// Open reads the agent log file at the given path into a cache database.
+func Open(logFile string, opts ...Option) (*Logs, error) {
+	file, err := os.Open(logFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not open log file: %w", err)
+	}
+	defer file.Close()
+
+	c := newConfig(opts)
+	logs, err := open(c)
+	if err != nil {
+		return nil, err
+	}
+
+	err = logs.read(file, func() error {
+		if c.indexOnly == false {
+			return nil
+		}
+		return logs.db.SetSourceFile(logFile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// Read reads agent log lines from the reader into a cache database. Lines that
+// are not agent log lines are skipped.
+func Read(reader io.Reader, opts ...Option) (*Logs, error) {
+	c := newConfig(opts)
+	if c.indexOnly == true {
+		return nil, fmt.Errorf("index only caches require a log file, see Open")
+	}
+
+	logs, err := open(c)
+	if err != nil {
+		return nil, err
+	}
+
+	err = logs.read(reader, func() error { return nil })
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// read reads the lines from the reader, unless the cache already holds lines.
+// The prepare function is invoked before the lines are read. The logs are
+// closed if an error occurs.
+func (l *Logs) read(reader io.Reader, prepare func() error) error {
+	hasCachedLogs, err := l.db.HasCachedLogs()
+	if err != nil {
+		l.Close()
+		return fmt.Errorf("could not verify cache: %w", err)
+	}
+	if hasCachedLogs == true {
+		l.logger.Debug("using lines already in cache file", "cache-file", l.db.DatabaseFile)
+		return nil
+	}
+
+	err = prepare()
+	if err != nil {
+		l.Close()
+		return fmt.Errorf("could not prepare cache: %w", err)
+	}
+
+	err = ingest.Read(reader, l.db, l.logger)
+	if err != nil {
+		l.Close()
+		return fmt.Errorf("could not read log lines: %w", err)
+	}
+	return nil
+}
+
+// OpenCache opens a cache file that was previously created by the log viewer,
+// or with [WithCacheFile]. Any [WithCacheFile] option is ignored.
+//
+// The cache is not upgraded, so a cache created by a previous version of the
+// log viewer is refused with [ErrCacheOutdated]. The lines and their indexes
+// are left as they are, but queries, e.g. [Logs.Lines], store their results
+// in tables of the cache file while they run, so the file must be writable.
+func OpenCache(cacheFile string, opts ...Option) (*Logs, error) {
+	if _, err := os.Stat(cacheFile); err != nil {
+		return nil, fmt.Errorf("could not open cache file: %w", err)
+	}
+
+	c := newConfig(append(opts, WithCacheFile(cacheFile)))
+	db, err := database.New(database.DbParams{
+		DatabaseFilePath: c.cacheFile,
+		DoMigration:      false,
+		Logger:           c.logger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not open cache database: %w", err)
+	}
+	logs := &Logs{db: db, logger: c.logger}
+
+	hasCachedLogs, err := logs.db.HasCachedLogs()
+	if err != nil {
+		logs.Close()
+		return nil, fmt.Errorf("could not verify cache: %w", err)
+	}
+	if hasCachedLogs == false {
+		logs.Close()
+		return nil, ErrNoLogs
+	}
+
+	return logs, nil
+}
+
+func open(c *config) (*Logs, error) {
+	removeCache := false
+	if c.cacheFile == "" {
+		file, err := os.CreateTemp("", "nrlv-*.sqlite")
+		if err != nil {
+			return nil, fmt.Errorf("could not create cache file: %w", err)
+		}
+		file.Close()
+		c.cacheFile = file.Name()
+		removeCache = true
+	}
+
+	db, err := database.New(database.DbParams{
+		DatabaseFilePath: c.cacheFile,
+		DoMigration:      true,
+		Logger:           c.logger,
+	})
+	if err != nil {
+		if removeCache == true {
+			os.Remove(c.cacheFile)
+		}
+		return nil, fmt.Errorf("could not open cache database: %w", err)
+	}
+
+	return &Logs{db: db, logger: c.logger, removeCache: removeCache}, nil
+}
+
+// CacheFile is the path to the cache database holding the lines.
+func (l *Logs) CacheFile() string {
+	return l.db.DatabaseFile
+}
+
+// Close closes the cache database. A temporary cache file, i.e. one not
+// given with [WithCacheFile], is removed.
+func (l *Logs) Close() error {
+	l.db.Close()
+	if l.removeCache == false {
+		return nil
+	}
+
+	err := os.Remove(l.db.DatabaseFile)
+	if err != nil {
+		return fmt.Errorf("could not remove cache file: %w", err)
+	}
+	return nil
+}
+
+// Lines iterates the lines matching the filter in the order they were
+// logged. A zero [Filter] matches all lines. When the filter includes context
+// lines, the surrounding lines of each match are included as well, see
+// [Line.IsContext].
+//
+// The iteration stops at the first error encountered while querying the
+// cache, which is yielded with a zero [Line]. An error decoding a single line
+// is yielded with that line, and the iteration continues if the caller keeps
+// ranging.
+func (l *Logs) Lines(ctx context.Context, filter Filter) iter.Seq2[Line, error] {
+	return func(yield func(Line, error) bool) {
+		query := filter.toDatabaseFilter().Query(l.db, l.logger)
+		defer query.Close()
+
+		for row, err := range query.Results(ctx) {
+			if err != nil {
+				yield(Line{}, err)
+				return
+			}
+			if yield(newLine(row)) == false {
+				return
+			}
+		}
+	}
+}
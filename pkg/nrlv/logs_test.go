@@ -0,0 +1,218 @@
+package nrlv
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collect(t *testing.T, logs *Logs, filter Filter) []Line {
+	t.Helper()
+	result := make([]Line, 0)
+	for line, err := range logs.Lines(context.Background(), filter) {
+		require.Nil(t, err)
+		result = append(result, line)
+	}
+	return result
+}
+
+func TestOpen(t *testing.T) {
+	t.Run("reads the log into a temporary cache", func(t *testing.T) {
+		logs, err := Open("../../testdata/v0/good-line.log")
+		require.Nil(t, err)
+
+		cacheFile := logs.CacheFile()
+		assert.FileExists(t, cacheFile)
+
+		lines := collect(t, logs, Filter{})
+		require.Equal(t, 1, len(lines))
+		assert.Equal(t, LevelWarn, lines[0].Level)
+		assert.Equal(t, "api", lines[0].Component)
+		assert.Equal(t, "No transaction found for custom attributes.", lines[0].Message)
+		assert.Equal(t, 33732, lines[0].Pid)
+		assert.Equal(t, "redacted", lines[0].Hostname)
+		assert.Equal(t, KindMessage, lines[0].Kind)
+		assert.Equal(t, false, lines[0].IsContext)
+		assert.Equal(t, "2025-04-16T08:06:49.424Z", lines[0].Time.UTC().Format("2006-01-02T15:04:05.000Z"))
+
+		err = logs.Close()
+		assert.Nil(t, err)
+		assert.NoFileExists(t, cacheFile)
+	})
+
+	t.Run("retains a given cache file", func(t *testing.T) {
+		cacheFile := filepath.Join(t.TempDir(), "cache.sqlite")
+		logs, err := Open("../../testdata/v0/good-line.log", WithCacheFile(cacheFile))
+		require.Nil(t, err)
+		assert.Equal(t, cacheFile, logs.CacheFile())
+		require.Nil(t, logs.Close())
+		assert.FileExists(t, cacheFile)
+
+		// The lines already in the cache are used instead of reading the log
+		// again.
+		logs, err = Read(strings.NewReader(""), WithCacheFile(cacheFile))
+		require.Nil(t, err)
+		assert.Equal(t, 1, len(collect(t, logs, Filter{})))
+		require.Nil(t, logs.Close())
+
+		logs, err = OpenCache(cacheFile)
+		require.Nil(t, err)
+		assert.Equal(t, 1, len(collect(t, logs, Filter{})))
+		require.Nil(t, logs.Close())
+	})
+
+	t.Run("reads lines from the log file of an index only cache", func(t *testing.T) {
+		logs, err := Open("../../testdata/v0/good-line.log", WithIndexOnly())
+		require.Nil(t, err)
+		defer logs.Close()
+
+		lines := collect(t, logs, Filter{Search: "transaction"})
+		require.Equal(t, 1, len(lines))
+		assert.Equal(t, "No transaction found for custom attributes.", lines[0].Message)
+		assert.Contains(t, lines[0].Original, `"pid":33732`)
+
+		_, err = Read(strings.NewReader(""), WithIndexOnly())
+		assert.NotNil(t, err)
+	})
+
+	t.Run("returns an error for a missing log file", func(t *testing.T) {
+		_, err := Open("../../testdata/v0/missing.log")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestOpenCache(t *testing.T) {
+	t.Run("returns an error for a missing cache file", func(t *testing.T) {
+		_, err := OpenCache(filepath.Join(t.TempDir(), "missing.sqlite"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("returns an error for an empty cache file", func(t *testing.T) {
+		cacheFile := filepath.Join(t.TempDir(), "cache.sqlite")
+		logs, err := Read(strings.NewReader(""), WithCacheFile(cacheFile))
+		require.Nil(t, err)
+		require.Nil(t, logs.Close())
+
+		_, err = OpenCache(cacheFile)
+		assert.ErrorIs(t, err, ErrNoLogs)
+	})
+
+	t.Run("refuses an outdated cache file without upgrading it", func(t *testing.T) {
+		cacheFile := filepath.Join(t.TempDir(), "cache.sqlite")
+		logs, err := Open("../../testdata/v0/good-line.log", WithCacheFile(cacheFile))
+		require.Nil(t, err)
+		require.Nil(t, logs.Close())
+
+		db, err := sql.Open("sqlite", cacheFile)
+		require.Nil(t, err)
+		defer db.Close()
+		_, err = db.Exec(`update schema_migrations set version = 1`)
+		require.Nil(t, err)
+
+		_, err = OpenCache(cacheFile)
+		assert.ErrorIs(t, err, ErrCacheOutdated)
+
+		var version int
+		require.Nil(t, db.QueryRow(`select version from schema_migrations`).Scan(&version))
+		assert.Equal(t, 1, version)
+	})
+}
+
+func TestLogs_Lines(t *testing.T) {
+	logs, err := Open("../../testdata/v0/http-server.log")
+	require.Nil(t, err)
+	t.Cleanup(func() {
+		logs.Close()
+	})
+
+	t.Run("iterates all lines", func(t *testing.T) {
+		lines := collect(t, logs, Filter{})
+		assert.Equal(t, 8_092, len(lines))
+		for i := 1; i < len(lines); i++ {
+			require.Less(t, lines[i-1].Id, lines[i].Id)
+		}
+	})
+
+	t.Run("filters lines", func(t *testing.T) {
+		lines := collect(t, logs, Filter{MinLevel: LevelWarn})
+		assert.Equal(t, 12, len(lines))
+
+		lines = collect(t, logs, Filter{Search: "rss > 80000000"})
+		assert.Equal(t, 1, len(lines))
+
+		lines = collect(t, logs, Filter{Search: "aggreg", Mode: SearchSubstring, Components: []string{"base_aggregator"}})
+		assert.NotEmpty(t, lines)
+		for _, line := range lines {
+			assert.Equal(t, "base_aggregator", line.Component)
+		}
+	})
+
+	t.Run("includes context lines", func(t *testing.T) {
+		lines := collect(t, logs, Filter{MinLevel: LevelError, ContextLines: 2})
+		require.Equal(t, 5, len(lines))
+		assert.Equal(t, []bool{true, true, false, true, true}, []bool{
+			lines[0].IsContext,
+			lines[1].IsContext,
+			lines[2].IsContext,
+			lines[3].IsContext,
+			lines[4].IsContext,
+		})
+		assert.Equal(t, LevelError, lines[2].Level)
+	})
+
+	t.Run("stops when the caller stops ranging", func(t *testing.T) {
+		count := 0
+		for range logs.Lines(context.Background(), Filter{}) {
+			count += 1
+			if count == 3 {
+				break
+			}
+		}
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("yields the error of a cancelled query", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var lastErr error
+		count := 0
+		for _, err := range logs.Lines(ctx, Filter{}) {
+			lastErr = err
+			count += 1
+		}
+		assert.Equal(t, 1, count)
+		assert.ErrorIs(t, lastErr, context.Canceled)
+	})
+
+	t.Run("renders details", func(t *testing.T) {
+		lines := collect(t, logs, Filter{Search: "data[0].high_security = false", Components: []string{"remote_method"}})
+		require.NotEmpty(t, lines)
+		assert.Equal(t, KindData, lines[0].Kind)
+		assert.Equal(t, "data", lines[0].Kind.String())
+
+		details, err := lines[0].Details()
+		require.Nil(t, err)
+		assert.Equal(
+			t,
+			"Calling preconnect on collector API\n\nAttributes:\n\tcompressed: false\n\nData:\n\t- high_security: false",
+			details,
+		)
+
+		value, found := lines[0].Field("data[0].high_security")
+		assert.True(t, found)
+		assert.Equal(t, false, value)
+	})
+}
+
+func TestLevel_String(t *testing.T) {
+	assert.Equal(t, "Trace", LevelTrace.String())
+	assert.Equal(t, "Fatal", LevelFatal.String())
+	assert.Equal(t, "", Level(0).String())
+}